@@ -3,8 +3,10 @@ package confy
 import (
 	"context"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 
@@ -21,8 +23,10 @@ func F(key string, value any) logger.Field {
 
 // Priority constants for configuration sources.
 const (
+	PriorityDefaults    = 0   // Compiled-in defaults from DefaultsFS (overridden by everything)
 	PriorityEnvLow      = 50  // Environment variables with lower priority (files override env)
 	PriorityBaseConfig  = 100 // Base configuration file priority
+	PriorityProfile     = 150 // First profile file; each further profile adds 2, its .local file 1
 	PriorityLocalConfig = 200 // Local configuration file priority (overrides base)
 	PriorityEnvHigh     = 300 // Environment variables with higher priority (env overrides files)
 )
@@ -45,6 +49,24 @@ type AutoDiscoveryConfig struct {
 	// Defaults to ["config.local.yaml", "config.local.yml"]
 	LocalConfigNames []string
 
+	// Profiles are the active environment profiles, applied in order
+	// For each profile "config.{profile}.yaml" and "config.{profile}.local.yaml"
+	// are loaded between the base and local config files
+	// Defaults to the first of ProfileEnvVars that is set (comma separated)
+	Profiles []string
+
+	// ProfileEnvVars are the environment variables consulted when Profiles is empty
+	// Defaults to ["CONFY_PROFILE", "APP_ENV"]
+	ProfileEnvVars []string
+
+	// DefaultsFS is an optional file system (e.g. //go:embed) holding
+	// compiled-in defaults, loaded as the lowest priority layer
+	DefaultsFS fs.FS
+
+	// DefaultsPattern selects the files of DefaultsFS to load (fs.Glob syntax)
+	// Defaults to the first of ConfigNames present in DefaultsFS
+	DefaultsPattern string
+
 	// MaxDepth is the maximum number of parent directories to search
 	// Defaults to 5
 	MaxDepth int
@@ -95,6 +117,18 @@ type AutoDiscoveryResult struct {
 	// LocalConfigPath is the path to the local config file
 	LocalConfigPath string
 
+	// Profiles are the active profiles, in the order they were applied
+	Profiles []string
+
+	// ProfileConfigs are the files discovered for the active profiles
+	ProfileConfigs []ProfileConfig
+
+	// ConfigPaths lists every config file that was loaded, lowest priority first
+	ConfigPaths []string
+
+	// DefaultsPattern is the pattern loaded from DefaultsFS, if any
+	DefaultsPattern string
+
 	// WorkingDirectory is the directory where configs were found
 	WorkingDirectory string
 
@@ -105,11 +139,24 @@ type AutoDiscoveryResult struct {
 	AppName string
 }
 
+// ProfileConfig describes the config files discovered for one profile.
+type ProfileConfig struct {
+	// Profile is the profile name
+	Profile string
+
+	// ConfigPath is the path to config.{profile}.yaml, if found
+	ConfigPath string
+
+	// LocalConfigPath is the path to config.{profile}.local.yaml, if found
+	LocalConfigPath string
+}
+
 // DefaultAutoDiscoveryConfig returns default auto-discovery configuration.
 func DefaultAutoDiscoveryConfig() AutoDiscoveryConfig {
 	return AutoDiscoveryConfig{
 		ConfigNames:      []string{"config.yaml", "config.yml"},
 		LocalConfigNames: []string{"config.local.yaml", "config.local.yml"},
+		ProfileEnvVars:   []string{"CONFY_PROFILE", "APP_ENV"},
 		MaxDepth:         5,
 		RequireBase:      false,
 		RequireLocal:     false,
@@ -146,11 +193,22 @@ func DiscoverAndLoadConfigs(cfg AutoDiscoveryConfig) (Confy, *AutoDiscoveryResul
 		cfg.SearchPaths = []string{cwd}
 	}
 
+	if cfg.ProfileEnvVars == nil {
+		cfg.ProfileEnvVars = []string{"CONFY_PROFILE", "APP_ENV"}
+	}
+
 	// Default env separator
 	if cfg.EnvSeparator == "" {
 		cfg.EnvSeparator = "_"
 	}
 
+	profiles, err := activeProfiles(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cfg.Profiles = profiles
+
 	// Discover config files
 	result, err := discoverConfigFiles(cfg)
 	if err != nil {
@@ -164,11 +222,35 @@ func DiscoverAndLoadConfigs(cfg AutoDiscoveryConfig) (Confy, *AutoDiscoveryResul
 	})
 
 	// Priority scheme:
+	// - Compiled-in defaults (DefaultsFS): 0
 	// - Base config: 100
+	// - Profile configs: 150, 151 (.local), 152, 153, ... in profile order
 	// - Local config: 200
 	// - Environment (if EnvOverridesFile=true): 300
 	// - Environment (if EnvOverridesFile=false): 50
 
+	// Load compiled-in defaults if provided (lowest priority)
+	if cfg.DefaultsFS != nil {
+		if pattern := defaultsPattern(cfg); pattern != "" {
+			source, err := sources.NewFSSource(cfg.DefaultsFS, pattern, sources.FSSourceOptions{
+				Name:          "config.defaults",
+				Priority:      PriorityDefaults,
+				ExpandEnvVars: true,
+				Logger:        cfg.Logger,
+				ErrorHandler:  cfg.ErrorHandler,
+			})
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to create defaults config source: %w", err)
+			}
+
+			if err := confy.LoadFrom(source); err != nil {
+				return nil, nil, fmt.Errorf("failed to load defaults config: %w", err)
+			}
+
+			result.DefaultsPattern = pattern
+		}
+	}
+
 	// Load base config if found
 	if result.BaseConfigPath != "" {
 		source, err := sources.NewFileSource(result.BaseConfigPath, sources.FileSourceOptions{
@@ -189,12 +271,50 @@ func DiscoverAndLoadConfigs(cfg AutoDiscoveryConfig) (Confy, *AutoDiscoveryResul
 				if cfg.RequireBase {
 					return nil, nil, fmt.Errorf("failed to load base config: %w", err)
 				}
+			} else {
+				result.ConfigPaths = append(result.ConfigPaths, result.BaseConfigPath)
 			}
 		}
 	} else if cfg.RequireBase {
 		return nil, nil, errors.New("base config file required but not found")
 	}
 
+	// Load profile configs in order, between base and local
+	for i, profile := range result.ProfileConfigs {
+		layers := []struct {
+			path   string
+			suffix string
+		}{
+			{profile.ConfigPath, ""},
+			{profile.LocalConfigPath, ".local"},
+		}
+
+		for j, layer := range layers {
+			// Profile files are optional, but one that exists must load
+			if layer.path == "" || !fileExists(layer.path) {
+				continue
+			}
+
+			source, err := sources.NewFileSource(layer.path, sources.FileSourceOptions{
+				Name:          "config.profile." + profile.Profile + layer.suffix,
+				Priority:      profilePriority(i) + j,
+				WatchEnabled:  true,
+				ExpandEnvVars: true,
+				Logger:        cfg.Logger,
+				ErrorHandler:  cfg.ErrorHandler,
+			})
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to create profile config source for %q: %w", profile.Profile, err)
+			}
+
+			if err := confy.LoadFrom(source); err != nil {
+				return nil, nil, fmt.Errorf("failed to load profile config %s: %w", layer.path, err)
+			}
+
+			result.ConfigPaths = append(result.ConfigPaths, layer.path)
+		}
+	}
+
 	// Load local config if found (higher priority - overrides base)
 	if result.LocalConfigPath != "" {
 		source, err := sources.NewFileSource(result.LocalConfigPath, sources.FileSourceOptions{
@@ -215,6 +335,8 @@ func DiscoverAndLoadConfigs(cfg AutoDiscoveryConfig) (Confy, *AutoDiscoveryResul
 				if cfg.RequireLocal {
 					return nil, nil, fmt.Errorf("failed to load local config: %w", err)
 				}
+			} else {
+				result.ConfigPaths = append(result.ConfigPaths, result.LocalConfigPath)
 			}
 		}
 	} else if cfg.RequireLocal {
@@ -292,10 +414,111 @@ func DiscoverAndLoadConfigs(cfg AutoDiscoveryConfig) (Confy, *AutoDiscoveryResul
 	return confy, result, nil
 }
 
+// defaultsPattern returns the pattern to load from DefaultsFS.
+func defaultsPattern(cfg AutoDiscoveryConfig) string {
+	if cfg.DefaultsPattern != "" {
+		return cfg.DefaultsPattern
+	}
+
+	for _, configName := range cfg.ConfigNames {
+		if _, err := fs.Stat(cfg.DefaultsFS, configName); err == nil {
+			return configName
+		}
+	}
+
+	return ""
+}
+
+// maxProfiles is the number of profiles that fit between the base and local
+// config priorities.
+const maxProfiles = (PriorityLocalConfig - PriorityProfile) / 2
+
+// activeProfiles returns cfg.Profiles, or the comma separated profiles of the
+// first ProfileEnvVars entry that is set, cleaned and de-duplicated.
+func activeProfiles(cfg AutoDiscoveryConfig) ([]string, error) {
+	requested := cfg.Profiles
+	if len(requested) == 0 {
+		for _, envVar := range cfg.ProfileEnvVars {
+			if value := strings.TrimSpace(os.Getenv(envVar)); value != "" {
+				requested = strings.Split(value, ",")
+
+				break
+			}
+		}
+	}
+
+	profiles := make([]string, 0, len(requested))
+
+	for _, profile := range requested {
+		profile = strings.TrimSpace(profile)
+		if profile == "" || slices.Contains(profiles, profile) {
+			continue
+		}
+
+		// Profiles become part of file names; keep them to a single path element
+		if profile == "." || profile == ".." || strings.ContainsAny(profile, `/\`) {
+			return nil, fmt.Errorf("invalid config profile %q", profile)
+		}
+
+		// config.local.yaml is the local override, not a profile
+		if profile == "local" {
+			return nil, fmt.Errorf("config profile %q is reserved", profile)
+		}
+
+		profiles = append(profiles, profile)
+	}
+
+	if len(profiles) > maxProfiles {
+		return nil, fmt.Errorf("too many config profiles: %d (max %d)", len(profiles), maxProfiles)
+	}
+
+	return profiles, nil
+}
+
+// profilePriority returns the priority of the i-th profile's config file;
+// its .local file sits one above.
+func profilePriority(i int) int {
+	return PriorityProfile + 2*i
+}
+
+// profileConfigName inserts profile (and an optional suffix) before the
+// extension of a base config name: config.yaml -> config.prod.local.yaml.
+func profileConfigName(configName, profile, suffix string) string {
+	ext := filepath.Ext(configName)
+
+	return strings.TrimSuffix(configName, ext) + "." + profile + suffix + ext
+}
+
+// findProfileConfigs looks for the files of every active profile in dir.
+func findProfileConfigs(dir string, cfg AutoDiscoveryConfig) []ProfileConfig {
+	var found []ProfileConfig
+
+	for _, profile := range cfg.Profiles {
+		profileConfig := ProfileConfig{Profile: profile}
+
+		for _, configName := range cfg.ConfigNames {
+			if path := filepath.Join(dir, profileConfigName(configName, profile, "")); profileConfig.ConfigPath == "" && fileExists(path) {
+				profileConfig.ConfigPath = path
+			}
+
+			if path := filepath.Join(dir, profileConfigName(configName, profile, ".local")); profileConfig.LocalConfigPath == "" && fileExists(path) {
+				profileConfig.LocalConfigPath = path
+			}
+		}
+
+		if profileConfig.ConfigPath != "" || profileConfig.LocalConfigPath != "" {
+			found = append(found, profileConfig)
+		}
+	}
+
+	return found
+}
+
 // discoverConfigFiles searches for config files in the specified paths.
 func discoverConfigFiles(cfg AutoDiscoveryConfig) (*AutoDiscoveryResult, error) {
 	result := &AutoDiscoveryResult{
-		AppName: cfg.AppName,
+		AppName:  cfg.AppName,
+		Profiles: cfg.Profiles,
 	}
 
 	// Search in each path
@@ -352,6 +575,14 @@ func searchInPathHierarchy(startPath string, cfg AutoDiscoveryConfig, result *Au
 			}
 		}
 
+		// Look for profile config files
+		if profileConfigs := findProfileConfigs(currentPath, cfg); len(profileConfigs) > 0 {
+			result.ProfileConfigs = profileConfigs
+			if result.WorkingDirectory == "" {
+				result.WorkingDirectory = currentPath
+			}
+		}
+
 		// Check if this looks like a monorepo (has apps/ directory)
 		appsDir := filepath.Join(currentPath, "apps")
 		if dirExists(appsDir) {
@@ -359,7 +590,7 @@ func searchInPathHierarchy(startPath string, cfg AutoDiscoveryConfig, result *Au
 		}
 
 		// If we found at least one config, we're done
-		if result.BaseConfigPath != "" || result.LocalConfigPath != "" {
+		if result.BaseConfigPath != "" || result.LocalConfigPath != "" || len(result.ProfileConfigs) > 0 {
 			return true, nil
 		}
 
@@ -537,6 +768,14 @@ func LoadConfigWithAppScope(appName string, logger logger.Logger, errorHandler e
 			)
 		}
 
+		for _, profile := range result.ProfileConfigs {
+			logger.Info("discovered profile config",
+				F("profile", profile.Profile),
+				F("path", profile.ConfigPath),
+				F("local_path", profile.LocalConfigPath),
+			)
+		}
+
 		if result.IsMonorepo {
 			logger.Info("detected monorepo layout",
 				F("app", appName),
@@ -637,6 +876,11 @@ func GetConfigSearchInfo(appName string) string {
 	info.WriteString(fmt.Sprintf("  Working Directory: %s\n", cwd))
 	info.WriteString(fmt.Sprintf("  Base Config Names: %v\n", cfg.ConfigNames))
 	info.WriteString(fmt.Sprintf("  Local Config Names: %v\n", cfg.LocalConfigNames))
+
+	if profiles, err := activeProfiles(cfg); err == nil && len(profiles) > 0 {
+		info.WriteString(fmt.Sprintf("  Profiles: %v (config.{profile}.yaml, config.{profile}.local.yaml)\n", profiles))
+	}
+
 	info.WriteString(fmt.Sprintf("  Max Search Depth: %d parent directories\n", cfg.MaxDepth))
 	info.WriteString(fmt.Sprintf("  App Scoping: %v\n", cfg.EnableAppScoping))
 
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"testing/fstest"
 
 	logger "github.com/xraph/go-utils/log"
 )
@@ -236,6 +237,44 @@ func TestDiscoverAndLoadConfigs_NoConfigFiles(t *testing.T) {
 	}
 }
 
+// TestDiscoverAndLoadConfigs_DefaultsFS tests compiled-in defaults as the lowest layer.
+func TestDiscoverAndLoadConfigs_DefaultsFS(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	baseConfig := `
+database:
+  host: prod.example.com
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.yaml"), []byte(baseConfig), 0644); err != nil {
+		t.Fatalf("Failed to write base config: %v", err)
+	}
+
+	cfg := DefaultAutoDiscoveryConfig()
+	cfg.SearchPaths = []string{tmpDir}
+	cfg.EnableEnvSource = false
+	cfg.Logger = logger.NewNoopLogger()
+	cfg.DefaultsFS = fstest.MapFS{
+		"config.yaml": {Data: []byte("database:\n  host: localhost\n  port: 5432\n")},
+	}
+
+	confy, result, err := DiscoverAndLoadConfigs(cfg)
+	if err != nil {
+		t.Fatalf("Failed to discover configs: %v", err)
+	}
+
+	if result.DefaultsPattern != "config.yaml" {
+		t.Errorf("Expected defaults pattern 'config.yaml', got '%s'", result.DefaultsPattern)
+	}
+
+	if host := confy.GetString("database.host"); host != "prod.example.com" {
+		t.Errorf("Expected database.host from base config, got '%s'", host)
+	}
+
+	if port := confy.GetInt("database.port"); port != 5432 {
+		t.Errorf("Expected database.port 5432 from defaults, got %d", port)
+	}
+}
+
 // TestDiscoverAndLoadConfigs_RequiredConfig tests behavior when config is required.
 func TestDiscoverAndLoadConfigs_RequiredConfig(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "forge-required-config-test-*")
@@ -514,6 +553,145 @@ apps:
 	}
 }
 
+// TestDiscoverAndLoadConfigs_Profiles tests profile overlays between base and local configs.
+func TestDiscoverAndLoadConfigs_Profiles(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"config.yaml":            "setting1: base\nsetting2: base\nsetting3: base\nsetting4: base\nsetting5: base\n",
+		"config.staging.yaml":    "setting2: staging\nsetting3: staging\nsetting4: staging\nsetting5: staging\n",
+		"config.eu.yaml":         "setting3: eu\nsetting4: eu\nsetting5: eu\n",
+		"config.eu.local.yaml":   "setting4: eu-local\nsetting5: eu-local\n",
+		"config.local.yaml":      "setting5: local\n",
+		"config.production.yaml": "setting1: production\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	cfg := DefaultAutoDiscoveryConfig()
+	cfg.SearchPaths = []string{tmpDir}
+	cfg.Profiles = []string{"staging", " eu", "staging"}
+	cfg.EnableEnvSource = false
+	cfg.Logger = logger.NewNoopLogger()
+
+	confy, result, err := DiscoverAndLoadConfigs(cfg)
+	if err != nil {
+		t.Fatalf("Failed to load configs: %v", err)
+	}
+
+	tests := []struct {
+		key      string
+		expected string
+		desc     string
+	}{
+		{"setting1", "base", "inactive profile is ignored"},
+		{"setting2", "staging", "profile overrides base"},
+		{"setting3", "eu", "later profile overrides earlier"},
+		{"setting4", "eu-local", "profile local overrides profile"},
+		{"setting5", "local", "local overrides every profile"},
+	}
+
+	for _, tt := range tests {
+		if val := confy.GetString(tt.key); val != tt.expected {
+			t.Errorf("%s: expected '%s', got '%s'", tt.desc, tt.expected, val)
+		}
+	}
+
+	if strings.Join(result.Profiles, ",") != "staging,eu" {
+		t.Errorf("Expected profiles [staging eu], got %v", result.Profiles)
+	}
+
+	want := []string{"config.yaml", "config.staging.yaml", "config.eu.yaml", "config.eu.local.yaml", "config.local.yaml"}
+	if len(result.ConfigPaths) != len(want) {
+		t.Fatalf("Expected config paths %v, got %v", want, result.ConfigPaths)
+	}
+
+	for i, name := range want {
+		if result.ConfigPaths[i] != filepath.Join(tmpDir, name) {
+			t.Errorf("ConfigPaths[%d] = %s, want %s", i, result.ConfigPaths[i], name)
+		}
+	}
+}
+
+// TestDiscoverAndLoadConfigs_ProfileEnv tests selecting profiles from the environment.
+func TestDiscoverAndLoadConfigs_ProfileEnv(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Only profile files exist; they are still discovered
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.prod.yaml"), []byte("env: prod\n"), 0644); err != nil {
+		t.Fatalf("Failed to write profile config: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.dev.yaml"), []byte("env: dev\n"), 0644); err != nil {
+		t.Fatalf("Failed to write profile config: %v", err)
+	}
+
+	t.Setenv("CONFY_PROFILE", "prod")
+	t.Setenv("APP_ENV", "dev")
+
+	cfg := DefaultAutoDiscoveryConfig()
+	cfg.SearchPaths = []string{tmpDir}
+	cfg.EnableEnvSource = false
+	cfg.Logger = logger.NewNoopLogger()
+
+	confy, result, err := DiscoverAndLoadConfigs(cfg)
+	if err != nil {
+		t.Fatalf("Failed to load configs: %v", err)
+	}
+
+	if env := confy.GetString("env"); env != "prod" {
+		t.Errorf("Expected CONFY_PROFILE to take precedence over APP_ENV, got env=%s", env)
+	}
+
+	if result.WorkingDirectory != tmpDir || len(result.ProfileConfigs) != 1 {
+		t.Errorf("Expected prod profile config in %s, got %+v", tmpDir, result)
+	}
+
+	t.Setenv("CONFY_PROFILE", "../secrets")
+
+	if _, _, err := DiscoverAndLoadConfigs(cfg); err == nil {
+		t.Error("Expected an error for a profile that is not a single path element")
+	}
+}
+
+func TestDiscoverAndLoadConfigs_ProfileErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.yaml"), []byte("env: base\n"), 0644); err != nil {
+		t.Fatalf("Failed to write base config: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.broken.yaml"), []byte("env: [unclosed\n"), 0644); err != nil {
+		t.Fatalf("Failed to write profile config: %v", err)
+	}
+
+	cfg := DefaultAutoDiscoveryConfig()
+	cfg.SearchPaths = []string{tmpDir}
+	cfg.EnableEnvSource = false
+	cfg.Logger = logger.NewNoopLogger()
+
+	// A missing profile file is skipped
+	cfg.Profiles = []string{"missing"}
+	if _, _, err := DiscoverAndLoadConfigs(cfg); err != nil {
+		t.Errorf("Expected a missing profile file to be skipped, got %v", err)
+	}
+
+	// A profile file that exists but does not parse is an error
+	cfg.Profiles = []string{"broken"}
+	if _, _, err := DiscoverAndLoadConfigs(cfg); err == nil || !strings.Contains(err.Error(), "config.broken.yaml") {
+		t.Errorf("Expected an error naming the broken profile file, got %v", err)
+	}
+
+	// "local" would load config.local.yaml twice
+	cfg.Profiles = []string{"local"}
+	if _, _, err := DiscoverAndLoadConfigs(cfg); err == nil {
+		t.Error("Expected an error for the reserved local profile")
+	}
+}
+
 // Benchmark tests.
 func BenchmarkDiscoverAndLoadConfigs(b *testing.B) {
 	tmpDir, _ := os.MkdirTemp("", "forge-bench-*")
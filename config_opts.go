@@ -51,6 +51,13 @@ func WithCacheEnabled(enabled bool) Option {
 	}
 }
 
+// WithPersistOnSet enables writing values passed to Set to the highest-priority writable source.
+func WithPersistOnSet(enabled bool) Option {
+	return func(c *Config) {
+		c.PersistOnSet = enabled
+	}
+}
+
 // WithReloadOnChange enables or disables automatic reload on configuration changes.
 func WithReloadOnChange(enabled bool) Option {
 	return func(c *Config) {
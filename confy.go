@@ -7,11 +7,10 @@ package confy
 import (
 	"context"
 	"fmt"
-	"maps"
 	"os"
 	"reflect"
+	"slices"
 	"sort"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -35,6 +34,7 @@ type ConfyImpl struct {
 	validator       *Validator
 	watcher         *Watcher
 	data            map[string]any
+	sourceData      map[string]map[string]any
 	watchCallbacks  map[string][]func(string, any)
 	changeCallbacks []func(ConfigChange)
 	mu              sync.RWMutex
@@ -47,6 +47,7 @@ type ConfyImpl struct {
 	secretsManager  configcore.SecretsManager
 	converter       *configcore.TypeConverter
 	merger          *configcore.MergeUtil
+	persistOnSet    bool
 }
 
 // Config contains configuration for creating a ConfyImpl instance.
@@ -60,6 +61,7 @@ type Config struct {
 	ErrorRetryCount int                 `json:"error_retry_count" yaml:"error_retry_count"`
 	ErrorRetryDelay time.Duration       `json:"error_retry_delay" yaml:"error_retry_delay"`
 	MetricsEnabled  bool                `json:"metrics_enabled"   yaml:"metrics_enabled"`
+	PersistOnSet    bool                `json:"persist_on_set"    yaml:"persist_on_set"`
 	Logger          logger.Logger       `json:"-"                 yaml:"-"`
 	Metrics         metrics.Metrics     `json:"-"                 yaml:"-"`
 	ErrorHandler    errors.ErrorHandler `json:"-"                 yaml:"-"`
@@ -124,6 +126,7 @@ func newFromConfig(config Config) Confy {
 		errorHandler:    config.ErrorHandler,
 		converter:       configcore.NewTypeConverter(),
 		merger:          configcore.NewMergeUtil(),
+		persistOnSet:    config.PersistOnSet,
 	}
 
 	impl.registry = NewSourceRegistry(impl.logger)
@@ -1093,8 +1096,44 @@ func (c *ConfyImpl) Validate() error {
 	return c.validator.ValidateAll(c.data)
 }
 
-// Set sets a configuration value.
+// Set sets a configuration value. With PersistOnSet enabled, the value is
+// also written to the highest-priority writable source; failures are
+// reported to the error handler.
 func (c *ConfyImpl) Set(key string, value any) {
+	c.set(key, value)
+
+	if !c.persistOnSet {
+		return
+	}
+
+	if err := c.writeToSource(context.Background(), key, value); err != nil {
+		if c.logger != nil {
+			c.logger.Error("failed to persist configuration value",
+				logger.String("key", key),
+				logger.Error(err),
+			)
+		}
+
+		if c.errorHandler != nil {
+			_ = c.errorHandler.HandleError(context.Background(), err)
+		}
+	}
+}
+
+// Persist writes a configuration value to the highest-priority writable
+// source and, once written, sets it in memory.
+func (c *ConfyImpl) Persist(ctx context.Context, key string, value any) error {
+	if err := c.writeToSource(ctx, key, value); err != nil {
+		return err
+	}
+
+	c.set(key, value)
+
+	return nil
+}
+
+// set updates a value in memory and notifies callbacks.
+func (c *ConfyImpl) set(key string, value any) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -1113,6 +1152,35 @@ func (c *ConfyImpl) Set(key string, value any) {
 	c.notifyWatchCallbacks()
 }
 
+// writeToSource writes a value to the highest-priority writable source.
+func (c *ConfyImpl) writeToSource(ctx context.Context, key string, value any) error {
+	var target WritableSource
+
+	targetPriority := 0
+
+	for _, source := range c.registry.GetSources() {
+		writable, ok := source.(WritableSource)
+		if !ok || !writable.IsWritable() {
+			continue
+		}
+
+		if target == nil || source.Priority() > targetPriority {
+			target = writable
+			targetPriority = source.Priority()
+		}
+	}
+
+	if target == nil {
+		return ErrConfigError("no writable configuration source registered", nil)
+	}
+
+	if err := target.Persist(ctx, key, value); err != nil {
+		return ErrConfigError(fmt.Sprintf("failed to persist configuration key '%s'", key), err)
+	}
+
+	return nil
+}
+
 // =============================================================================
 // BINDING METHODS
 // =============================================================================
@@ -1468,49 +1536,188 @@ func (c *ConfyImpl) ConfigFileUsed() string {
 
 func (c *ConfyImpl) loadAllSources(ctx context.Context) error {
 	mergedData := make(map[string]any)
-
-	sources := c.registry.GetSources()
-
-	// Sort sources by priority (lower number = lower priority, loaded first)
-	// This ensures higher priority sources override lower priority ones
-	type prioritySource struct {
-		priority int
-		source   ConfigSource
-	}
-
-	prioritySources := make([]prioritySource, 0, len(sources))
-	for _, source := range sources {
-		prioritySources = append(prioritySources, prioritySource{
-			priority: source.Priority(),
-			source:   source,
-		})
-	}
-
-	// Sort by priority (ascending) using sort.Slice for O(n log n) performance
-	sort.Slice(prioritySources, func(i, j int) bool {
-		return prioritySources[i].priority < prioritySources[j].priority
-	})
+	sourceData := make(map[string]map[string]any)
 
 	// Load sources in priority order (lower priority first, so higher priority can override)
-	for _, ps := range prioritySources {
-		data, err := c.loader.LoadSource(ctx, ps.source)
+	for _, source := range c.sourcesByPriority() {
+		data, err := c.loader.LoadSource(ctx, source)
 		if err != nil {
 			if c.errorHandler != nil {
 				// nolint:gosec // G104: Error handler intentionally discards return value
 				_ = c.errorHandler.HandleError(context.Background(), err)
 			}
 
-			return ErrConfigError("failed to load source "+ps.source.Name(), err)
+			return ErrConfigError("failed to load source "+source.Name(), err)
 		}
 
 		c.mergeData(mergedData, data)
+		sourceData[source.Name()] = c.merger.DeepCopy(data)
 	}
 
 	c.data = mergedData
+	c.sourceData = sourceData
 
 	return nil
 }
 
+// sourcesByPriority returns the registered sources sorted by ascending
+// priority, so that higher priority sources override lower priority ones.
+func (c *ConfyImpl) sourcesByPriority() []ConfigSource {
+	sources := c.registry.GetSources()
+
+	sort.SliceStable(sources, func(i, j int) bool {
+		return sources[i].Priority() < sources[j].Priority()
+	})
+
+	return sources
+}
+
+// removeDeletedKeys handles keys a source no longer provides. Each falls
+// back to the remaining sources or, if none has it, is removed; removals
+// are returned as delete changes.
+func (c *ConfyImpl) removeDeletedKeys(source string, previous, current map[string]any) []ConfigChange {
+	var changes []ConfigChange
+
+	for _, path := range deletedPaths(previous, current, nil) {
+		if value, ok := c.valueFromSources(path); ok {
+			setValueAtPath(c.data, path, value)
+
+			continue
+		}
+
+		oldValue, _ := valueAtPath(c.data, path)
+		deleteValueAtPath(c.data, path)
+
+		changes = append(changes, ConfigChange{
+			Source:    source,
+			Type:      ChangeTypeDelete,
+			Key:       strings.Join(path, "."),
+			OldValue:  oldValue,
+			Timestamp: time.Now(),
+		})
+	}
+
+	return changes
+}
+
+// valueFromSources merges the value at path from the last data of every
+// source, in priority order.
+func (c *ConfyImpl) valueFromSources(path []string) (any, bool) {
+	var (
+		value any
+		found bool
+	)
+
+	for _, source := range c.sourcesByPriority() {
+		next, ok := valueAtPath(c.sourceData[source.Name()], path)
+		if !ok {
+			continue
+		}
+
+		currentMap, currentIsMap := value.(map[string]any)
+		nextMap, nextIsMap := next.(map[string]any)
+
+		if found && currentIsMap && nextIsMap {
+			value = c.merger.DeepMerge(currentMap, nextMap)
+		} else {
+			value = c.merger.DeepCopyValue(next)
+		}
+
+		found = true
+	}
+
+	return value, found
+}
+
+// deletedPaths returns the paths present in previous but not in current,
+// without descending into deleted sections.
+func deletedPaths(previous, current map[string]any, prefix []string) [][]string {
+	keys := make([]string, 0, len(previous))
+	for key := range previous {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var paths [][]string
+
+	for _, key := range keys {
+		path := append(slices.Clone(prefix), key)
+
+		currentValue, ok := current[key]
+		if !ok {
+			paths = append(paths, path)
+
+			continue
+		}
+
+		previousMap, previousIsMap := previous[key].(map[string]any)
+		currentMap, currentIsMap := currentValue.(map[string]any)
+
+		if previousIsMap && currentIsMap {
+			paths = append(paths, deletedPaths(previousMap, currentMap, path)...)
+		}
+	}
+
+	return paths
+}
+
+// valueAtPath returns the value at path in data.
+func valueAtPath(data map[string]any, path []string) (any, bool) {
+	var current any = data
+
+	for _, key := range path {
+		section, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+
+		if current, ok = section[key]; !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// setValueAtPath sets the value at path in data, creating sections as needed.
+func setValueAtPath(data map[string]any, path []string, value any) {
+	current := data
+
+	for _, key := range path[:len(path)-1] {
+		next, ok := current[key].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			current[key] = next
+		}
+
+		current = next
+	}
+
+	current[path[len(path)-1]] = value
+}
+
+// deleteValueAtPath removes the value at path in data, along with sections
+// left empty by the removal.
+func deleteValueAtPath(data map[string]any, path []string) {
+	if len(path) == 1 {
+		delete(data, path[0])
+
+		return
+	}
+
+	section, ok := data[path[0]].(map[string]any)
+	if !ok {
+		return
+	}
+
+	deleteValueAtPath(section, path[1:])
+
+	if len(section) == 0 {
+		delete(data, path[0])
+	}
+}
+
 func (c *ConfyImpl) handleConfigChange(source string, data map[string]any) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -1522,11 +1729,22 @@ func (c *ConfyImpl) handleConfigChange(source string, data map[string]any) {
 		)
 	}
 
-	oldData := make(map[string]any)
-	maps.Copy(oldData, c.data)
+	oldData := c.merger.DeepCopy(c.data)
+
+	if c.sourceData == nil {
+		c.sourceData = make(map[string]map[string]any)
+	}
+
+	previous, known := c.sourceData[source]
+	c.sourceData[source] = c.merger.DeepCopy(data)
 
 	c.mergeData(c.data, data)
 
+	var deletions []ConfigChange
+	if known {
+		deletions = c.removeDeletedKeys(source, previous, data)
+	}
+
 	if err := c.validator.ValidateAll(c.data); err != nil {
 		if c.logger != nil {
 			c.logger.Error("configuration validation failed after change",
@@ -1538,10 +1756,20 @@ func (c *ConfyImpl) handleConfigChange(source string, data map[string]any) {
 		if c.validator.IsStrictMode() {
 			c.data = oldData
 
+			if known {
+				c.sourceData[source] = previous
+			} else {
+				delete(c.sourceData, source)
+			}
+
 			return
 		}
 	}
 
+	for _, deletion := range deletions {
+		c.notifyChangeCallbacks(deletion)
+	}
+
 	change := ConfigChange{
 		Source:    source,
 		Type:      ChangeTypeUpdate,
@@ -1606,90 +1834,7 @@ func (c *ConfyImpl) mergeData(target, source map[string]any) {
 // structToMap converts a struct to map[string]any using struct tags
 // Supports yaml tags (preferred) and json tags as fallback, with optional custom tagName.
 func (c *ConfyImpl) structToMap(v any, tagName string) (map[string]any, error) {
-	val := reflect.ValueOf(v)
-
-	// Handle pointer to struct
-	if val.Kind() == reflect.Ptr {
-		if val.IsNil() {
-			return nil, errors.New("cannot convert nil pointer to map")
-		}
-
-		val = val.Elem()
-	}
-
-	if val.Kind() != reflect.Struct {
-		return nil, fmt.Errorf("value must be a struct, got %s", val.Kind())
-	}
-
-	result := make(map[string]any)
-	typ := val.Type()
-
-	for i := 0; i < val.NumField(); i++ {
-		field := typ.Field(i)
-		fieldVal := val.Field(i)
-
-		// Skip unexported fields
-		if !field.IsExported() {
-			continue
-		}
-
-		// Get field name from tags (yaml takes precedence over json)
-		fieldName := field.Name
-
-		// Try yaml tag first
-		if yamlTag := field.Tag.Get("yaml"); yamlTag != "" {
-			if idx := strings.Index(yamlTag, ","); idx != -1 {
-				fieldName = yamlTag[:idx]
-			} else {
-				fieldName = yamlTag
-			}
-
-			if fieldName == "-" {
-				continue
-			}
-		} else if jsonTag := field.Tag.Get("json"); jsonTag != "" {
-			// Fallback to json tag
-			if idx := strings.Index(jsonTag, ","); idx != -1 {
-				fieldName = jsonTag[:idx]
-			} else {
-				fieldName = jsonTag
-			}
-
-			if fieldName == "-" {
-				continue
-			}
-		}
-
-		// If using custom tagName from options (not yaml/json), respect it
-		if tagName != "" && tagName != "yaml" && tagName != "json" {
-			if customTag := field.Tag.Get(tagName); customTag != "" {
-				if idx := strings.Index(customTag, ","); idx != -1 {
-					fieldName = customTag[:idx]
-				} else {
-					fieldName = customTag
-				}
-
-				if fieldName == "-" {
-					continue
-				}
-			}
-		}
-
-		// Handle nested structs recursively
-		if fieldVal.Kind() == reflect.Struct {
-			nested, err := c.structToMap(fieldVal.Interface(), tagName)
-			if err == nil {
-				result[fieldName] = nested
-
-				continue
-			}
-		}
-
-		// Set the value
-		result[fieldName] = fieldVal.Interface()
-	}
-
-	return result, nil
+	return configcore.StructToMap(v, tagName)
 }
 
 func (c *ConfyImpl) bindValue(value any, target any) error {
@@ -2110,129 +2255,7 @@ func (c *ConfyImpl) deepMergeValues(defaultValue, configValue any) any {
 
 // applyStructDefaults applies default values from struct tags.
 func (c *ConfyImpl) applyStructDefaults(structValue reflect.Value) error {
-	structType := structValue.Type()
-
-	for i := 0; i < structValue.NumField(); i++ {
-		field := structValue.Field(i)
-		fieldType := structType.Field(i)
-
-		if !field.CanSet() {
-			continue
-		}
-
-		// Check for default tag
-		defaultTag := fieldType.Tag.Get("default")
-		if defaultTag == "" || defaultTag == "-" {
-			// Recursively apply defaults to nested structs
-			if field.Kind() == reflect.Struct {
-				if err := c.applyStructDefaults(field); err != nil {
-					return err
-				}
-			}
-
-			continue
-		}
-
-		// Only apply default if field is zero value
-		if !field.IsZero() {
-			continue
-		}
-		// Parse and set default value based on field type
-		if err := c.setDefaultValue(field, defaultTag, fieldType); err != nil {
-			return ErrConfigError(
-				fmt.Sprintf("failed to set default for field '%s'", fieldType.Name),
-				err,
-			)
-		}
-	}
-
-	return nil
-}
-
-// setDefaultValue sets a field value from a default tag string.
-func (c *ConfyImpl) setDefaultValue(field reflect.Value, defaultTag string, fieldType reflect.StructField) error {
-	switch field.Kind() {
-	case reflect.String:
-		field.SetString(defaultTag)
-
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		// Check if it's a duration
-		if field.Type() == reflect.TypeFor[time.Duration]() {
-			if d, err := time.ParseDuration(defaultTag); err == nil {
-				field.SetInt(int64(d))
-			} else {
-				return fmt.Errorf("invalid duration default: %s", defaultTag)
-			}
-		} else {
-			if intVal, err := strconv.ParseInt(defaultTag, 10, 64); err == nil {
-				field.SetInt(intVal)
-			} else {
-				return fmt.Errorf("invalid int default: %s", defaultTag)
-			}
-		}
-
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		if uintVal, err := strconv.ParseUint(defaultTag, 10, 64); err == nil {
-			field.SetUint(uintVal)
-		} else {
-			return fmt.Errorf("invalid uint default: %s", defaultTag)
-		}
-
-	case reflect.Float32, reflect.Float64:
-		if floatVal, err := strconv.ParseFloat(defaultTag, 64); err == nil {
-			field.SetFloat(floatVal)
-		} else {
-			return fmt.Errorf("invalid float default: %s", defaultTag)
-		}
-
-	case reflect.Bool:
-		if boolVal, err := strconv.ParseBool(defaultTag); err == nil {
-			field.SetBool(boolVal)
-		} else {
-			return fmt.Errorf("invalid bool default: %s", defaultTag)
-		}
-
-	case reflect.Slice:
-		// Handle slice defaults (comma-separated)
-		if field.Type().Elem().Kind() == reflect.String {
-			values := strings.Split(defaultTag, ",")
-
-			slice := reflect.MakeSlice(field.Type(), len(values), len(values))
-			for i, val := range values {
-				slice.Index(i).SetString(strings.TrimSpace(val))
-			}
-
-			field.Set(slice)
-		} else {
-			return errors.New("slice defaults only supported for []string")
-		}
-
-	case reflect.Struct:
-		// Handle time.Time
-		if field.Type() == reflect.TypeFor[time.Time]() {
-			formats := []string{
-				time.RFC3339,
-				time.RFC3339Nano,
-				"2006-01-02 15:04:05",
-				"2006-01-02T15:04:05",
-				"2006-01-02",
-			}
-			for _, format := range formats {
-				if t, err := time.Parse(format, defaultTag); err == nil {
-					field.Set(reflect.ValueOf(t))
-
-					return nil
-				}
-			}
-
-			return fmt.Errorf("invalid time default: %s", defaultTag)
-		}
-
-	default:
-		return fmt.Errorf("unsupported default type: %v", field.Kind())
-	}
-
-	return nil
+	return configcore.ApplyStructDefaults(structValue)
 }
 
 // setFieldValueWithDeepMerge sets field with deep merge support for nested structs.
@@ -1,12 +1,16 @@
 package confy
 
 import (
+	"context"
+	"errors"
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	configcore "github.com/xraph/confy/internal"
+	"github.com/xraph/confy/sources"
 )
 
 // =============================================================================
@@ -1590,3 +1594,211 @@ func TestConfy_Concurrency(t *testing.T) {
 		}
 	}
 }
+
+func TestDefaultsFrom(t *testing.T) {
+	type ServerConfig struct {
+		Host string `default:"0.0.0.0" yaml:"host"`
+		Port int    `default:"8080"    yaml:"port"`
+	}
+
+	confy := NewFromConfig(Config{})
+
+	defaults, err := DefaultsFrom("server", ServerConfig{})
+	if err != nil {
+		t.Fatalf("DefaultsFrom() error = %v", err)
+	}
+
+	override := newMockSource("override", PriorityBaseConfig)
+	override.loadData = map[string]any{"server": map[string]any{"port": 9090}}
+
+	if err := confy.LoadFrom(defaults, override); err != nil {
+		t.Fatalf("LoadFrom() error = %v", err)
+	}
+
+	if host := confy.GetString("server.host"); host != "0.0.0.0" {
+		t.Errorf("server.host = %q, want default", host)
+	}
+
+	if port := confy.GetInt("server.port"); port != 9090 {
+		t.Errorf("server.port = %d, want override", port)
+	}
+
+	if _, ok := confy.GetAllSettings()["server"]; !ok {
+		t.Error("GetAllSettings() should include defaults")
+	}
+
+	if _, ok := confy.GetSourceMetadata()["struct:server"]; !ok {
+		t.Error("defaults source should be registered")
+	}
+}
+
+// writableMockSource records values persisted through Confy.Persist.
+type writableMockSource struct {
+	*mockConfigSource
+	writable  bool
+	persisted map[string]any
+}
+
+func (w *writableMockSource) IsWritable() bool { return w.writable }
+
+func (w *writableMockSource) Persist(ctx context.Context, key string, value any) error {
+	w.persisted[key] = value
+
+	return nil
+}
+
+func TestTestConfyImpl_Persist(t *testing.T) {
+	confy := NewTestConfyImpl().(*TestConfyImpl)
+
+	if err := confy.Persist(context.Background(), "limits.rps", 10); err == nil {
+		t.Error("Persist() without a writable source should fail")
+	}
+
+	confy.SetWritable(true)
+	confy.SetPersistError(errors.New("conflict"))
+
+	if err := confy.Persist(context.Background(), "limits.rps", 20); err == nil {
+		t.Error("Persist() should return the injected error")
+	}
+
+	confy.SetPersistError(nil)
+
+	if err := confy.Persist(context.Background(), "limits.rps", 30); err != nil {
+		t.Fatalf("Persist() error = %v", err)
+	}
+
+	if confy.GetInt("limits.rps") != 30 {
+		t.Errorf("limits.rps = %v, want 30", confy.Get("limits.rps"))
+	}
+
+	calls := confy.GetPersistCalls()
+	if len(calls) != 3 || calls[0].Error == nil || calls[1].Error == nil || calls[2].Error != nil {
+		t.Errorf("GetPersistCalls() = %+v, want two failed calls and one success", calls)
+	}
+}
+
+func TestConfy_Persist(t *testing.T) {
+	confy, ok := NewFromConfig(Config{}).(PersistableConfy)
+	if !ok {
+		t.Fatal("ConfyImpl should implement PersistableConfy")
+	}
+
+	if err := confy.Persist(context.Background(), "limits.rps", 10); err == nil {
+		t.Error("Persist() without writable sources should fail")
+	}
+
+	low := &writableMockSource{mockConfigSource: newMockSource("low", 100), writable: true, persisted: map[string]any{}}
+	high := &writableMockSource{mockConfigSource: newMockSource("high", 200), writable: true, persisted: map[string]any{}}
+	disabled := &writableMockSource{mockConfigSource: newMockSource("disabled", 300), persisted: map[string]any{}}
+
+	if err := confy.LoadFrom(low, high, disabled); err != nil {
+		t.Fatalf("LoadFrom() error = %v", err)
+	}
+
+	if err := confy.Persist(context.Background(), "limits.rps", 10); err != nil {
+		t.Fatalf("Persist() error = %v", err)
+	}
+
+	if high.persisted["limits.rps"] != 10 || len(low.persisted) != 0 || len(disabled.persisted) != 0 {
+		t.Errorf("persisted low=%v high=%v disabled=%v, want only high", low.persisted, high.persisted, disabled.persisted)
+	}
+
+	if confy.GetInt("limits.rps") != 10 {
+		t.Errorf("limits.rps = %d, want 10", confy.GetInt("limits.rps"))
+	}
+
+	confy.Set("limits.burst", 20)
+
+	if _, ok := high.persisted["limits.burst"]; ok {
+		t.Error("Set() should not persist without PersistOnSet")
+	}
+
+	persisting := New(WithPersistOnSet(true))
+	if err := persisting.LoadFrom(high); err != nil {
+		t.Fatalf("LoadFrom() error = %v", err)
+	}
+
+	persisting.Set("limits.burst", 20)
+
+	if high.persisted["limits.burst"] != 20 {
+		t.Errorf("Set() with PersistOnSet persisted %v, want 20", high.persisted["limits.burst"])
+	}
+}
+
+func TestConfy_SourceChangeDeletesKeys(t *testing.T) {
+	low := newMockSource("low", 100)
+	low.loadData = map[string]any{"limits": map[string]any{"rps": 10}}
+
+	high := newMockSource("high", 200)
+	high.loadData = map[string]any{
+		"limits":   map[string]any{"rps": 50, "burst": 20},
+		"features": map[string]any{"beta": true},
+		"name":     "checkout",
+	}
+
+	confy := NewFromConfig(Config{}).(*ConfyImpl)
+	if err := confy.LoadFrom(low, high); err != nil {
+		t.Fatalf("LoadFrom() error = %v", err)
+	}
+
+	changes := make(chan ConfigChange, 8)
+	confy.WatchChanges(func(change ConfigChange) { changes <- change })
+
+	// The high source drops limits.rps, limits.burst and features
+	confy.handleConfigChange("high", map[string]any{
+		"limits": map[string]any{},
+		"name":   "checkout",
+	})
+
+	if confy.GetInt("limits.rps") != 10 {
+		t.Errorf("limits.rps = %v, want the low source's value", confy.Get("limits.rps"))
+	}
+
+	if confy.IsSet("limits.burst") || confy.IsSet("features") {
+		t.Errorf("data = %v, want deleted keys removed", confy.GetAllSettings())
+	}
+
+	deleted := make(map[string]bool)
+
+	for len(deleted) < 2 {
+		select {
+		case change := <-changes:
+			if change.Type == ChangeTypeDelete {
+				deleted[change.Key] = true
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("deleted = %v, want limits.burst and features", deleted)
+		}
+	}
+
+	if !deleted["limits.burst"] || !deleted["features"] {
+		t.Errorf("deleted = %v, want limits.burst and features", deleted)
+	}
+}
+
+func TestLoadFrom_ReaderSourcePriority(t *testing.T) {
+	base, err := sources.NewReaderSource(strings.NewReader("server:\n  port: 8080\n  host: localhost\n"), "yaml", sources.ReaderSourceOptions{
+		Name:     "base",
+		Priority: 100,
+	})
+	if err != nil {
+		t.Fatalf("NewReaderSource() error = %v", err)
+	}
+
+	piped, err := sources.NewReaderSource(strings.NewReader(`{"server": {"port": 9090}}`), "json", sources.ReaderSourceOptions{
+		Name:     "piped",
+		Priority: 200,
+	})
+	if err != nil {
+		t.Fatalf("NewReaderSource() error = %v", err)
+	}
+
+	confy := New()
+	if err := confy.LoadFrom(piped, base); err != nil {
+		t.Fatalf("LoadFrom() error = %v", err)
+	}
+
+	if confy.GetInt("server.port") != 9090 || confy.GetString("server.host") != "localhost" {
+		t.Errorf("server = %v, want piped port over base host", confy.Get("server"))
+	}
+}
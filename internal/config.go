@@ -89,6 +89,17 @@ type Confy interface {
 	ConfigFileUsed() string
 }
 
+// PersistableConfy is a Confy that can write values back to a writable
+// source. It is kept apart from Confy so that existing implementations of
+// Confy do not break.
+type PersistableConfy interface {
+	Confy
+
+	// Persist writes value to the highest-priority writable source and then
+	// sets it in memory.
+	Persist(ctx context.Context, key string, value any) error
+}
+
 // GetOption defines functional options for advanced get operations.
 type GetOption func(*GetOptions)
 
@@ -79,6 +79,19 @@ func ErrSourceError(sourceName string, operation string, cause error) error {
 		WithContext("operation", operation)
 }
 
+// ErrWriteConflict creates an error for a write rejected because the key
+// changed since it was last read.
+func ErrWriteConflict(key string, cause error) error {
+	return errors.NewError(errors.CodeConflict, fmt.Sprintf("write conflict on key '%s'", key), cause).
+		WithContext("key", key)
+}
+
+// IsWriteConflict returns true if err, or any error it wraps, is a write
+// conflict.
+func IsWriteConflict(err error) bool {
+	return errors.Is(err, &errors.Error{Code: errors.CodeConflict})
+}
+
 // ErrLoaderError creates a loader error.
 func ErrLoaderError(operation string, cause error) error {
 	msg := fmt.Sprintf("loader failed during %s", operation)
@@ -87,6 +87,22 @@ type SourceMetadata struct {
 	Properties   map[string]any `json:"properties"`
 }
 
+// SourceMetadataReporter is implemented by sources that report runtime state,
+// such as degraded operation or the revision they loaded, in SourceMetadata.
+type SourceMetadataReporter interface {
+	// ReportMetadata updates metadata with source-specific state
+	ReportMetadata(metadata *SourceMetadata)
+}
+
+// WritableSource is implemented by sources that can persist runtime changes
+// made through PersistableConfy.Persist.
+type WritableSource interface {
+	// IsWritable returns true if writes are enabled for the source
+	IsWritable() bool
+	// Persist writes value at the dot-separated key to the backing store
+	Persist(ctx context.Context, key string, value any) error
+}
+
 // ChangeType represents the type of configuration change.
 type ChangeType string
 
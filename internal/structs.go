@@ -0,0 +1,226 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StructToMap converts a struct to map[string]any using struct tags
+// Supports yaml tags (preferred) and json tags as fallback, with optional custom tagName.
+func StructToMap(v any, tagName string) (map[string]any, error) {
+	val := reflect.ValueOf(v)
+
+	// Handle pointer to struct
+	if val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return nil, errors.New("cannot convert nil pointer to map")
+		}
+
+		val = val.Elem()
+	}
+
+	if val.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("value must be a struct, got %s", val.Kind())
+	}
+
+	result := make(map[string]any)
+	typ := val.Type()
+
+	for i := 0; i < val.NumField(); i++ {
+		field := typ.Field(i)
+		fieldVal := val.Field(i)
+
+		// Skip unexported fields
+		if !field.IsExported() {
+			continue
+		}
+
+		// Get field name from tags (yaml takes precedence over json)
+		fieldName := field.Name
+
+		// Try yaml tag first
+		if yamlTag := field.Tag.Get("yaml"); yamlTag != "" {
+			if idx := strings.Index(yamlTag, ","); idx != -1 {
+				fieldName = yamlTag[:idx]
+			} else {
+				fieldName = yamlTag
+			}
+
+			if fieldName == "-" {
+				continue
+			}
+		} else if jsonTag := field.Tag.Get("json"); jsonTag != "" {
+			// Fallback to json tag
+			if idx := strings.Index(jsonTag, ","); idx != -1 {
+				fieldName = jsonTag[:idx]
+			} else {
+				fieldName = jsonTag
+			}
+
+			if fieldName == "-" {
+				continue
+			}
+		}
+
+		// If using custom tagName from options (not yaml/json), respect it
+		if tagName != "" && tagName != "yaml" && tagName != "json" {
+			if customTag := field.Tag.Get(tagName); customTag != "" {
+				if idx := strings.Index(customTag, ","); idx != -1 {
+					fieldName = customTag[:idx]
+				} else {
+					fieldName = customTag
+				}
+
+				if fieldName == "-" {
+					continue
+				}
+			}
+		}
+
+		// Handle nested structs recursively (time.Time is kept as a value)
+		if fieldVal.Kind() == reflect.Struct && fieldVal.Type() != reflect.TypeFor[time.Time]() {
+			nested, err := StructToMap(fieldVal.Interface(), tagName)
+			if err == nil {
+				result[fieldName] = nested
+
+				continue
+			}
+		}
+
+		// Set the value
+		result[fieldName] = fieldVal.Interface()
+	}
+
+	return result, nil
+}
+
+// ApplyStructDefaults applies default values from struct tags.
+func ApplyStructDefaults(structValue reflect.Value) error {
+	structType := structValue.Type()
+
+	for i := 0; i < structValue.NumField(); i++ {
+		field := structValue.Field(i)
+		fieldType := structType.Field(i)
+
+		if !field.CanSet() {
+			continue
+		}
+
+		// Check for default tag
+		defaultTag := fieldType.Tag.Get("default")
+		if defaultTag == "" || defaultTag == "-" {
+			// Recursively apply defaults to nested structs
+			if field.Kind() == reflect.Struct {
+				if err := ApplyStructDefaults(field); err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		// Only apply default if field is zero value
+		if !field.IsZero() {
+			continue
+		}
+		// Parse and set default value based on field type
+		if err := setDefaultValue(field, defaultTag, fieldType); err != nil {
+			return ErrConfigError(
+				fmt.Sprintf("failed to set default for field '%s'", fieldType.Name),
+				err,
+			)
+		}
+	}
+
+	return nil
+}
+
+// setDefaultValue sets a field value from a default tag string.
+func setDefaultValue(field reflect.Value, defaultTag string, fieldType reflect.StructField) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(defaultTag)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		// Check if it's a duration
+		if field.Type() == reflect.TypeFor[time.Duration]() {
+			if d, err := time.ParseDuration(defaultTag); err == nil {
+				field.SetInt(int64(d))
+			} else {
+				return fmt.Errorf("invalid duration default: %s", defaultTag)
+			}
+		} else {
+			if intVal, err := strconv.ParseInt(defaultTag, 10, 64); err == nil {
+				field.SetInt(intVal)
+			} else {
+				return fmt.Errorf("invalid int default: %s", defaultTag)
+			}
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if uintVal, err := strconv.ParseUint(defaultTag, 10, 64); err == nil {
+			field.SetUint(uintVal)
+		} else {
+			return fmt.Errorf("invalid uint default: %s", defaultTag)
+		}
+
+	case reflect.Float32, reflect.Float64:
+		if floatVal, err := strconv.ParseFloat(defaultTag, 64); err == nil {
+			field.SetFloat(floatVal)
+		} else {
+			return fmt.Errorf("invalid float default: %s", defaultTag)
+		}
+
+	case reflect.Bool:
+		if boolVal, err := strconv.ParseBool(defaultTag); err == nil {
+			field.SetBool(boolVal)
+		} else {
+			return fmt.Errorf("invalid bool default: %s", defaultTag)
+		}
+
+	case reflect.Slice:
+		// Handle slice defaults (comma-separated)
+		if field.Type().Elem().Kind() == reflect.String {
+			values := strings.Split(defaultTag, ",")
+
+			slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+			for i, val := range values {
+				slice.Index(i).SetString(strings.TrimSpace(val))
+			}
+
+			field.Set(slice)
+		} else {
+			return errors.New("slice defaults only supported for []string")
+		}
+
+	case reflect.Struct:
+		// Handle time.Time
+		if field.Type() == reflect.TypeFor[time.Time]() {
+			formats := []string{
+				time.RFC3339,
+				time.RFC3339Nano,
+				"2006-01-02 15:04:05",
+				"2006-01-02T15:04:05",
+				"2006-01-02",
+			}
+			for _, format := range formats {
+				if t, err := time.Parse(format, defaultTag); err == nil {
+					field.Set(reflect.ValueOf(t))
+
+					return nil
+				}
+			}
+
+			return fmt.Errorf("invalid time default: %s", defaultTag)
+		}
+
+	default:
+		return fmt.Errorf("unsupported default type: %v", field.Kind())
+	}
+
+	return nil
+}
@@ -186,13 +186,7 @@ func (sr *SourceRegistryImpl) GetSourceMetadata(name string) (*SourceMetadata, e
 		return nil, fmt.Errorf("source not found: %s", name)
 	}
 
-	// Return a copy to prevent external modification
-	metadataCopy := *metadata
-
-	metadataCopy.Properties = make(map[string]any)
-	maps.Copy(metadataCopy.Properties, metadata.Properties)
-
-	return &metadataCopy, nil
+	return sr.copyMetadata(name, metadata), nil
 }
 
 // GetAllMetadata returns metadata for all sources.
@@ -203,16 +197,25 @@ func (sr *SourceRegistryImpl) GetAllMetadata() map[string]*SourceMetadata {
 	result := make(map[string]*SourceMetadata)
 
 	for name, metadata := range sr.metadata {
-		// Return copies to prevent external modification
-		metadataCopy := *metadata
+		result[name] = sr.copyMetadata(name, metadata)
+	}
+
+	return result
+}
 
-		metadataCopy.Properties = make(map[string]any)
-		maps.Copy(metadataCopy.Properties, metadata.Properties)
+// copyMetadata returns a copy of metadata to prevent external modification,
+// updated with any runtime state reported by the source itself.
+func (sr *SourceRegistryImpl) copyMetadata(name string, metadata *SourceMetadata) *SourceMetadata {
+	metadataCopy := *metadata
 
-		result[name] = &metadataCopy
+	metadataCopy.Properties = make(map[string]any)
+	maps.Copy(metadataCopy.Properties, metadata.Properties)
+
+	if reporter, ok := sr.sources[name].(SourceMetadataReporter); ok {
+		reporter.ReportMetadata(&metadataCopy)
 	}
 
-	return result
+	return &metadataCopy
 }
 
 // UpdateSourceMetadata updates metadata for a source (internal use).
@@ -473,6 +473,47 @@ func TestSourceRegistry_GetAllMetadata(t *testing.T) {
 	}
 }
 
+// reportingMockSource reports runtime state through SourceMetadataReporter.
+type reportingMockSource struct {
+	*mockConfigSource
+}
+
+func (r *reportingMockSource) ReportMetadata(metadata *SourceMetadata) {
+	metadata.LastError = "upstream unavailable"
+	metadata.Properties["degraded"] = true
+}
+
+func TestSourceRegistry_MetadataReporter(t *testing.T) {
+	registry := NewSourceRegistry(nil)
+
+	_ = registry.RegisterSource(&reportingMockSource{newMockSource("reporting", 1)})
+
+	metadata, err := registry.GetSourceMetadata("reporting")
+	if err != nil {
+		t.Fatalf("GetSourceMetadata() error = %v", err)
+	}
+
+	if metadata.Properties["degraded"] != true {
+		t.Errorf("Properties[degraded] = %v, want true", metadata.Properties["degraded"])
+	}
+
+	if metadata.LastError != "upstream unavailable" {
+		t.Errorf("LastError = %q, want reported error", metadata.LastError)
+	}
+
+	if all := registry.GetAllMetadata(); all["reporting"].Properties["degraded"] != true {
+		t.Error("GetAllMetadata() should include reported state")
+	}
+
+	// Reported state is applied to copies only.
+	again, _ := registry.GetSourceMetadata("reporting")
+	again.Properties["degraded"] = false
+
+	if metadata, _ := registry.GetSourceMetadata("reporting"); metadata.Properties["degraded"] != true {
+		t.Error("modifying returned metadata should not affect the registry")
+	}
+}
+
 // =============================================================================
 // QUERY TESTS
 // =============================================================================
@@ -15,6 +15,7 @@ import (
 	"time"
 
 	configscore "github.com/xraph/confy/internal"
+	"github.com/xraph/confy/sources"
 	errors "github.com/xraph/go-utils/errs"
 	logger "github.com/xraph/go-utils/log"
 	"github.com/xraph/go-utils/metrics"
@@ -689,6 +690,8 @@ func (sm *SecretsManagerImpl) createProvider(config ProviderConfig) (SecretProvi
 		return &FileSecretProvider{}, nil
 	case "memory":
 		return &MemorySecretProvider{}, nil
+	case "credentials":
+		return &CredentialsSecretProvider{}, nil
 	case "vault":
 		return &VaultSecretProvider{}, nil
 	default:
@@ -770,6 +773,9 @@ type SecretEncryptor struct {
 	gcm cipher.AEAD
 }
 
+// SecretEncryptor can encrypt cached source data at rest.
+var _ sources.Encryptor = (*SecretEncryptor)(nil)
+
 // NewSecretEncryptor creates a new secret encryptor.
 func NewSecretEncryptor(keyStr string) *SecretEncryptor {
 	// Create key from string
@@ -1018,6 +1024,102 @@ func (fsp *FileSecretProvider) Close(ctx context.Context) error {
 	return nil
 }
 
+// CredentialsSecretProvider provides secrets from systemd credentials and
+// Docker secrets directories. It is read-only.
+type CredentialsSecretProvider struct {
+	source *sources.CredentialsSource
+}
+
+// NewCredentialsSecretProvider creates a credentials provider. Use
+// options.Decryptor with a SecretEncryptor for encrypted files.
+func NewCredentialsSecretProvider(options sources.CredentialsSourceOptions) (*CredentialsSecretProvider, error) {
+	source, err := sources.NewCredentialsSource(options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CredentialsSecretProvider{source: source.(*sources.CredentialsSource)}, nil
+}
+
+func (csp *CredentialsSecretProvider) Name() string { return "credentials" }
+
+func (csp *CredentialsSecretProvider) GetSecret(ctx context.Context, key string) (string, error) {
+	if csp.source == nil {
+		return "", errors.New("credentials provider not initialized")
+	}
+
+	return csp.source.GetSecret(ctx, key)
+}
+
+func (csp *CredentialsSecretProvider) SetSecret(ctx context.Context, key, value string) error {
+	return errors.New("credentials provider is read-only")
+}
+
+func (csp *CredentialsSecretProvider) DeleteSecret(ctx context.Context, key string) error {
+	return errors.New("credentials provider is read-only")
+}
+
+func (csp *CredentialsSecretProvider) ListSecrets(ctx context.Context) ([]string, error) {
+	if csp.source == nil {
+		return nil, errors.New("credentials provider not initialized")
+	}
+
+	return csp.source.ListSecrets()
+}
+
+func (csp *CredentialsSecretProvider) HealthCheck(ctx context.Context) error {
+	if csp.source == nil || !csp.source.IsAvailable(ctx) {
+		return errors.New("no credentials directory is accessible")
+	}
+
+	return nil
+}
+
+func (csp *CredentialsSecretProvider) SupportsRotation() bool { return false }
+func (csp *CredentialsSecretProvider) SupportsCaching() bool  { return true }
+
+func (csp *CredentialsSecretProvider) Initialize(ctx context.Context, config map[string]interface{}) error {
+	options := sources.CredentialsSourceOptions{}
+
+	switch dirs := config["directories"].(type) {
+	case string:
+		options.Directories = []string{dirs}
+	case []string:
+		options.Directories = dirs
+	case []interface{}:
+		for _, dir := range dirs {
+			if s, ok := dir.(string); ok {
+				options.Directories = append(options.Directories, s)
+			}
+		}
+	}
+
+	if separator, ok := config["separator"].(string); ok {
+		options.Separator = separator
+	}
+
+	if allow, ok := config["allow_world_readable"].(bool); ok {
+		options.AllowWorldReadable = allow
+	}
+
+	if key, ok := config["encryption_key"].(string); ok && key != "" {
+		options.Decryptor = NewSecretEncryptor(key)
+	}
+
+	source, err := sources.NewCredentialsSource(options)
+	if err != nil {
+		return err
+	}
+
+	csp.source = source.(*sources.CredentialsSource)
+
+	return nil
+}
+
+func (csp *CredentialsSecretProvider) Close(ctx context.Context) error {
+	return nil
+}
+
 // MemorySecretProvider provides secrets from memory (for testing).
 type MemorySecretProvider struct {
 	secrets map[string]string
@@ -0,0 +1,61 @@
+package confy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCredentialsSecretProvider(t *testing.T) {
+	dir := t.TempDir()
+
+	encryptor := NewSecretEncryptor("key")
+
+	encrypted, err := encryptor.Encrypt("s3cr3t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "db.password"), []byte(encrypted), 0400); err != nil {
+		t.Fatal(err)
+	}
+
+	manager := NewSecretsManager(SecretsConfig{
+		DefaultProvider: "creds",
+		Providers: map[string]ProviderConfig{
+			"creds": {
+				Type:    "credentials",
+				Enabled: true,
+				Properties: map[string]any{
+					"directories":    []any{dir},
+					"encryption_key": "key",
+				},
+			},
+		},
+	})
+
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer func() { _ = manager.Stop(context.Background()) }()
+
+	provider, err := manager.GetProvider("creds")
+	if err != nil {
+		t.Fatalf("GetProvider() error = %v", err)
+	}
+
+	value, err := provider.GetSecret(context.Background(), "db.password")
+	if err != nil || value != "s3cr3t" {
+		t.Errorf("GetSecret() = %q, %v", value, err)
+	}
+
+	keys, err := provider.ListSecrets(context.Background())
+	if err != nil || len(keys) != 1 || keys[0] != "db.password" {
+		t.Errorf("ListSecrets() = %v, %v", keys, err)
+	}
+
+	if err := provider.SetSecret(context.Background(), "db.password", "x"); err == nil {
+		t.Error("SetSecret() should fail for the read-only provider")
+	}
+}
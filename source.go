@@ -2,6 +2,7 @@ package confy
 
 import (
 	"github.com/xraph/confy/internal"
+	"github.com/xraph/confy/sources"
 )
 
 // ConfigSource represents a source of configuration data.
@@ -19,6 +20,12 @@ type ValidationRule = internal.ValidationRule
 // SourceMetadata contains metadata about a configuration source.
 type SourceMetadata = internal.SourceMetadata
 
+// SourceMetadataReporter is implemented by sources that report runtime state in SourceMetadata.
+type SourceMetadataReporter = internal.SourceMetadataReporter
+
+// WritableSource is implemented by sources that can persist runtime changes.
+type WritableSource = internal.WritableSource
+
 // ChangeType represents the type of configuration change.
 type ChangeType = internal.ChangeType
 
@@ -52,3 +59,12 @@ type SourceEventHandler = internal.SourceEventHandler
 
 // WatchContext contains context for watching configuration changes.
 type WatchContext = internal.WatchContext
+
+// DefaultsFrom returns a lowest-priority source exposing the field values and
+// `default` tags of v under prefix, so defaults are part of the effective
+// configuration instead of only being applied during Bind.
+func DefaultsFrom(prefix string, v any) (ConfigSource, error) {
+	return sources.NewStructSource(prefix, v, sources.StructSourceOptions{
+		Priority: PriorityDefaults,
+	})
+}
@@ -0,0 +1,37 @@
+package sources
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// backoffDelay returns the delay before retry attempt n (starting at 1) using
+// exponential backoff capped at maxDelay, with up to 20% random jitter so that
+// many replicas reconnecting to the same backend do not retry in lockstep.
+func backoffDelay(attempt int, baseDelay, maxDelay time.Duration) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	if baseDelay <= 0 {
+		baseDelay = time.Second
+	}
+
+	if maxDelay < baseDelay {
+		maxDelay = baseDelay
+	}
+
+	delay := baseDelay
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	//nolint:gosec // G404: jitter does not need a cryptographic source
+	jitter := time.Duration(rand.Int64N(int64(delay)/5 + 1))
+
+	return delay - jitter
+}
@@ -0,0 +1,787 @@
+package sources
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	configcore "github.com/xraph/confy/internal"
+	errors "github.com/xraph/go-utils/errs"
+	logger "github.com/xraph/go-utils/log"
+)
+
+// defaultBundleManifests are the manifest names looked up when none is configured.
+var defaultBundleManifests = []string{"manifest.yaml", "manifest.yml", "manifest.json"}
+
+// BundleSource represents a tar.gz or zip archive of configuration files,
+// optionally verified with a detached ed25519 signature.
+type BundleSource struct {
+	name         string
+	path         string
+	sigPath      string
+	priority     int
+	publicKey    ed25519.PublicKey
+	digest       string
+	files        []string
+	version      string
+	verified     bool
+	watcher      *fsnotify.Watcher
+	watching     bool
+	watchStop    chan struct{}
+	logger       logger.Logger
+	errorHandler errors.ErrorHandler
+	options      BundleSourceOptions
+	mu           sync.RWMutex
+}
+
+// BundleSourceOptions contains options for bundle configuration sources.
+type BundleSourceOptions struct {
+	Name     string
+	Priority int
+	// PublicKey verifies the bundle's detached ed25519 signature.
+	PublicKey ed25519.PublicKey
+	// PublicKeyFile is read when PublicKey is empty. It may hold the raw key,
+	// its hex or base64 encoding, or a PEM "PUBLIC KEY" block.
+	PublicKeyFile string
+	// SignaturePath defaults to the bundle path with ".sig" appended. The
+	// signature may be raw or hex/base64 encoded.
+	SignaturePath string
+	// AllowUnsigned loads bundles without verification when no key is
+	// configured. Intended for development only.
+	AllowUnsigned bool
+	// Manifest names the manifest inside the bundle; its "files" list gives
+	// the merge order. Defaults to manifest.yaml, manifest.yml or
+	// manifest.json. Without a manifest, all configuration files are merged
+	// in lexical order.
+	Manifest      string
+	MaxFileSize   int64
+	ExpandEnvVars bool
+	WatchEnabled  bool
+	WatchDebounce time.Duration
+	Logger        logger.Logger
+	ErrorHandler  errors.ErrorHandler
+}
+
+// BundleSourceConfig contains configuration for creating bundle sources.
+type BundleSourceConfig struct {
+	Path          string        `json:"path"            yaml:"path"`
+	Priority      int           `json:"priority"        yaml:"priority"`
+	PublicKeyFile string        `json:"public_key_file" yaml:"public_key_file"`
+	SignaturePath string        `json:"signature_path"  yaml:"signature_path"`
+	AllowUnsigned bool          `json:"allow_unsigned"  yaml:"allow_unsigned"`
+	Manifest      string        `json:"manifest"        yaml:"manifest"`
+	MaxFileSize   int64         `json:"max_file_size"   yaml:"max_file_size"`
+	ExpandEnvVars bool          `json:"expand_env_vars" yaml:"expand_env_vars"`
+	WatchEnabled  bool          `json:"watch_enabled"   yaml:"watch_enabled"`
+	WatchDebounce time.Duration `json:"watch_debounce"  yaml:"watch_debounce"`
+}
+
+// bundleManifest is the manifest stored inside a bundle.
+type bundleManifest struct {
+	Version string
+	Files   []string
+}
+
+// NewBundleSource creates a new bundle configuration source. A public key is
+// required unless options.AllowUnsigned is set.
+func NewBundleSource(path string, options BundleSourceOptions) (configcore.ConfigSource, error) {
+	if path == "" {
+		return nil, configcore.ErrConfigError("bundle path is required", nil)
+	}
+
+	publicKey := options.PublicKey
+
+	if len(publicKey) == 0 && options.PublicKeyFile != "" {
+		data, err := os.ReadFile(options.PublicKeyFile)
+		if err != nil {
+			return nil, configcore.ErrConfigError("failed to read bundle public key", err)
+		}
+
+		publicKey, err = parseEd25519PublicKey(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(publicKey) != 0 && len(publicKey) != ed25519.PublicKeySize {
+		return nil, configcore.ErrConfigError(fmt.Sprintf("invalid ed25519 public key size %d", len(publicKey)), nil)
+	}
+
+	if len(publicKey) == 0 && !options.AllowUnsigned {
+		return nil, configcore.ErrConfigError("bundle public key is required unless AllowUnsigned is set", nil)
+	}
+
+	if options.SignaturePath == "" {
+		options.SignaturePath = path + ".sig"
+	}
+
+	if options.MaxFileSize == 0 {
+		options.MaxFileSize = 16 << 20
+	}
+
+	if options.WatchDebounce == 0 {
+		options.WatchDebounce = 200 * time.Millisecond
+	}
+
+	name := options.Name
+	if name == "" {
+		name = "bundle:" + filepath.Base(path)
+	}
+
+	return &BundleSource{
+		name:         name,
+		path:         path,
+		sigPath:      options.SignaturePath,
+		priority:     options.Priority,
+		publicKey:    publicKey,
+		logger:       options.Logger,
+		errorHandler: options.ErrorHandler,
+		options:      options,
+	}, nil
+}
+
+// Name returns the source name.
+func (bs *BundleSource) Name() string {
+	return bs.name
+}
+
+// GetName returns the source name (alias for Name).
+func (bs *BundleSource) GetName() string {
+	return bs.name
+}
+
+// GetType returns the source type.
+func (bs *BundleSource) GetType() string {
+	return "bundle"
+}
+
+// IsAvailable checks if the bundle file exists.
+func (bs *BundleSource) IsAvailable(ctx context.Context) bool {
+	_, err := os.Stat(bs.path)
+
+	return err == nil
+}
+
+// Priority returns the source priority.
+func (bs *BundleSource) Priority() int {
+	return bs.priority
+}
+
+// Load verifies the bundle and merges its files in manifest order.
+func (bs *BundleSource) Load(ctx context.Context) (map[string]any, error) {
+	archive, err := os.ReadFile(bs.path)
+	if err != nil {
+		return nil, configcore.ErrConfigError("failed to read bundle "+bs.path, err)
+	}
+
+	verified, err := bs.verify(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := bs.extract(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := bs.manifest(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	merger := configcore.NewMergeUtil()
+	result := make(map[string]any)
+
+	for _, name := range manifest.Files {
+		data, err := bs.parseEntry(name, entries)
+		if err != nil {
+			return nil, err
+		}
+
+		merger.MergeInPlace(result, data)
+	}
+
+	digest := sha256.Sum256(archive)
+
+	bs.mu.Lock()
+	bs.digest = hex.EncodeToString(digest[:])
+	bs.files = manifest.Files
+	bs.version = manifest.Version
+	bs.verified = verified
+	bs.mu.Unlock()
+
+	if bs.logger != nil {
+		bs.logger.Info("configuration loaded from bundle",
+			logger.String("path", bs.path),
+			logger.String("version", manifest.Version),
+			logger.Int("files", len(manifest.Files)),
+			logger.Bool("verified", verified),
+		)
+	}
+
+	return result, nil
+}
+
+// Watch starts watching the bundle and its signature for replacement.
+func (bs *BundleSource) Watch(ctx context.Context, callback func(map[string]any)) error {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	if bs.watching {
+		return configcore.ErrConfigError("already watching bundle", nil)
+	}
+
+	if !bs.IsWatchable() {
+		return configcore.ErrConfigError("bundle watching is not enabled", nil)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return configcore.ErrConfigError("failed to create bundle watcher", err)
+	}
+
+	// Watch the directories to catch bundles replaced by rename
+	for _, dir := range uniqueStrings(filepath.Dir(bs.path), filepath.Dir(bs.sigPath)) {
+		if err := watcher.Add(dir); err != nil {
+			_ = watcher.Close()
+
+			return configcore.ErrConfigError("failed to watch directory "+dir, err)
+		}
+	}
+
+	bs.watcher = watcher
+	bs.watchStop = make(chan struct{})
+	bs.watching = true
+
+	go bs.watchLoop(ctx, watcher, bs.watchStop, callback)
+
+	if bs.logger != nil {
+		bs.logger.Info("started watching bundle",
+			logger.String("path", bs.path),
+		)
+	}
+
+	return nil
+}
+
+// StopWatch stops watching the bundle.
+func (bs *BundleSource) StopWatch() error {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	if !bs.watching {
+		return nil
+	}
+
+	if bs.watchStop != nil {
+		close(bs.watchStop)
+		bs.watchStop = nil
+	}
+
+	if bs.watcher != nil {
+		_ = bs.watcher.Close()
+		bs.watcher = nil
+	}
+
+	bs.watching = false
+
+	if bs.logger != nil {
+		bs.logger.Info("stopped watching bundle",
+			logger.String("path", bs.path),
+		)
+	}
+
+	return nil
+}
+
+// Reload forces a reload of the bundle.
+func (bs *BundleSource) Reload(ctx context.Context) error {
+	_, err := bs.Load(ctx)
+
+	return err
+}
+
+// IsWatchable returns true if bundle watching is enabled.
+func (bs *BundleSource) IsWatchable() bool {
+	return bs.options.WatchEnabled
+}
+
+// SupportsSecrets returns false.
+func (bs *BundleSource) SupportsSecrets() bool {
+	return false
+}
+
+// GetSecret is not supported by bundle sources.
+func (bs *BundleSource) GetSecret(ctx context.Context, key string) (string, error) {
+	return "", configcore.ErrConfigError("bundle source does not support secrets", nil)
+}
+
+// GetDigest returns the SHA-256 digest of the last loaded bundle.
+func (bs *BundleSource) GetDigest() string {
+	bs.mu.RLock()
+	defer bs.mu.RUnlock()
+
+	return bs.digest
+}
+
+// ReportMetadata reports the bundle digest, manifest version and files.
+func (bs *BundleSource) ReportMetadata(metadata *configcore.SourceMetadata) {
+	bs.mu.RLock()
+	defer bs.mu.RUnlock()
+
+	if metadata.Properties == nil {
+		metadata.Properties = make(map[string]any)
+	}
+
+	metadata.Properties["path"] = bs.path
+	metadata.Properties["sha256"] = bs.digest
+	metadata.Properties["verified"] = bs.verified
+	metadata.Properties["files"] = append([]string(nil), bs.files...)
+
+	if bs.version != "" {
+		metadata.Properties["version"] = bs.version
+	}
+}
+
+// verify checks the detached signature. It reports whether the bundle was
+// verified, which is false only for unsigned bundles allowed by options.
+func (bs *BundleSource) verify(archive []byte) (bool, error) {
+	if len(bs.publicKey) == 0 {
+		return false, nil
+	}
+
+	raw, err := os.ReadFile(bs.sigPath)
+	if err != nil {
+		return false, configcore.ErrConfigError("failed to read bundle signature "+bs.sigPath, err)
+	}
+
+	signature, err := decodeKeyMaterial(raw, ed25519.SignatureSize)
+	if err != nil {
+		return false, configcore.ErrConfigError("invalid bundle signature "+bs.sigPath, err)
+	}
+
+	if !ed25519.Verify(bs.publicKey, archive, signature) {
+		return false, configcore.ErrConfigError("bundle signature verification failed for "+bs.path, nil)
+	}
+
+	return true, nil
+}
+
+// extract reads the regular files of a tar.gz or zip archive into memory.
+func (bs *BundleSource) extract(archive []byte) (map[string][]byte, error) {
+	switch {
+	case bytes.HasPrefix(archive, []byte{0x1f, 0x8b}):
+		return bs.extractTarGz(archive)
+	case bytes.HasPrefix(archive, []byte("PK\x03\x04")), bytes.HasPrefix(archive, []byte("PK\x05\x06")):
+		return bs.extractZip(archive)
+	default:
+		return nil, configcore.ErrConfigError("unsupported bundle format for "+bs.path+"; expected tar.gz or zip", nil)
+	}
+}
+
+func (bs *BundleSource) extractTarGz(archive []byte) (map[string][]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, configcore.ErrConfigError("failed to open gzip bundle "+bs.path, err)
+	}
+	defer gz.Close()
+
+	entries := make(map[string][]byte)
+	reader := tar.NewReader(gz)
+
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, configcore.ErrConfigError("failed to read tar bundle "+bs.path, err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name, ok := cleanBundleName(header.Name)
+		if !ok {
+			return nil, configcore.ErrConfigError("invalid file name in bundle: "+header.Name, nil)
+		}
+
+		content, err := bs.readLimited(name, reader)
+		if err != nil {
+			return nil, err
+		}
+
+		entries[name] = content
+	}
+
+	return entries, nil
+}
+
+func (bs *BundleSource) extractZip(archive []byte) (map[string][]byte, error) {
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, configcore.ErrConfigError("failed to open zip bundle "+bs.path, err)
+	}
+
+	entries := make(map[string][]byte)
+
+	for _, file := range reader.File {
+		if !file.Mode().IsRegular() {
+			continue
+		}
+
+		name, ok := cleanBundleName(file.Name)
+		if !ok {
+			return nil, configcore.ErrConfigError("invalid file name in bundle: "+file.Name, nil)
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			return nil, configcore.ErrConfigError("failed to open bundle file "+name, err)
+		}
+
+		content, err := bs.readLimited(name, rc)
+		_ = rc.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		entries[name] = content
+	}
+
+	return entries, nil
+}
+
+// readLimited reads a bundle entry, rejecting entries above MaxFileSize.
+func (bs *BundleSource) readLimited(name string, r io.Reader) ([]byte, error) {
+	content, err := io.ReadAll(io.LimitReader(r, bs.options.MaxFileSize+1))
+	if err != nil {
+		return nil, configcore.ErrConfigError("failed to read bundle file "+name, err)
+	}
+
+	if int64(len(content)) > bs.options.MaxFileSize {
+		return nil, configcore.ErrConfigError(fmt.Sprintf("bundle file %s exceeds %d bytes", name, bs.options.MaxFileSize), nil)
+	}
+
+	return content, nil
+}
+
+// manifest parses the bundle manifest, or lists all configuration files in
+// lexical order when the bundle has none.
+func (bs *BundleSource) manifest(entries map[string][]byte) (*bundleManifest, error) {
+	candidates := defaultBundleManifests
+	if bs.options.Manifest != "" {
+		candidates = []string{bs.options.Manifest}
+	}
+
+	for _, name := range candidates {
+		if _, ok := entries[name]; !ok {
+			continue
+		}
+
+		data, err := bs.parseEntry(name, entries)
+		if err != nil {
+			return nil, err
+		}
+
+		manifest := &bundleManifest{}
+
+		if version, ok := data["version"]; ok {
+			manifest.Version = fmt.Sprint(version)
+		}
+
+		files, ok := data["files"].([]any)
+		if !ok {
+			return nil, configcore.ErrConfigError("bundle manifest "+name+" must list files", nil)
+		}
+
+		for _, file := range files {
+			fileName, ok := cleanBundleName(fmt.Sprint(file))
+			if !ok {
+				return nil, configcore.ErrConfigError(fmt.Sprintf("invalid file %v in bundle manifest", file), nil)
+			}
+
+			if _, exists := entries[fileName]; !exists {
+				return nil, configcore.ErrConfigError("bundle manifest lists missing file "+fileName, nil)
+			}
+
+			manifest.Files = append(manifest.Files, fileName)
+		}
+
+		return manifest, nil
+	}
+
+	if bs.options.Manifest != "" {
+		return nil, configcore.ErrConfigError("bundle manifest not found: "+bs.options.Manifest, nil)
+	}
+
+	manifest := &bundleManifest{}
+
+	for name := range entries {
+		if _, ok := processorForExtension(strings.ToLower(path.Ext(name))); ok {
+			manifest.Files = append(manifest.Files, name)
+		}
+	}
+
+	sort.Strings(manifest.Files)
+
+	return manifest, nil
+}
+
+// parseEntry parses a bundle entry by its extension.
+func (bs *BundleSource) parseEntry(name string, entries map[string][]byte) (map[string]any, error) {
+	processor, ok := processorForExtension(strings.ToLower(path.Ext(name)))
+	if !ok {
+		return nil, configcore.ErrConfigError("cannot detect format of bundle file "+name, nil)
+	}
+
+	data, err := processor.Parse(entries[name])
+	if err != nil {
+		return nil, configcore.ErrConfigError("failed to parse bundle file "+name, err)
+	}
+
+	if data == nil {
+		data = make(map[string]any)
+	}
+
+	if err := processor.Validate(data); err != nil {
+		return nil, configcore.ErrConfigError("validation failed for bundle file "+name, err)
+	}
+
+	if bs.options.ExpandEnvVars {
+		data = expandEnvInMap(data)
+	}
+
+	return data, nil
+}
+
+// watchLoop reloads the bundle after its file or signature changes, waiting
+// for the debounce period so that a bundle and its signature replaced
+// together are verified as a pair.
+func (bs *BundleSource) watchLoop(ctx context.Context, watcher *fsnotify.Watcher, stop chan struct{}, callback func(map[string]any)) {
+	defer func() {
+		if r := recover(); r != nil {
+			if bs.logger != nil {
+				bs.logger.Error("panic in bundle watch loop",
+					logger.String("path", bs.path),
+					logger.Any("panic", r),
+				)
+			}
+		}
+	}()
+
+	bundlePath := filepath.Clean(bs.path)
+	sigPath := filepath.Clean(bs.sigPath)
+
+	var pending <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+
+			name := filepath.Clean(event.Name)
+			if name != bundlePath && name != sigPath {
+				continue
+			}
+
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+				pending = time.After(bs.options.WatchDebounce)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+
+			bs.handleWatchError(err)
+		case <-pending:
+			pending = nil
+
+			bs.reloadIfChanged(ctx, callback)
+		}
+	}
+}
+
+// reloadIfChanged reloads the bundle when its digest differs from the last
+// load. Bundles failing verification are rejected and the previous
+// configuration stays in effect.
+func (bs *BundleSource) reloadIfChanged(ctx context.Context, callback func(map[string]any)) {
+	archive, err := os.ReadFile(bs.path)
+	if err != nil {
+		bs.handleWatchError(err)
+
+		return
+	}
+
+	digest := sha256.Sum256(archive)
+	if hex.EncodeToString(digest[:]) == bs.GetDigest() {
+		return
+	}
+
+	config, err := bs.Load(ctx)
+	if err != nil {
+		bs.handleWatchError(err)
+
+		return
+	}
+
+	if bs.logger != nil {
+		bs.logger.Info("bundle replaced, configuration reloaded",
+			logger.String("path", bs.path),
+			logger.String("sha256", bs.GetDigest()),
+		)
+	}
+
+	if callback != nil {
+		callback(config)
+	}
+}
+
+// handleWatchError handles errors during watching.
+func (bs *BundleSource) handleWatchError(err error) {
+	if bs.logger != nil {
+		bs.logger.Error("bundle watch error",
+			logger.String("path", bs.path),
+			logger.Error(err),
+		)
+	}
+
+	if bs.errorHandler != nil {
+		_ = bs.errorHandler.HandleError(context.Background(), configcore.ErrConfigError("bundle watch error for "+bs.path, err))
+	}
+}
+
+// cleanBundleName normalises an archive entry name, rejecting absolute
+// names and names escaping the archive root.
+func cleanBundleName(name string) (string, bool) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if strings.HasPrefix(name, "/") {
+		return "", false
+	}
+
+	cleaned := path.Clean(name)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", false
+	}
+
+	return cleaned, true
+}
+
+// parseEd25519PublicKey parses a raw, hex, base64 or PEM-encoded ed25519
+// public key.
+func parseEd25519PublicKey(data []byte) (ed25519.PublicKey, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, configcore.ErrConfigError("failed to parse PEM public key", err)
+		}
+
+		edKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, configcore.ErrConfigError("PEM public key is not ed25519", nil)
+		}
+
+		return edKey, nil
+	}
+
+	key, err := decodeKeyMaterial(data, ed25519.PublicKeySize)
+	if err != nil {
+		return nil, configcore.ErrConfigError("invalid ed25519 public key", err)
+	}
+
+	return ed25519.PublicKey(key), nil
+}
+
+// decodeKeyMaterial decodes raw, hex or base64 bytes of the expected size.
+func decodeKeyMaterial(data []byte, size int) ([]byte, error) {
+	if len(data) == size {
+		return data, nil
+	}
+
+	text := strings.TrimSpace(string(data))
+
+	if decoded, err := hex.DecodeString(text); err == nil && len(decoded) == size {
+		return decoded, nil
+	}
+
+	if decoded, err := base64.StdEncoding.DecodeString(text); err == nil && len(decoded) == size {
+		return decoded, nil
+	}
+
+	return nil, fmt.Errorf("expected %d bytes as raw, hex or base64", size)
+}
+
+// uniqueStrings returns the distinct values in order.
+func uniqueStrings(values ...string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+
+	return result
+}
+
+// BundleSourceFactory creates bundle configuration sources.
+type BundleSourceFactory struct {
+	logger       logger.Logger
+	errorHandler errors.ErrorHandler
+}
+
+// NewBundleSourceFactory creates a new bundle source factory.
+func NewBundleSourceFactory(logger logger.Logger, errorHandler errors.ErrorHandler) *BundleSourceFactory {
+	return &BundleSourceFactory{
+		logger:       logger,
+		errorHandler: errorHandler,
+	}
+}
+
+// CreateFromConfig creates a bundle source from configuration.
+func (factory *BundleSourceFactory) CreateFromConfig(config BundleSourceConfig) (configcore.ConfigSource, error) {
+	options := BundleSourceOptions{
+		Priority:      config.Priority,
+		PublicKeyFile: config.PublicKeyFile,
+		SignaturePath: config.SignaturePath,
+		AllowUnsigned: config.AllowUnsigned,
+		Manifest:      config.Manifest,
+		MaxFileSize:   config.MaxFileSize,
+		ExpandEnvVars: config.ExpandEnvVars,
+		WatchEnabled:  config.WatchEnabled,
+		WatchDebounce: config.WatchDebounce,
+		Logger:        factory.logger,
+		ErrorHandler:  factory.errorHandler,
+	}
+
+	return NewBundleSource(config.Path, options)
+}
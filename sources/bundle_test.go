@@ -0,0 +1,299 @@
+package sources
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	configcore "github.com/xraph/confy/internal"
+)
+
+// buildTarGz returns a tar.gz archive holding files in the given order.
+func buildTarGz(t *testing.T, names []string, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for _, name := range names {
+		content := files[name]
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// buildZip returns a zip archive holding files in the given order.
+func buildZip(t *testing.T, names []string, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	zw := zip.NewWriter(&buf)
+
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := w.Write([]byte(files[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// writeSignedBundle writes a bundle and its base64 signature next to it.
+func writeSignedBundle(t *testing.T, path string, archive []byte, key ed25519.PrivateKey) {
+	t.Helper()
+
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(key, archive))
+	if err := os.WriteFile(path+".sig", []byte(signature+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, archive, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+var testBundleFiles = map[string]string{
+	"manifest.yaml":     "version: \"1.2\"\nfiles:\n  - base.yaml\n  - ./env/prod.json\n  - overrides.toml\n",
+	"base.yaml":         "server:\n  port: 8080\n  host: localhost\nlevel: info\n",
+	"env/prod.json":     `{"server": {"host": "prod.internal"}, "level": "warn"}`,
+	"overrides.toml":    "level = \"error\"\n",
+	"README.md":         "not configuration",
+	"unused/extra.yaml": "level: ignored\n",
+}
+
+func TestBundleSource_LoadManifestOrder(t *testing.T) {
+	public, private, _ := ed25519.GenerateKey(rand.Reader)
+	dir := t.TempDir()
+
+	// Archive order differs from the manifest order on purpose
+	names := []string{"overrides.toml", "README.md", "env/prod.json", "base.yaml", "unused/extra.yaml", "manifest.yaml"}
+
+	for _, tc := range []struct {
+		name    string
+		archive []byte
+	}{
+		{"bundle.tar.gz", buildTarGz(t, names, testBundleFiles)},
+		{"bundle.zip", buildZip(t, names, testBundleFiles)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, tc.name)
+			writeSignedBundle(t, path, tc.archive, private)
+
+			source, err := NewBundleSource(path, BundleSourceOptions{PublicKey: public})
+			if err != nil {
+				t.Fatalf("NewBundleSource() error = %v", err)
+			}
+
+			data, err := source.Load(context.Background())
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			server, _ := data["server"].(map[string]any)
+			if data["level"] != "error" || server["host"] != "prod.internal" || server["port"] != 8080 {
+				t.Errorf("Load() = %v, want files merged in manifest order", data)
+			}
+
+			metadata := &configcore.SourceMetadata{}
+			source.(configcore.SourceMetadataReporter).ReportMetadata(metadata)
+
+			if metadata.Properties["verified"] != true || metadata.Properties["version"] != "1.2" {
+				t.Errorf("metadata = %v, want verified version 1.2", metadata.Properties)
+			}
+
+			files, _ := metadata.Properties["files"].([]string)
+			if strings.Join(files, ",") != "base.yaml,env/prod.json,overrides.toml" {
+				t.Errorf("files = %v", files)
+			}
+		})
+	}
+}
+
+func TestBundleSource_WithoutManifest(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bundle.tar.gz")
+
+	files := map[string]string{"b.yaml": "level: b\n", "a.yaml": "level: a\nname: app\n", "notes.txt": "x"}
+	if err := os.WriteFile(path, buildTarGz(t, []string{"b.yaml", "notes.txt", "a.yaml"}, files), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewBundleSource(path, BundleSourceOptions{}); err == nil {
+		t.Error("NewBundleSource() should require a public key unless AllowUnsigned is set")
+	}
+
+	source, err := NewBundleSource(path, BundleSourceOptions{AllowUnsigned: true})
+	if err != nil {
+		t.Fatalf("NewBundleSource() error = %v", err)
+	}
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if data["level"] != "b" || data["name"] != "app" {
+		t.Errorf("Load() = %v, want lexical merge order", data)
+	}
+}
+
+func TestBundleSource_RejectsBadBundles(t *testing.T) {
+	public, private, _ := ed25519.GenerateKey(rand.Reader)
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	dir := t.TempDir()
+
+	archive := buildTarGz(t, []string{"base.yaml"}, testBundleFiles)
+
+	tests := []struct {
+		name  string
+		write func(path string)
+		want  string
+	}{
+		{"wrong key", func(path string) { writeSignedBundle(t, path, archive, otherKey) }, "verification failed"},
+		{"tampered", func(path string) {
+			writeSignedBundle(t, path, archive, private)
+			tampered := buildTarGz(t, []string{"overrides.toml"}, testBundleFiles)
+			_ = os.WriteFile(path, tampered, 0644)
+		}, "verification failed"},
+		{"missing signature", func(path string) { _ = os.WriteFile(path, archive, 0644) }, "signature"},
+		{"escaping entry", func(path string) {
+			writeSignedBundle(t, path, buildTarGz(t, []string{"../base.yaml"}, map[string]string{"../base.yaml": "a: 1\n"}), private)
+		}, "invalid file name"},
+		{"missing manifest file", func(path string) {
+			writeSignedBundle(t, path, buildTarGz(t, []string{"manifest.yaml"}, testBundleFiles), private)
+		}, "missing file"},
+		{"not an archive", func(path string) { writeSignedBundle(t, path, []byte("level: info\n"), private) }, "unsupported bundle format"},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "bundle"+string(rune('a'+i))+".tar.gz")
+			tt.write(path)
+
+			source, _ := NewBundleSource(path, BundleSourceOptions{PublicKey: public})
+			if _, err := source.Load(context.Background()); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEd25519PublicKey(t *testing.T) {
+	public, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	for name, encoded := range map[string][]byte{
+		"raw":    public,
+		"base64": []byte(base64.StdEncoding.EncodeToString(public) + "\n"),
+	} {
+		key, err := parseEd25519PublicKey(encoded)
+		if err != nil || !key.Equal(public) {
+			t.Errorf("parseEd25519PublicKey(%s) = %x, %v", name, key, err)
+		}
+	}
+
+	if _, err := parseEd25519PublicKey([]byte("short")); err == nil {
+		t.Error("parseEd25519PublicKey() should reject malformed keys")
+	}
+}
+
+func TestBundleSource_WatchReplacement(t *testing.T) {
+	public, private, _ := ed25519.GenerateKey(rand.Reader)
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bundle.tar.gz")
+
+	writeSignedBundle(t, path, buildTarGz(t, []string{"base.yaml"}, testBundleFiles), private)
+
+	source, _ := NewBundleSource(path, BundleSourceOptions{
+		PublicKey:     public,
+		WatchEnabled:  true,
+		WatchDebounce: 20 * time.Millisecond,
+	})
+
+	if _, err := source.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	updates := make(chan map[string]any, 4)
+	if err := source.Watch(context.Background(), func(data map[string]any) { updates <- data }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer func() { _ = source.StopWatch() }()
+
+	// A bundle signed by another key must be ignored
+	staging := filepath.Join(t.TempDir(), "next.tar.gz")
+	next := buildTarGz(t, []string{"overrides.toml"}, testBundleFiles)
+
+	writeSignedBundle(t, staging, next, otherKey)
+
+	if err := os.Rename(staging+".sig", path+".sig"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Rename(staging, path); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case data := <-updates:
+		t.Fatalf("unexpected update %v for unverified bundle", data)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// Replace both files atomically with a correctly signed bundle
+	writeSignedBundle(t, staging, next, private)
+
+	if err := os.Rename(staging+".sig", path+".sig"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Rename(staging, path); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case data := <-updates:
+		if data["level"] != "error" {
+			t.Errorf("watched data = %v, want level=error", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for bundle replacement")
+	}
+}
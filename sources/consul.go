@@ -6,6 +6,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -16,50 +20,99 @@ import (
 	logger "github.com/xraph/go-utils/log"
 )
 
-// ConsulSource represents a Consul configuration source.
+// ConsulSource represents a Consul configuration source. Keys under the
+// prefix map to nested configuration; keys ending in .yaml, .yml, .json or
+// .toml are parsed as documents and merged at their path. In single-key
+// mode one key holds the whole configuration document. Several prefixes
+// can be layered, later ones overriding earlier ones.
 type ConsulSource struct {
 	name         string
 	client       *api.Client
 	prefix       string
+	prefixes     []string
 	priority     int
 	watching     bool
 	watchStop    chan struct{}
-	lastIndex    uint64
+	lastIndex    map[string]uint64
+	layers       map[string]map[string]any
+	documents    map[string]consulDocument
+	modifyIndex  map[string]uint64
+	token        string
 	logger       logger.Logger
 	errorHandler errors.ErrorHandler
 	options      ConsulSourceOptions
 	mu           sync.RWMutex
 }
 
+// consulDocument caches a parsed document key. A value that fails to parse
+// is reported once and the last good document is kept.
+type consulDocument struct {
+	modifyIndex uint64
+	config      map[string]any
+	failedIndex uint64
+	err         error
+}
+
 // ConsulSourceOptions contains options for Consul configuration sources.
 type ConsulSourceOptions struct {
-	Name         string
-	Address      string
-	Token        string
-	Datacenter   string
-	Prefix       string
+	Name    string
+	Address string
+	Token   string
+	// TokenFile is read before every request instead of Token, so that a
+	// rotated ACL token takes effect without restarting.
+	TokenFile  string
+	Datacenter string
+	// Namespace and Partition select a Consul Enterprise namespace and
+	// admin partition.
+	Namespace string
+	Partition string
+	Prefix    string
+	// Prefixes are layered over the prefix argument in order, for example
+	// global/, region/eu/ and app/foo/. Persist writes to the last one.
+	// In single-key mode each entry is a document key.
+	Prefixes     []string
 	Priority     int
 	WatchEnabled bool
 	Timeout      time.Duration
-	RetryCount   int
-	RetryDelay   time.Duration
-	TLS          *ConsulTLSConfig
+	// Deprecated: the watch retries indefinitely, backing off from
+	// RetryDelay up to MaxRetryDelay.
+	RetryCount    int
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+	TLS           *ConsulTLSConfig
+	// SingleKey treats the prefix as one key, such as app/config.yaml,
+	// holding the entire configuration document.
+	SingleKey bool
+	// Format overrides the document format detected from the key extension
+	// in single-key mode.
+	Format string
+	// WriteEnabled allows Persist to write keys under the prefix, using
+	// check-and-set against the last observed ModifyIndex.
+	WriteEnabled bool
 	Logger       logger.Logger
 	ErrorHandler errors.ErrorHandler
 }
 
 // ConsulSourceConfig contains configuration for creating Consul sources.
 type ConsulSourceConfig struct {
-	Address      string           `json:"address"       yaml:"address"`
-	Token        string           `json:"token"         yaml:"token"`
-	Datacenter   string           `json:"datacenter"    yaml:"datacenter"`
-	Prefix       string           `json:"prefix"        yaml:"prefix"`
-	Priority     int              `json:"priority"      yaml:"priority"`
-	WatchEnabled bool             `json:"watch_enabled" yaml:"watch_enabled"`
-	Timeout      time.Duration    `json:"timeout"       yaml:"timeout"`
-	RetryCount   int              `json:"retry_count"   yaml:"retry_count"`
-	RetryDelay   time.Duration    `json:"retry_delay"   yaml:"retry_delay"`
-	TLS          *ConsulTLSConfig `json:"tls"           yaml:"tls"`
+	Address       string           `json:"address"         yaml:"address"`
+	Token         string           `json:"token"           yaml:"token"`
+	TokenFile     string           `json:"token_file"      yaml:"token_file"`
+	Datacenter    string           `json:"datacenter"      yaml:"datacenter"`
+	Namespace     string           `json:"namespace"       yaml:"namespace"`
+	Partition     string           `json:"partition"       yaml:"partition"`
+	Prefix        string           `json:"prefix"          yaml:"prefix"`
+	Prefixes      []string         `json:"prefixes"        yaml:"prefixes"`
+	Priority      int              `json:"priority"        yaml:"priority"`
+	WatchEnabled  bool             `json:"watch_enabled"   yaml:"watch_enabled"`
+	Timeout       time.Duration    `json:"timeout"         yaml:"timeout"`
+	RetryCount    int              `json:"retry_count"     yaml:"retry_count"`
+	RetryDelay    time.Duration    `json:"retry_delay"     yaml:"retry_delay"`
+	MaxRetryDelay time.Duration    `json:"max_retry_delay" yaml:"max_retry_delay"`
+	TLS           *ConsulTLSConfig `json:"tls"             yaml:"tls"`
+	SingleKey     bool             `json:"single_key"      yaml:"single_key"`
+	Format        string           `json:"format"          yaml:"format"`
+	WriteEnabled  bool             `json:"write_enabled"   yaml:"write_enabled"`
 }
 
 // ConsulTLSConfig contains TLS configuration for Consul.
@@ -89,16 +142,42 @@ func NewConsulSource(prefix string, options ConsulSourceOptions) (configcore.Con
 		options.RetryDelay = 5 * time.Second
 	}
 
+	if options.MaxRetryDelay == 0 {
+		options.MaxRetryDelay = 2 * time.Minute
+	}
+
+	prefixes := append([]string{prefix}, options.Prefixes...)
+	if prefix == "" && len(options.Prefixes) > 0 {
+		prefixes = prefixes[1:]
+	}
+
+	prefixes = uniqueStrings(prefixes...)
+
+	if options.SingleKey {
+		for _, key := range prefixes {
+			if key == "" || strings.HasSuffix(key, "/") {
+				return nil, configcore.ErrConfigError("single-key mode requires a key, not a prefix: "+key, nil)
+			}
+
+			if _, err := getFormatProcessor(documentFormat(key, options.Format)); err != nil {
+				return nil, configcore.ErrConfigError("cannot determine document format of Consul key "+key, err)
+			}
+		}
+	}
+
 	name := options.Name
 	if name == "" {
-		name = "consul:" + prefix
+		name = "consul:" + strings.Join(prefixes, ",")
 	}
 
 	// Create Consul client configuration
 	consulConfig := api.DefaultConfig()
 	consulConfig.Address = options.Address
 	consulConfig.Token = options.Token
+	consulConfig.TokenFile = options.TokenFile
 	consulConfig.Datacenter = options.Datacenter
+	consulConfig.Namespace = options.Namespace
+	consulConfig.Partition = options.Partition
 	consulConfig.WaitTime = options.Timeout
 
 	// Configure TLS if enabled
@@ -119,8 +198,13 @@ func NewConsulSource(prefix string, options ConsulSourceOptions) (configcore.Con
 	source := &ConsulSource{
 		name:         name,
 		client:       client,
-		prefix:       prefix,
+		prefix:       prefixes[len(prefixes)-1],
+		prefixes:     prefixes,
 		priority:     options.Priority,
+		lastIndex:    make(map[string]uint64),
+		layers:       make(map[string]map[string]any),
+		documents:    make(map[string]consulDocument),
+		token:        consulConfig.Token,
 		logger:       options.Logger,
 		errorHandler: options.ErrorHandler,
 		options:      options,
@@ -162,69 +246,55 @@ func (cs *ConsulSource) Priority() int {
 
 // Load loads configuration from Consul KV store.
 func (cs *ConsulSource) Load(ctx context.Context) (map[string]any, error) {
-	if cs.logger != nil {
-		cs.logger.Debug("loading configuration from Consul",
-			logger.String("prefix", cs.prefix),
-			logger.String("address", cs.options.Address),
-		)
-	}
-
-	kv := cs.client.KV()
-
-	pairs, meta, err := kv.List(cs.prefix, &api.QueryOptions{
-		RequireConsistent: true,
-		AllowStale:        false,
-	})
-	if err != nil {
-		return nil, configcore.ErrConfigError(fmt.Sprintf("failed to query Consul KV: %v", err), err)
-	}
-
-	// Update last index for watching
-	cs.mu.Lock()
-	cs.lastIndex = meta.LastIndex
-	cs.mu.Unlock()
-
-	config := make(map[string]any)
-
-	for _, pair := range pairs {
-		// Skip directories (keys ending with /)
-		if strings.HasSuffix(pair.Key, "/") {
-			continue
+	for _, prefix := range cs.prefixes {
+		if cs.logger != nil {
+			cs.logger.Debug("loading configuration from Consul",
+				logger.String("prefix", prefix),
+				logger.String("address", cs.options.Address),
+			)
 		}
 
-		// Remove prefix from key
-		key := strings.TrimPrefix(pair.Key, cs.prefix)
-		key = strings.TrimPrefix(key, "/")
+		config, meta, err := cs.fetch(prefix, &api.QueryOptions{
+			RequireConsistent: true,
+			AllowStale:        false,
+		})
+		if err != nil {
+			return nil, err
+		}
 
-		if key == "" {
-			continue
+		// Update last index for watching
+		cs.mu.Lock()
+		cs.layers[prefix] = config
+		cs.lastIndex[prefix] = meta.LastIndex
+		cs.mu.Unlock()
+
+		if cs.logger != nil {
+			cs.logger.Info("configuration loaded from Consul",
+				logger.String("prefix", prefix),
+				logger.Int("keys", len(config)),
+				logger.Uint64("last_index", meta.LastIndex),
+			)
 		}
+	}
 
-		// Convert Consul key format to nested structure
-		value, err := cs.parseValue(pair.Value)
-		if err != nil {
-			if cs.logger != nil {
-				cs.logger.Warn("failed to parse Consul value",
-					logger.String("key", pair.Key),
-					logger.Error(err),
-				)
-			}
+	return cs.mergeLayers(), nil
+}
 
-			continue
-		}
+// mergeLayers merges the configuration of each prefix in order.
+func (cs *ConsulSource) mergeLayers() map[string]any {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
 
-		cs.setNestedValue(config, key, value)
-	}
+	config := make(map[string]any)
+	merger := configcore.NewMergeUtil()
 
-	if cs.logger != nil {
-		cs.logger.Info("configuration loaded from Consul",
-			logger.String("prefix", cs.prefix),
-			logger.Int("keys", len(pairs)),
-			logger.Uint64("last_index", meta.LastIndex),
-		)
+	for _, prefix := range cs.prefixes {
+		if layer, ok := cs.layers[prefix]; ok {
+			merger.MergeInPlace(config, copyConfigMap(layer))
+		}
 	}
 
-	return config, nil
+	return config
 }
 
 // Watch starts watching Consul KV for changes.
@@ -243,12 +313,14 @@ func (cs *ConsulSource) Watch(ctx context.Context, callback func(map[string]any)
 	cs.watchStop = make(chan struct{})
 	cs.watching = true
 
-	// Start watching goroutine
-	go cs.watchLoop(ctx, callback)
+	// Blocking queries cover one prefix each
+	for _, prefix := range cs.prefixes {
+		go cs.watchLoop(ctx, prefix, cs.watchStop, callback)
+	}
 
 	if cs.logger != nil {
 		cs.logger.Info("started watching Consul KV",
-			logger.String("prefix", cs.prefix),
+			logger.String("prefixes", strings.Join(cs.prefixes, ",")),
 		)
 	}
 
@@ -273,7 +345,7 @@ func (cs *ConsulSource) StopWatch() error {
 
 	if cs.logger != nil {
 		cs.logger.Info("stopped watching Consul KV",
-			logger.String("prefix", cs.prefix),
+			logger.String("prefixes", strings.Join(cs.prefixes, ",")),
 		)
 	}
 
@@ -284,7 +356,7 @@ func (cs *ConsulSource) StopWatch() error {
 func (cs *ConsulSource) Reload(ctx context.Context) error {
 	if cs.logger != nil {
 		cs.logger.Info("reloading Consul configuration",
-			logger.String("prefix", cs.prefix),
+			logger.String("prefixes", strings.Join(cs.prefixes, ",")),
 		)
 	}
 
@@ -304,148 +376,561 @@ func (cs *ConsulSource) SupportsSecrets() bool {
 	return true
 }
 
-// GetSecret retrieves a secret from Consul KV.
+// GetSecret retrieves a secret from Consul KV, looking in the most specific
+// prefix first.
 func (cs *ConsulSource) GetSecret(ctx context.Context, key string) (string, error) {
 	kv := cs.client.KV()
 
-	// Prepend prefix if not already present
-	secretKey := key
-	if !strings.HasPrefix(key, cs.prefix) {
-		secretKey = cs.prefix + "/" + key
-	}
+	for i := len(cs.prefixes) - 1; i >= 0; i-- {
+		prefix := cs.prefixes[i]
 
-	pair, _, err := kv.Get(secretKey, &api.QueryOptions{
-		RequireConsistent: true,
-	})
-	if err != nil {
-		return "", configcore.ErrConfigError(fmt.Sprintf("failed to get secret from Consul: %v", err), err)
-	}
+		// Prepend prefix if not already present
+		secretKey := key
+		if !strings.HasPrefix(key, prefix) {
+			secretKey = strings.TrimSuffix(prefix, "/") + "/" + key
+		}
+
+		pair, _, err := kv.Get(secretKey, cs.queryOptions(&api.QueryOptions{
+			RequireConsistent: true,
+		}).WithContext(ctx))
+		if err != nil {
+			return "", configcore.ErrConfigError(fmt.Sprintf("failed to get secret from Consul: %v", err), err)
+		}
 
-	if pair == nil {
-		return "", configcore.ErrConfigError("secret not found in Consul: "+key, nil)
+		if pair != nil {
+			return string(pair.Value), nil
+		}
 	}
 
-	return string(pair.Value), nil
+	return "", configcore.ErrConfigError("secret not found in Consul: "+key, nil)
 }
 
-// watchLoop is the main watching loop for Consul KV changes.
-func (cs *ConsulSource) watchLoop(ctx context.Context, callback func(map[string]any)) {
+// watchLoop is the main watching loop for Consul KV changes under one
+// prefix. A change reloads that layer and notifies with all layers merged.
+// Errors are retried with exponential backoff until the watch is stopped.
+func (cs *ConsulSource) watchLoop(ctx context.Context, prefix string, stop <-chan struct{}, callback func(map[string]any)) {
 	defer func() {
 		if r := recover(); r != nil {
 			if cs.logger != nil {
 				cs.logger.Error("panic in Consul watch loop",
-					logger.String("prefix", cs.prefix),
+					logger.String("prefix", prefix),
 					logger.Any("panic", r),
 				)
 			}
 		}
 	}()
 
-	kv := cs.client.KV()
-	retryCount := 0
+	// Abort blocking queries as soon as the watch stops
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	failures := 0
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-cs.watchStop:
-			return
 		default:
 		}
 
 		// Use blocking query to watch for changes
 		cs.mu.RLock()
-		lastIndex := cs.lastIndex
+		lastIndex := cs.lastIndex[prefix]
 		cs.mu.RUnlock()
 
-		pairs, meta, err := kv.List(cs.prefix, &api.QueryOptions{
+		config, meta, err := cs.fetch(prefix, (&api.QueryOptions{
 			WaitIndex: lastIndex,
 			WaitTime:  cs.options.Timeout,
-		})
+		}).WithContext(ctx))
 		if err != nil {
-			retryCount++
-			if retryCount <= cs.options.RetryCount {
-				if cs.logger != nil {
-					cs.logger.Warn("Consul watch error, retrying",
-						logger.String("prefix", cs.prefix),
-						logger.Int("attempt", retryCount),
-						logger.Error(err),
-					)
-				}
+			if ctx.Err() != nil {
+				return
+			}
 
-				select {
-				case <-ctx.Done():
-					return
-				case <-cs.watchStop:
-					return
-				case <-time.After(cs.options.RetryDelay):
-					continue
-				}
-			} else {
-				cs.handleWatchError(err)
+			failures++
+			cs.handleWatchError(prefix, err)
 
+			select {
+			case <-ctx.Done():
 				return
+			case <-time.After(backoffDelay(failures, cs.options.RetryDelay, cs.options.MaxRetryDelay)):
 			}
-		}
 
-		// Reset retry count on successful operation
-		retryCount = 0
+			continue
+		}
 
-		// Check if index has changed (indicating changes)
-		if meta.LastIndex > lastIndex {
-			cs.mu.Lock()
-			cs.lastIndex = meta.LastIndex
-			cs.mu.Unlock()
+		failures = 0
 
+		if meta.LastIndex < lastIndex {
+			// The index went backwards, for example after a snapshot
+			// restore: start over with a full reload
 			if cs.logger != nil {
-				cs.logger.Info("Consul KV changes detected",
-					logger.String("prefix", cs.prefix),
+				cs.logger.Warn("Consul index went backwards, reloading",
+					logger.String("prefix", prefix),
+					logger.Uint64("last_index", lastIndex),
 					logger.Uint64("new_index", meta.LastIndex),
 				)
 			}
 
-			// Parse changes and notify callback
-			config := make(map[string]any)
+			cs.mu.Lock()
+			cs.lastIndex[prefix] = 0
+			cs.mu.Unlock()
 
-			for _, pair := range pairs {
-				if strings.HasSuffix(pair.Key, "/") {
-					continue
-				}
+			continue
+		}
+
+		if meta.LastIndex == lastIndex {
+			continue
+		}
 
-				key := strings.TrimPrefix(pair.Key, cs.prefix)
-				key = strings.TrimPrefix(key, "/")
+		cs.mu.Lock()
+		previous := cs.layers[prefix]
+		cs.layers[prefix] = config
+		cs.lastIndex[prefix] = meta.LastIndex
+		cs.mu.Unlock()
 
-				if key == "" {
-					continue
-				}
+		// The index also moves for writes that leave the configuration as is
+		if reflect.DeepEqual(previous, config) {
+			continue
+		}
 
-				value, err := cs.parseValue(pair.Value)
-				if err != nil {
-					continue
-				}
+		deleted := deletedConsulKeys(previous, config)
+
+		if cs.logger != nil {
+			cs.logger.Info("Consul KV changes detected",
+				logger.String("prefix", prefix),
+				logger.Uint64("new_index", meta.LastIndex),
+				logger.Int("deleted_keys", len(deleted)),
+			)
 
-				cs.setNestedValue(config, key, value)
+			if len(deleted) > 0 {
+				cs.logger.Debug("Consul keys deleted",
+					logger.String("prefix", prefix),
+					logger.String("keys", strings.Join(deleted, ",")),
+				)
 			}
+		}
 
-			// Notify callback
-			if callback != nil {
-				go func() {
-					defer func() {
-						if r := recover(); r != nil {
-							if cs.logger != nil {
-								cs.logger.Error("panic in Consul watch callback",
-									logger.String("prefix", cs.prefix),
-									logger.Any("panic", r),
-								)
-							}
+		merged := cs.mergeLayers()
+
+		// Notify callback
+		if callback != nil {
+			go func() {
+				defer func() {
+					if r := recover(); r != nil {
+						if cs.logger != nil {
+							cs.logger.Error("panic in Consul watch callback",
+								logger.String("prefix", prefix),
+								logger.Any("panic", r),
+							)
 						}
-					}()
-
-					callback(config)
+					}
 				}()
+
+				callback(merged)
+			}()
+		}
+	}
+}
+
+// deletedConsulKeys returns the sorted dot-separated keys present in
+// previous but not in current.
+func deletedConsulKeys(previous, current map[string]any) []string {
+	before := make(map[string]any)
+	after := make(map[string]any)
+
+	flattenConfig(previous, "", before)
+	flattenConfig(current, "", after)
+
+	var deleted []string
+
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			deleted = append(deleted, key)
+		}
+	}
+
+	sort.Strings(deleted)
+
+	return deleted
+}
+
+// fetch queries a prefix, or a single key, and converts the result to
+// configuration.
+func (cs *ConsulSource) fetch(prefix string, options *api.QueryOptions) (map[string]any, *api.QueryMeta, error) {
+	kv := cs.client.KV()
+	options = cs.queryOptions(options)
+
+	if cs.options.SingleKey {
+		pair, meta, err := kv.Get(prefix, options)
+		if err != nil {
+			return nil, nil, configcore.ErrConfigError(fmt.Sprintf("failed to query Consul KV: %v", err), err)
+		}
+
+		if pair == nil {
+			cs.mu.RLock()
+			_, loaded := cs.layers[prefix]
+			cs.mu.RUnlock()
+
+			// A key that was loaded before has been deleted
+			if loaded {
+				return make(map[string]any), meta, nil
 			}
+
+			return nil, nil, configcore.ErrConfigError("Consul key not found: "+prefix, nil)
+		}
+
+		config, err := cs.parseKey(pair, documentFormat(prefix, cs.options.Format))
+		if err != nil {
+			return nil, nil, configcore.ErrConfigError("failed to parse Consul key "+prefix, err)
+		}
+
+		return config, meta, nil
+	}
+
+	pairs, meta, err := kv.List(prefix, options)
+	if err != nil {
+		return nil, nil, configcore.ErrConfigError(fmt.Sprintf("failed to query Consul KV: %v", err), err)
+	}
+
+	// Remember modify indexes of the written prefix for check-and-set
+	if prefix == cs.prefix {
+		modifyIndex := make(map[string]uint64, len(pairs))
+		for _, pair := range pairs {
+			modifyIndex[pair.Key] = pair.ModifyIndex
 		}
+
+		cs.mu.Lock()
+		cs.modifyIndex = modifyIndex
+		cs.mu.Unlock()
 	}
+
+	return cs.parsePairs(prefix, pairs), meta, nil
+}
+
+// queryOptions sets the current ACL token on options.
+func (cs *ConsulSource) queryOptions(options *api.QueryOptions) *api.QueryOptions {
+	options.Token = cs.currentToken()
+
+	return options
+}
+
+// currentToken returns the ACL token, re-reading TokenFile so that rotated
+// tokens are picked up. The last token read is kept if the file cannot be
+// read, for example while it is being replaced.
+func (cs *ConsulSource) currentToken() string {
+	if cs.options.TokenFile == "" {
+		return cs.options.Token
+	}
+
+	data, err := os.ReadFile(cs.options.TokenFile)
+
+	token := strings.TrimSpace(string(data))
+	if err == nil && token == "" {
+		err = fmt.Errorf("token file %s is empty", cs.options.TokenFile)
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if err == nil {
+		if token != cs.token && cs.logger != nil {
+			cs.logger.Info("Consul token file changed",
+				logger.String("token_file", cs.options.TokenFile),
+			)
+		}
+
+		cs.token = token
+	} else if cs.logger != nil {
+		cs.logger.Warn("failed to read Consul token file, keeping previous token",
+			logger.String("token_file", cs.options.TokenFile),
+			logger.Error(err),
+		)
+	}
+
+	return cs.token
+}
+
+// documentFormat returns format, or the format named by the key extension.
+func documentFormat(key, format string) string {
+	if format != "" {
+		return format
+	}
+
+	return strings.TrimPrefix(path.Ext(key), ".")
+}
+
+// IsWritable returns true if writes are enabled.
+func (cs *ConsulSource) IsWritable() bool {
+	return cs.options.WriteEnabled && !cs.options.SingleKey
+}
+
+// Persist writes value at the dot-separated key below the last prefix. Maps are
+// written as one Consul key per leaf, so limits.rps becomes prefix/limits/rps.
+// Strings are stored as is and other values as JSON. All keys are written in
+// one transaction, each checked against the ModifyIndex last observed by Load
+// or the watch; keys that did not exist must still be absent. A write that
+// loses the race fails with an error for which IsWriteConflict is true.
+func (cs *ConsulSource) Persist(ctx context.Context, key string, value any) error {
+	if !cs.IsWritable() {
+		return configcore.ErrConfigError("Consul source is not writable", nil)
+	}
+
+	leaves := make(map[string]any)
+	if nested, ok := value.(map[string]any); ok && len(nested) > 0 {
+		flattenConfig(nested, key, leaves)
+	} else {
+		leaves[key] = value
+	}
+
+	leafKeys := make([]string, 0, len(leaves))
+	for leafKey := range leaves {
+		leafKeys = append(leafKeys, leafKey)
+	}
+
+	sort.Strings(leafKeys)
+
+	options := cs.queryOptions(&api.QueryOptions{}).WithContext(ctx)
+
+	cs.mu.RLock()
+	ops := make(api.TxnOps, 0, len(leafKeys))
+
+	for _, leafKey := range leafKeys {
+		encoded, err := encodeConsulValue(leaves[leafKey])
+		if err != nil {
+			cs.mu.RUnlock()
+
+			return configcore.ErrConfigError("failed to encode value for key "+leafKey, err)
+		}
+
+		consulKey := cs.consulKey(leafKey)
+		ops = append(ops, &api.TxnOp{KV: &api.KVTxnOp{
+			Verb:      api.KVCAS,
+			Key:       consulKey,
+			Value:     encoded,
+			Index:     cs.modifyIndex[consulKey],
+			Namespace: cs.options.Namespace,
+			Partition: cs.options.Partition,
+		}})
+	}
+	cs.mu.RUnlock()
+
+	ok, response, _, err := cs.client.Txn().Txn(ops, options)
+	if err != nil {
+		return configcore.ErrConfigError(fmt.Sprintf("failed to write Consul KV: %v", err), err)
+	}
+
+	if !ok {
+		conflictKey := key
+		reason := "transaction rolled back"
+
+		if response != nil && len(response.Errors) > 0 {
+			if index := response.Errors[0].OpIndex; index >= 0 && index < len(leafKeys) {
+				conflictKey = leafKeys[index]
+			}
+
+			reason = response.Errors[0].What
+		}
+
+		return configcore.ErrWriteConflict(conflictKey, fmt.Errorf("consul: %s", reason))
+	}
+
+	// Track the new indexes so that a following write does not conflict
+	// with our own change before the watch observes it
+	cs.mu.Lock()
+	if cs.modifyIndex == nil {
+		cs.modifyIndex = make(map[string]uint64)
+	}
+
+	for _, result := range response.Results {
+		if result.KV != nil {
+			cs.modifyIndex[result.KV.Key] = result.KV.ModifyIndex
+		}
+	}
+	cs.mu.Unlock()
+
+	if cs.logger != nil {
+		cs.logger.Info("persisted configuration to Consul",
+			logger.String("prefix", cs.prefix),
+			logger.String("key", key),
+			logger.Int("keys", len(leafKeys)),
+		)
+	}
+
+	return nil
+}
+
+// consulKey converts a dot-separated configuration key to a Consul key under
+// the prefix.
+func (cs *ConsulSource) consulKey(key string) string {
+	consulKey := strings.ReplaceAll(key, ".", "/")
+
+	prefix := strings.TrimSuffix(cs.prefix, "/")
+	if prefix == "" {
+		return consulKey
+	}
+
+	return prefix + "/" + consulKey
+}
+
+// encodeConsulValue encodes a value the way parseValue reads it back.
+func encodeConsulValue(value any) ([]byte, error) {
+	if s, ok := value.(string); ok {
+		return []byte(s), nil
+	}
+
+	return json.Marshal(value)
+}
+
+// parsePairs converts KV pairs under prefix to nested configuration.
+// Document keys are merged at their path first, so that individual keys
+// override values from documents.
+func (cs *ConsulSource) parsePairs(prefix string, pairs api.KVPairs) map[string]any {
+	config := make(map[string]any)
+	merger := configcore.NewMergeUtil()
+
+	var plain []*api.KVPair
+
+	present := make(map[string]bool, len(pairs))
+
+	for _, pair := range pairs {
+		present[pair.Key] = true
+
+		// Skip directories (keys ending with /)
+		if strings.HasSuffix(pair.Key, "/") {
+			continue
+		}
+
+		// Remove prefix from key
+		key := strings.TrimPrefix(pair.Key, prefix)
+		key = strings.TrimPrefix(key, "/")
+
+		if key == "" {
+			continue
+		}
+
+		ext := path.Ext(key)
+		if _, ok := processorForExtension(ext); !ok {
+			plain = append(plain, pair)
+
+			continue
+		}
+
+		document, err := cs.parseKey(pair, strings.TrimPrefix(ext, "."))
+		if err != nil {
+			continue
+		}
+
+		target := config
+
+		if docPath := strings.TrimSuffix(key, ext); docPath != "" {
+			for _, segment := range strings.Split(docPath, "/") {
+				next, ok := target[segment].(map[string]any)
+				if !ok {
+					next = make(map[string]any)
+					target[segment] = next
+				}
+
+				target = next
+			}
+		}
+
+		merger.MergeInPlace(target, document)
+	}
+
+	// Forget documents that were deleted
+	cs.mu.Lock()
+	for key := range cs.documents {
+		if strings.HasPrefix(key, prefix) && !present[key] {
+			delete(cs.documents, key)
+		}
+	}
+	cs.mu.Unlock()
+
+	for _, pair := range plain {
+		key := strings.TrimPrefix(strings.TrimPrefix(pair.Key, prefix), "/")
+
+		// Convert Consul key format to nested structure
+		cs.setNestedValue(config, key, cs.parseValue(pair.Value))
+	}
+
+	return config
+}
+
+// parseKey parses a document key, reusing the cached result while its
+// ModifyIndex is unchanged. Parse failures are reported to the error handler
+// once per value, and the last good document is returned in their place.
+func (cs *ConsulSource) parseKey(pair *api.KVPair, format string) (map[string]any, error) {
+	cs.mu.RLock()
+	document, cached := cs.documents[pair.Key]
+	cs.mu.RUnlock()
+
+	if cached && document.modifyIndex == pair.ModifyIndex {
+		return copyConfigMap(document.config), nil
+	}
+
+	if !cached || document.failedIndex != pair.ModifyIndex {
+		config, err := cs.parseDocument(format, pair.Value)
+		if err == nil {
+			document = consulDocument{modifyIndex: pair.ModifyIndex, config: config}
+		} else {
+			document.failedIndex = pair.ModifyIndex
+			document.err = err
+
+			cs.reportParseError(pair.Key, err)
+		}
+
+		cs.mu.Lock()
+		cs.documents[pair.Key] = document
+		cs.mu.Unlock()
+	}
+
+	if document.config == nil {
+		return nil, document.err
+	}
+
+	return copyConfigMap(document.config), nil
+}
+
+// reportParseError reports a value that cannot be parsed as a source error.
+func (cs *ConsulSource) reportParseError(key string, err error) {
+	if cs.logger != nil {
+		cs.logger.Warn("failed to parse Consul document",
+			logger.String("key", key),
+			logger.Error(err),
+		)
+	}
+
+	if cs.errorHandler != nil {
+		_ = cs.errorHandler.HandleError(context.Background(), configcore.ErrSourceError(cs.name, "parse "+key, err))
+	}
+}
+
+// parseDocument parses a KV value with the processor for format.
+func (cs *ConsulSource) parseDocument(format string, data []byte) (map[string]any, error) {
+	processor, err := getFormatProcessor(format)
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := processor.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if document == nil {
+		document = make(map[string]any)
+	}
+
+	return document, nil
 }
 
 // testConnection tests the connection to Consul.
@@ -457,19 +942,19 @@ func (cs *ConsulSource) testConnection(ctx context.Context) error {
 }
 
 // parseValue parses a Consul value, attempting JSON first, then treating as string.
-func (cs *ConsulSource) parseValue(data []byte) (any, error) {
+func (cs *ConsulSource) parseValue(data []byte) any {
 	if len(data) == 0 {
-		return "", nil
+		return ""
 	}
 
 	// Try to parse as JSON first
 	var jsonValue any
 	if err := json.Unmarshal(data, &jsonValue); err == nil {
-		return jsonValue, nil
+		return jsonValue
 	}
 
 	// If JSON parsing fails, treat as string
-	return string(data), nil
+	return string(data)
 }
 
 // setNestedValue sets a nested configuration value using slash notation.
@@ -499,20 +984,17 @@ func (cs *ConsulSource) setNestedValue(config map[string]any, key string, value
 }
 
 // handleWatchError handles errors during watching.
-func (cs *ConsulSource) handleWatchError(err error) {
+func (cs *ConsulSource) handleWatchError(prefix string, err error) {
 	if cs.logger != nil {
-		cs.logger.Error("Consul watch error",
-			logger.String("prefix", cs.prefix),
+		cs.logger.Warn("Consul watch error, retrying",
+			logger.String("prefix", prefix),
 			logger.Error(err),
 		)
 	}
 
 	if cs.errorHandler != nil {
-		_ = cs.errorHandler.HandleError(context.Background(), configcore.ErrConfigError("Consul watch error for prefix "+cs.prefix, err))
+		_ = cs.errorHandler.HandleError(context.Background(), configcore.ErrConfigError("Consul watch error for prefix "+prefix, err))
 	}
-
-	// Stop watching on persistent errors
-	_ = cs.StopWatch()
 }
 
 // ConsulSourceFactory creates Consul configuration sources.
@@ -532,19 +1014,26 @@ func NewConsulSourceFactory(logger logger.Logger, errorHandler errors.ErrorHandl
 // CreateFromConfig creates a Consul source from configuration.
 func (factory *ConsulSourceFactory) CreateFromConfig(config ConsulSourceConfig) (configcore.ConfigSource, error) {
 	options := ConsulSourceOptions{
-		Name:         "consul:" + config.Prefix,
-		Address:      config.Address,
-		Token:        config.Token,
-		Datacenter:   config.Datacenter,
-		Prefix:       config.Prefix,
-		Priority:     config.Priority,
-		WatchEnabled: config.WatchEnabled,
-		Timeout:      config.Timeout,
-		RetryCount:   config.RetryCount,
-		RetryDelay:   config.RetryDelay,
-		TLS:          config.TLS,
-		Logger:       factory.logger,
-		ErrorHandler: factory.errorHandler,
+		Address:       config.Address,
+		Token:         config.Token,
+		TokenFile:     config.TokenFile,
+		Datacenter:    config.Datacenter,
+		Namespace:     config.Namespace,
+		Partition:     config.Partition,
+		Prefix:        config.Prefix,
+		Prefixes:      config.Prefixes,
+		Priority:      config.Priority,
+		WatchEnabled:  config.WatchEnabled,
+		Timeout:       config.Timeout,
+		RetryCount:    config.RetryCount,
+		RetryDelay:    config.RetryDelay,
+		MaxRetryDelay: config.MaxRetryDelay,
+		TLS:           config.TLS,
+		SingleKey:     config.SingleKey,
+		Format:        config.Format,
+		WriteEnabled:  config.WriteEnabled,
+		Logger:        factory.logger,
+		ErrorHandler:  factory.errorHandler,
 	}
 
 	return NewConsulSource(config.Prefix, options)
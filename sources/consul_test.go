@@ -0,0 +1,708 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	configcore "github.com/xraph/confy/internal"
+)
+
+// fakeConsulPair is a KV entry as returned by the Consul HTTP API.
+type fakeConsulPair struct {
+	Key         string
+	Value       []byte
+	ModifyIndex uint64
+}
+
+// fakeConsul emulates the parts of the Consul KV HTTP API used by
+// ConsulSource, including blocking queries.
+type fakeConsul struct {
+	mu      sync.Mutex
+	index   uint64
+	pairs   map[string]*fakeConsulPair
+	changed chan struct{}
+	server  *httptest.Server
+	// token, when set, is the only ACL token accepted
+	token string
+	// queries records the query string of each KV and txn request
+	queries []string
+	// tombstones holds the index at which each deleted key was removed
+	tombstones map[string]uint64
+	// failures is the number of KV requests still to answer with an error
+	failures int
+}
+
+func newFakeConsul(t *testing.T, pairs map[string]string) *fakeConsul {
+	t.Helper()
+
+	fc := &fakeConsul{
+		pairs:      make(map[string]*fakeConsulPair),
+		tombstones: make(map[string]uint64),
+		changed:    make(chan struct{}),
+	}
+	for key, value := range pairs {
+		fc.put(key, value)
+	}
+
+	fc.server = httptest.NewServer(http.HandlerFunc(fc.serveHTTP))
+	t.Cleanup(fc.server.Close)
+
+	return fc
+}
+
+// put stores a key and wakes blocking queries.
+func (fc *fakeConsul) put(key, value string) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	fc.index++
+	fc.pairs[key] = &fakeConsulPair{Key: key, Value: []byte(value), ModifyIndex: fc.index}
+	delete(fc.tombstones, key)
+
+	fc.notify()
+}
+
+// remove deletes a key, leaving a tombstone so that the prefix index moves
+// forward as it does in Consul.
+func (fc *fakeConsul) remove(key string) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	fc.index++
+	delete(fc.pairs, key)
+	fc.tombstones[key] = fc.index
+
+	fc.notify()
+}
+
+// restore replaces all data with a snapshot taken at a lower index.
+func (fc *fakeConsul) restore(pairs map[string]string) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	fc.index = 1
+	fc.pairs = make(map[string]*fakeConsulPair)
+	fc.tombstones = make(map[string]uint64)
+
+	for key, value := range pairs {
+		fc.index++
+		fc.pairs[key] = &fakeConsulPair{Key: key, Value: []byte(value), ModifyIndex: fc.index}
+	}
+
+	fc.notify()
+}
+
+// fail makes the next n KV requests fail.
+func (fc *fakeConsul) fail(n int) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	fc.failures = n
+}
+
+// notify wakes blocking queries; fc.mu must be held.
+func (fc *fakeConsul) notify() {
+	close(fc.changed)
+	fc.changed = make(chan struct{})
+}
+
+func (fc *fakeConsul) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/v1/agent/self" {
+		_, _ = w.Write([]byte("{}"))
+
+		return
+	}
+
+	fc.mu.Lock()
+	fc.queries = append(fc.queries, r.URL.RawQuery)
+	token := fc.token
+	fc.mu.Unlock()
+
+	if token != "" && r.Header.Get("X-Consul-Token") != token {
+		http.Error(w, "ACL not found", http.StatusForbidden)
+
+		return
+	}
+
+	if r.URL.Path == "/v1/txn" {
+		fc.serveTxn(w, r)
+
+		return
+	}
+
+	key, ok := strings.CutPrefix(r.URL.Path, "/v1/kv/")
+	if !ok {
+		http.NotFound(w, r)
+
+		return
+	}
+
+	query := r.URL.Query()
+	recurse := query.Has("recurse")
+
+	wait, err := time.ParseDuration(query.Get("wait"))
+	if err != nil || wait <= 0 {
+		wait = time.Second
+	}
+
+	timeout := time.After(wait)
+	waitIndex, _ := strconv.ParseUint(query.Get("index"), 10, 64)
+
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	// Blocking query: wait until a matching key changes past the client's
+	// index
+	for {
+		result, index := fc.match(key, recurse)
+
+		if fc.failures > 0 && (waitIndex == 0 || index > waitIndex) {
+			fc.failures--
+
+			http.Error(w, "rpc error: no cluster leader", http.StatusInternalServerError)
+
+			return
+		}
+
+		if waitIndex == 0 || index > waitIndex {
+			w.Header().Set("X-Consul-Index", strconv.FormatUint(index, 10))
+
+			if len(result) == 0 {
+				w.WriteHeader(http.StatusNotFound)
+
+				return
+			}
+
+			_ = json.NewEncoder(w).Encode(result)
+
+			return
+		}
+
+		changed := fc.changed
+		fc.mu.Unlock()
+
+		select {
+		case <-changed:
+			fc.mu.Lock()
+		case <-timeout:
+			fc.mu.Lock()
+
+			waitIndex = 0
+		case <-r.Context().Done():
+			fc.mu.Lock()
+
+			return
+		}
+	}
+}
+
+// match returns the pairs for a key or prefix, sorted by key, and their
+// index: the highest ModifyIndex, as Consul reports for KV queries.
+func (fc *fakeConsul) match(key string, recurse bool) ([]*fakeConsulPair, uint64) {
+	var (
+		result []*fakeConsulPair
+		index  uint64 = 1
+	)
+
+	for name, pair := range fc.pairs {
+		if name == key || (recurse && strings.HasPrefix(name, key)) {
+			result = append(result, pair)
+			index = max(index, pair.ModifyIndex)
+		}
+	}
+
+	for name, deleted := range fc.tombstones {
+		if name == key || (recurse && strings.HasPrefix(name, key)) {
+			index = max(index, deleted)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+
+	return result, index
+}
+
+// serveTxn applies check-and-set operations atomically.
+func (fc *fakeConsul) serveTxn(w http.ResponseWriter, r *http.Request) {
+	var ops []struct {
+		KV struct {
+			Verb  string
+			Key   string
+			Value []byte
+			Index uint64
+		}
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&ops); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	fc.mu.Lock()
+
+	for i, op := range ops {
+		var current uint64
+		if pair, ok := fc.pairs[op.KV.Key]; ok {
+			current = pair.ModifyIndex
+		}
+
+		if op.KV.Verb != "cas" || current != op.KV.Index {
+			fc.mu.Unlock()
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"Errors": []map[string]any{{"OpIndex": i, "What": "failed to set key: index is stale"}},
+			})
+
+			return
+		}
+	}
+
+	fc.index++
+
+	results := make([]map[string]any, 0, len(ops))
+
+	for _, op := range ops {
+		fc.pairs[op.KV.Key] = &fakeConsulPair{Key: op.KV.Key, Value: op.KV.Value, ModifyIndex: fc.index}
+		results = append(results, map[string]any{"KV": map[string]any{"Key": op.KV.Key, "ModifyIndex": fc.index}})
+	}
+
+	fc.notify()
+	fc.mu.Unlock()
+
+	_ = json.NewEncoder(w).Encode(map[string]any{"Results": results})
+}
+
+// value returns the stored value of key.
+func (fc *fakeConsul) value(key string) string {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	if pair, ok := fc.pairs[key]; ok {
+		return string(pair.Value)
+	}
+
+	return ""
+}
+
+func (fc *fakeConsul) options() ConsulSourceOptions {
+	return ConsulSourceOptions{Address: strings.TrimPrefix(fc.server.URL, "http://"), Timeout: time.Second}
+}
+
+func TestConsulSource_DocumentKeys(t *testing.T) {
+	fc := newFakeConsul(t, map[string]string{
+		"app/db.yaml":          "host: localhost\nport: 5432\n",
+		"app/db/port":          "6432",
+		"app/cache/redis.json": `{"ttl": 60}`,
+		"app/features.toml":    "beta = true\n",
+		"app/broken.yaml":      "key: [unclosed",
+		"app/name":             "checkout",
+	})
+
+	source, err := NewConsulSource("app", fc.options())
+	if err != nil {
+		t.Fatalf("NewConsulSource() error = %v", err)
+	}
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	db, _ := data["db"].(map[string]any)
+	if db["host"] != "localhost" || fmt.Sprint(db["port"]) != "6432" {
+		t.Errorf("db = %v, want document merged with plain key on top", db)
+	}
+
+	redis, _ := data["cache"].(map[string]any)["redis"].(map[string]any)
+	if fmt.Sprint(redis["ttl"]) != "60" {
+		t.Errorf("cache = %v, want nested JSON document", data["cache"])
+	}
+
+	if data["features"].(map[string]any)["beta"] != true || data["name"] != "checkout" {
+		t.Errorf("Load() = %v", data)
+	}
+
+	if _, ok := data["broken"]; ok {
+		t.Error("unparseable documents should be skipped")
+	}
+}
+
+func TestConsulSource_SingleKey(t *testing.T) {
+	fc := newFakeConsul(t, map[string]string{
+		"app/config.yaml": "server:\n  port: 8080\n",
+		"app/other":       "ignored",
+	})
+
+	options := fc.options()
+	options.SingleKey = true
+	options.WatchEnabled = true
+
+	source, err := NewConsulSource("app/config.yaml", options)
+	if err != nil {
+		t.Fatalf("NewConsulSource() error = %v", err)
+	}
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if fmt.Sprint(data["server"].(map[string]any)["port"]) != "8080" || data["other"] != nil {
+		t.Errorf("Load() = %v, want the document at the root", data)
+	}
+
+	updates := make(chan map[string]any, 4)
+	if err := source.Watch(context.Background(), func(data map[string]any) { updates <- data }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer func() { _ = source.StopWatch() }()
+
+	fc.put("app/config.yaml", "server:\n  port: 9090\n")
+
+	select {
+	case data := <-updates:
+		if fmt.Sprint(data["server"].(map[string]any)["port"]) != "9090" {
+			t.Errorf("watched data = %v, want port 9090", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for key update")
+	}
+
+	fc.remove("app/config.yaml")
+
+	select {
+	case data := <-updates:
+		if len(data) != 0 {
+			t.Errorf("watched data = %v, want empty configuration after deletion", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for key deletion")
+	}
+
+	options.Format = "ini"
+	if _, err := NewConsulSource("app/config", options); err == nil {
+		t.Error("NewConsulSource() should reject an unknown document format")
+	}
+
+	options.Format = ""
+	if _, err := NewConsulSource("app/", options); err == nil {
+		t.Error("NewConsulSource() should reject a prefix in single-key mode")
+	}
+}
+
+func TestConsulSource_Persist(t *testing.T) {
+	fc := newFakeConsul(t, map[string]string{"app/limits/rps": "100"})
+
+	options := fc.options()
+
+	readOnly, _ := NewConsulSource("app", options)
+	if err := readOnly.(configcore.WritableSource).Persist(context.Background(), "limits.rps", 10); err == nil {
+		t.Error("Persist() should fail without WriteEnabled")
+	}
+
+	options.WriteEnabled = true
+
+	source, err := NewConsulSource("app/", options)
+	if err != nil {
+		t.Fatalf("NewConsulSource() error = %v", err)
+	}
+
+	if _, err := source.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	writable := source.(configcore.WritableSource)
+
+	if err := writable.Persist(context.Background(), "limits", map[string]any{
+		"rps":   200,
+		"burst": map[string]any{"size": 50},
+		"mode":  "strict",
+	}); err != nil {
+		t.Fatalf("Persist() error = %v", err)
+	}
+
+	if fc.value("app/limits/rps") != "200" || fc.value("app/limits/burst/size") != "50" || fc.value("app/limits/mode") != "strict" {
+		t.Errorf("stored values = %q, %q, %q", fc.value("app/limits/rps"), fc.value("app/limits/burst/size"), fc.value("app/limits/mode"))
+	}
+
+	// Our own write must not conflict with the next one
+	if err := writable.Persist(context.Background(), "limits.rps", 300); err != nil {
+		t.Fatalf("second Persist() error = %v", err)
+	}
+
+	// Another replica changes the key before we observe it
+	fc.put("app/limits/rps", "400")
+
+	err = writable.Persist(context.Background(), "limits.rps", 500)
+	if !configcore.IsWriteConflict(err) {
+		t.Fatalf("Persist() error = %v, want a write conflict", err)
+	}
+
+	if !configcore.IsWriteConflict(configcore.ErrConfigError("failed to persist", err)) {
+		t.Error("IsWriteConflict() should see through wrapping errors")
+	}
+
+	if fc.value("app/limits/rps") != "400" {
+		t.Errorf("conflicting write was applied: %q", fc.value("app/limits/rps"))
+	}
+
+	// After reloading, the write succeeds
+	if _, err := source.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if err := writable.Persist(context.Background(), "limits.rps", 500); err != nil {
+		t.Errorf("Persist() after reload error = %v", err)
+	}
+}
+
+func TestConsulSource_LayeredPrefixes(t *testing.T) {
+	fc := newFakeConsul(t, map[string]string{
+		"global/limits/rps":    "100",
+		"global/limits/burst":  "20",
+		"global/log/level":     "info",
+		"region/eu/limits/rps": "50",
+		"app/foo/log/level":    "debug",
+	})
+
+	options := fc.options()
+	options.Prefixes = []string{"global/", "region/eu/", "app/foo/"}
+	options.WatchEnabled = true
+	options.WriteEnabled = true
+
+	source, err := NewConsulSource("", options)
+	if err != nil {
+		t.Fatalf("NewConsulSource() error = %v", err)
+	}
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	limits, _ := data["limits"].(map[string]any)
+	logConfig, _ := data["log"].(map[string]any)
+
+	if fmt.Sprint(limits["rps"]) != "50" || fmt.Sprint(limits["burst"]) != "20" || logConfig["level"] != "debug" {
+		t.Errorf("Load() = %v, want later prefixes layered over earlier ones", data)
+	}
+
+	updates := make(chan map[string]any, 4)
+	if err := source.Watch(context.Background(), func(data map[string]any) { updates <- data }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer func() { _ = source.StopWatch() }()
+
+	// A change in a lower layer is reported merged with the others
+	fc.put("global/limits/burst", "40")
+
+	select {
+	case data := <-updates:
+		limits, _ := data["limits"].(map[string]any)
+		if fmt.Sprint(limits["burst"]) != "40" || fmt.Sprint(limits["rps"]) != "50" {
+			t.Errorf("watched data = %v, want burst=40 with rps from region", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for layered update")
+	}
+
+	select {
+	case data := <-updates:
+		t.Errorf("unchanged prefixes notified %v", data)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := source.(configcore.WritableSource).Persist(context.Background(), "limits.rps", 75); err != nil {
+		t.Fatalf("Persist() error = %v", err)
+	}
+
+	if fc.value("app/foo/limits/rps") != "75" || fc.value("region/eu/limits/rps") != "50" {
+		t.Error("Persist() should write to the last prefix")
+	}
+}
+
+func TestConsulSource_TokenFileAndNamespace(t *testing.T) {
+	fc := newFakeConsul(t, map[string]string{"app/level": "info"})
+	fc.token = "first"
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("first\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	options := fc.options()
+	options.TokenFile = tokenFile
+	options.Namespace = "team-a"
+	options.Partition = "eu"
+
+	source, err := NewConsulSource("app", options)
+	if err != nil {
+		t.Fatalf("NewConsulSource() error = %v", err)
+	}
+
+	if _, err := source.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// Rotate the token
+	fc.mu.Lock()
+	fc.token = "second"
+	fc.mu.Unlock()
+
+	if err := os.WriteFile(tokenFile, []byte("second\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := source.Load(context.Background()); err != nil {
+		t.Fatalf("Load() after rotation error = %v", err)
+	}
+
+	// A missing file keeps the last token
+	_ = os.Remove(tokenFile)
+
+	if _, err := source.Load(context.Background()); err != nil {
+		t.Errorf("Load() with a missing token file error = %v", err)
+	}
+
+	fc.mu.Lock()
+	query := fc.queries[len(fc.queries)-1]
+	fc.mu.Unlock()
+
+	if !strings.Contains(query, "ns=team-a") || !strings.Contains(query, "partition=eu") {
+		t.Errorf("query = %q, want namespace and partition", query)
+	}
+}
+
+// consulErrorRecorder records errors passed to the error handler.
+type consulErrorRecorder struct {
+	mu     sync.Mutex
+	errors []error
+}
+
+func (r *consulErrorRecorder) HandleError(ctx context.Context, err error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.errors = append(r.errors, err)
+
+	return err
+}
+
+// count returns the number of recorded errors matching match.
+func (r *consulErrorRecorder) count(match func(error) bool) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+
+	for _, err := range r.errors {
+		if match(err) {
+			n++
+		}
+	}
+
+	return n
+}
+
+func TestConsulSource_WatchRecovery(t *testing.T) {
+	fc := newFakeConsul(t, map[string]string{
+		"app/limits/rps":   "100",
+		"app/limits/burst": "20",
+		"app/db.yaml":      "host: localhost\n",
+	})
+
+	recorder := &consulErrorRecorder{}
+
+	options := fc.options()
+	options.WatchEnabled = true
+	options.Timeout = 200 * time.Millisecond
+	options.RetryCount = 1
+	options.RetryDelay = 5 * time.Millisecond
+	options.MaxRetryDelay = 20 * time.Millisecond
+	options.ErrorHandler = recorder
+
+	source, err := NewConsulSource("app", options)
+	if err != nil {
+		t.Fatalf("NewConsulSource() error = %v", err)
+	}
+
+	if _, err := source.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	updates := make(chan map[string]any, 8)
+	if err := source.Watch(context.Background(), func(data map[string]any) { updates <- data }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer func() { _ = source.StopWatch() }()
+
+	next := func(what string) map[string]any {
+		t.Helper()
+
+		select {
+		case data := <-updates:
+			return data
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %s", what)
+
+			return nil
+		}
+	}
+
+	// More consecutive failures than RetryCount must not stop the watch
+	fc.fail(4)
+	fc.remove("app/limits/burst")
+
+	data := next("deletion")
+	if _, ok := data["limits"].(map[string]any)["burst"]; ok {
+		t.Errorf("watched data = %v, want limits.burst deleted", data)
+	}
+
+	if failures := recorder.count(func(err error) bool { return strings.Contains(err.Error(), "watch error") }); failures < 4 {
+		t.Errorf("reported %d watch errors, want every failure reported", failures)
+	}
+
+	// A document that fails to parse is reported once and keeps its last
+	// good value
+	fc.put("app/db.yaml", "host: [unclosed")
+
+	isParseError := func(err error) bool { return strings.Contains(err.Error(), "parse app/db.yaml") }
+
+	deadline := time.Now().Add(5 * time.Second)
+	for recorder.count(isParseError) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	loaded, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if loaded["db"].(map[string]any)["host"] != "localhost" {
+		t.Errorf("db = %v, want the last good document", loaded["db"])
+	}
+
+	if reported := recorder.count(isParseError); reported != 1 {
+		t.Errorf("parse error reported %d times, want once", reported)
+	}
+
+	// A snapshot restore moves the index backwards
+	fc.restore(map[string]string{"app/limits/rps": "7"})
+
+	data = next("snapshot restore")
+	if fmt.Sprint(data["limits"].(map[string]any)["rps"]) != "7" || data["db"] != nil {
+		t.Errorf("watched data = %v, want the restored snapshot", data)
+	}
+}
@@ -0,0 +1,370 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	configcore "github.com/xraph/confy/internal"
+	errors "github.com/xraph/go-utils/errs"
+	logger "github.com/xraph/go-utils/log"
+)
+
+const (
+	// SystemdCredentialsEnv names the environment variable pointing at the
+	// directory systemd populates from LoadCredential= and SetCredential=.
+	SystemdCredentialsEnv = "CREDENTIALS_DIRECTORY"
+
+	// DockerSecretsDir is the directory Docker and Swarm mount secrets into.
+	DockerSecretsDir = "/run/secrets"
+)
+
+// CredentialsSource exposes one-file-per-secret directories, such as systemd
+// credentials and Docker secrets, as configuration and secrets. File names
+// map to keys.
+type CredentialsSource struct {
+	name         string
+	directories  []string
+	priority     int
+	keys         []string
+	logger       logger.Logger
+	errorHandler errors.ErrorHandler
+	options      CredentialsSourceOptions
+	mu           sync.RWMutex
+}
+
+// CredentialsSourceOptions contains options for credentials sources.
+type CredentialsSourceOptions struct {
+	Name string
+	// Directories are searched in order; a file in an earlier directory wins.
+	// Defaults to DefaultCredentialsDirectories.
+	Directories []string
+	Priority    int
+	// Separator splits file names into nested keys. Defaults to ".".
+	Separator string
+	// AllowWorldReadable accepts files readable by any user. Docker mounts
+	// secrets with mode 0444 unless the service sets a stricter mode.
+	AllowWorldReadable bool
+	// Decryptor, when set, decrypts each file's contents.
+	Decryptor    Encryptor
+	Logger       logger.Logger
+	ErrorHandler errors.ErrorHandler
+}
+
+// CredentialsSourceConfig contains configuration for creating credentials
+// sources.
+type CredentialsSourceConfig struct {
+	Directories        []string `json:"directories"          yaml:"directories"`
+	Priority           int      `json:"priority"             yaml:"priority"`
+	Separator          string   `json:"separator"            yaml:"separator"`
+	AllowWorldReadable bool     `json:"allow_world_readable" yaml:"allow_world_readable"`
+}
+
+// DefaultCredentialsDirectories returns $CREDENTIALS_DIRECTORY, when set,
+// followed by /run/secrets.
+func DefaultCredentialsDirectories() []string {
+	var directories []string
+
+	if dir := os.Getenv(SystemdCredentialsEnv); dir != "" {
+		directories = append(directories, dir)
+	}
+
+	return append(directories, DockerSecretsDir)
+}
+
+// NewCredentialsSource creates a new credentials source. Missing directories
+// are skipped.
+func NewCredentialsSource(options CredentialsSourceOptions) (configcore.ConfigSource, error) {
+	if len(options.Directories) == 0 {
+		options.Directories = DefaultCredentialsDirectories()
+	}
+
+	if options.Separator == "" {
+		options.Separator = "."
+	}
+
+	for _, dir := range options.Directories {
+		if dir == "" {
+			return nil, configcore.ErrConfigError("credentials directory must not be empty", nil)
+		}
+	}
+
+	name := options.Name
+	if name == "" {
+		name = "credentials"
+	}
+
+	return &CredentialsSource{
+		name:         name,
+		directories:  append([]string(nil), options.Directories...),
+		priority:     options.Priority,
+		logger:       options.Logger,
+		errorHandler: options.ErrorHandler,
+		options:      options,
+	}, nil
+}
+
+// Name returns the source name.
+func (cs *CredentialsSource) Name() string {
+	return cs.name
+}
+
+// GetName returns the source name (alias for Name).
+func (cs *CredentialsSource) GetName() string {
+	return cs.name
+}
+
+// GetType returns the source type.
+func (cs *CredentialsSource) GetType() string {
+	return "credentials"
+}
+
+// IsAvailable returns true if any of the directories exists.
+func (cs *CredentialsSource) IsAvailable(ctx context.Context) bool {
+	for _, dir := range cs.directories {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Priority returns the source priority.
+func (cs *CredentialsSource) Priority() int {
+	return cs.priority
+}
+
+// Load reads every credential file and nests them by file name.
+func (cs *CredentialsSource) Load(ctx context.Context) (map[string]any, error) {
+	keys, err := cs.ListSecrets()
+	if err != nil {
+		return nil, err
+	}
+
+	config := make(map[string]any)
+
+	for _, key := range keys {
+		value, err := cs.read(key)
+		if err != nil {
+			return nil, err
+		}
+
+		setNestedPath(config, strings.Split(key, cs.options.Separator), value)
+	}
+
+	cs.mu.Lock()
+	cs.keys = keys
+	cs.mu.Unlock()
+
+	if cs.logger != nil {
+		cs.logger.Debug("credentials loaded",
+			logger.String("directories", strings.Join(cs.directories, ",")),
+			logger.Int("keys", len(keys)),
+		)
+	}
+
+	return config, nil
+}
+
+// Watch is not supported; credentials are fixed for the process lifetime.
+func (cs *CredentialsSource) Watch(ctx context.Context, callback func(map[string]any)) error {
+	return configcore.ErrConfigError("credentials source does not support watching", nil)
+}
+
+// StopWatch is a no-op for credentials sources.
+func (cs *CredentialsSource) StopWatch() error {
+	return nil
+}
+
+// Reload reloads the credentials.
+func (cs *CredentialsSource) Reload(ctx context.Context) error {
+	_, err := cs.Load(ctx)
+
+	return err
+}
+
+// IsWatchable returns false.
+func (cs *CredentialsSource) IsWatchable() bool {
+	return false
+}
+
+// SupportsSecrets returns true.
+func (cs *CredentialsSource) SupportsSecrets() bool {
+	return true
+}
+
+// GetSecret reads a credential by file name or by its dotted configuration
+// key.
+func (cs *CredentialsSource) GetSecret(ctx context.Context, key string) (string, error) {
+	candidates := []string{key}
+	if cs.options.Separator != "." {
+		candidates = append(candidates, strings.ReplaceAll(key, ".", cs.options.Separator))
+	}
+
+	for _, candidate := range candidates {
+		if !validCredentialName(candidate) {
+			continue
+		}
+
+		if _, ok := cs.locate(candidate); ok {
+			return cs.read(candidate)
+		}
+	}
+
+	return "", configcore.ErrConfigError("credential not found: "+key, nil)
+}
+
+// ListSecrets returns the credential file names across all directories.
+func (cs *CredentialsSource) ListSecrets() ([]string, error) {
+	seen := make(map[string]bool)
+
+	var keys []string
+
+	for _, dir := range cs.directories {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+
+			return nil, configcore.ErrConfigError("failed to read credentials directory "+dir, err)
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+
+			// Hidden entries include Kubernetes' ..data bookkeeping links
+			if strings.HasPrefix(name, ".") || seen[name] {
+				continue
+			}
+
+			info, err := os.Stat(filepath.Join(dir, name))
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+
+			seen[name] = true
+			keys = append(keys, name)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+// ReportMetadata reports the directories and loaded keys. Values are never
+// reported.
+func (cs *CredentialsSource) ReportMetadata(metadata *configcore.SourceMetadata) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	if metadata.Properties == nil {
+		metadata.Properties = make(map[string]any)
+	}
+
+	metadata.Properties["directories"] = append([]string(nil), cs.directories...)
+	metadata.Properties["keys"] = append([]string(nil), cs.keys...)
+}
+
+// locate returns the first directory containing the named credential.
+func (cs *CredentialsSource) locate(name string) (string, bool) {
+	for _, dir := range cs.directories {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return dir, true
+		}
+	}
+
+	return "", false
+}
+
+// read reads, checks and optionally decrypts a credential file.
+func (cs *CredentialsSource) read(name string) (string, error) {
+	if !validCredentialName(name) {
+		return "", configcore.ErrConfigError("invalid credential name: "+name, nil)
+	}
+
+	dir, ok := cs.locate(name)
+	if !ok {
+		return "", configcore.ErrConfigError("credential not found: "+name, nil)
+	}
+
+	// Scoped access keeps links from escaping the directory
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return "", configcore.ErrConfigError("failed to open credentials directory "+dir, err)
+	}
+	defer func() { _ = root.Close() }()
+
+	file, err := root.Open(name)
+	if err != nil {
+		return "", configcore.ErrConfigError("failed to open credential "+name, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", configcore.ErrConfigError("failed to stat credential "+name, err)
+	}
+
+	if !info.Mode().IsRegular() {
+		return "", configcore.ErrConfigError("credential is not a regular file: "+name, nil)
+	}
+
+	if info.Mode().Perm()&0o004 != 0 && !cs.options.AllowWorldReadable {
+		return "", configcore.ErrConfigError(fmt.Sprintf("credential %s is world-readable (mode %04o)", filepath.Join(dir, name), info.Mode().Perm()), nil)
+	}
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return "", configcore.ErrConfigError("failed to read credential "+name, err)
+	}
+
+	value := strings.TrimSpace(string(content))
+
+	if cs.options.Decryptor != nil {
+		value, err = cs.options.Decryptor.Decrypt(value)
+		if err != nil {
+			return "", configcore.ErrConfigError("failed to decrypt credential "+name, err)
+		}
+	}
+
+	return value, nil
+}
+
+// validCredentialName reports whether name is a plain file name.
+func validCredentialName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// CredentialsSourceFactory creates credentials sources.
+type CredentialsSourceFactory struct {
+	logger       logger.Logger
+	errorHandler errors.ErrorHandler
+}
+
+// NewCredentialsSourceFactory creates a new credentials source factory.
+func NewCredentialsSourceFactory(logger logger.Logger, errorHandler errors.ErrorHandler) *CredentialsSourceFactory {
+	return &CredentialsSourceFactory{
+		logger:       logger,
+		errorHandler: errorHandler,
+	}
+}
+
+// CreateFromConfig creates a credentials source from configuration.
+func (factory *CredentialsSourceFactory) CreateFromConfig(config CredentialsSourceConfig) (configcore.ConfigSource, error) {
+	return NewCredentialsSource(CredentialsSourceOptions{
+		Directories:        append([]string(nil), config.Directories...),
+		Priority:           config.Priority,
+		Separator:          config.Separator,
+		AllowWorldReadable: config.AllowWorldReadable,
+		Logger:             factory.logger,
+		ErrorHandler:       factory.errorHandler,
+	})
+}
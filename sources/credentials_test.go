@@ -0,0 +1,116 @@
+package sources
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeCredential writes a credential file with the given mode.
+func writeCredential(t *testing.T, dir, name, value string, mode os.FileMode) {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(value), mode); err != nil {
+		t.Fatal(err)
+	}
+
+	// WriteFile is subject to the umask
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCredentialsSource_Load(t *testing.T) {
+	systemd := t.TempDir()
+	docker := t.TempDir()
+
+	writeCredential(t, systemd, "database.password", "from-systemd\n", 0400)
+	writeCredential(t, docker, "database.password", "from-docker", 0400)
+	writeCredential(t, docker, "api_token", "tok", 0440)
+	writeCredential(t, docker, ".hidden", "x", 0400)
+
+	t.Setenv(SystemdCredentialsEnv, systemd)
+
+	if dirs := DefaultCredentialsDirectories(); !reflect.DeepEqual(dirs, []string{systemd, DockerSecretsDir}) {
+		t.Errorf("DefaultCredentialsDirectories() = %v", dirs)
+	}
+
+	source, err := NewCredentialsSource(CredentialsSourceOptions{Directories: []string{systemd, docker}})
+	if err != nil {
+		t.Fatalf("NewCredentialsSource() error = %v", err)
+	}
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := map[string]any{
+		"database":  map[string]any{"password": "from-systemd"},
+		"api_token": "tok",
+	}
+
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("Load() = %#v, want %#v", data, want)
+	}
+
+	secret, err := source.GetSecret(context.Background(), "database.password")
+	if err != nil || secret != "from-systemd" {
+		t.Errorf("GetSecret() = %q, %v, want earlier directory to win", secret, err)
+	}
+
+	for _, key := range []string{"missing", "../etc/passwd"} {
+		if _, err := source.GetSecret(context.Background(), key); err == nil {
+			t.Errorf("GetSecret(%q) should fail", key)
+		}
+	}
+}
+
+func TestCredentialsSource_WorldReadable(t *testing.T) {
+	dir := t.TempDir()
+	writeCredential(t, dir, "token", "tok", 0444)
+
+	source, _ := NewCredentialsSource(CredentialsSourceOptions{Directories: []string{dir}})
+
+	if _, err := source.Load(context.Background()); err == nil || !strings.Contains(err.Error(), "world-readable") {
+		t.Errorf("Load() error = %v, want world-readable rejection", err)
+	}
+
+	source, _ = NewCredentialsSource(CredentialsSourceOptions{Directories: []string{dir}, AllowWorldReadable: true})
+
+	if secret, err := source.GetSecret(context.Background(), "token"); err != nil || secret != "tok" {
+		t.Errorf("GetSecret() = %q, %v with AllowWorldReadable", secret, err)
+	}
+}
+
+func TestCredentialsSource_SeparatorAndDecryptor(t *testing.T) {
+	dir := t.TempDir()
+
+	encrypted, _ := reverseEncryptor{}.Encrypt("hunter2")
+	writeCredential(t, dir, "db_password", encrypted, 0400)
+
+	source, _ := NewCredentialsSource(CredentialsSourceOptions{
+		Directories: []string{dir, filepath.Join(dir, "missing")},
+		Separator:   "_",
+		Decryptor:   reverseEncryptor{},
+	})
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	db, _ := data["db"].(map[string]any)
+	if db["password"] != "hunter2" {
+		t.Errorf("Load() = %v, want decrypted db.password", data)
+	}
+
+	secret, err := source.GetSecret(context.Background(), "db.password")
+	if err != nil || secret != "hunter2" {
+		t.Errorf("GetSecret() = %q, %v, want dotted key lookup", secret, err)
+	}
+}
@@ -0,0 +1,626 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	configcore "github.com/xraph/confy/internal"
+	errors "github.com/xraph/go-utils/errs"
+	logger "github.com/xraph/go-utils/log"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// EtcdSource represents an etcd v3 configuration source.
+type EtcdSource struct {
+	name         string
+	client       *clientv3.Client
+	ownsClient   bool
+	kv           clientv3.KV
+	watcher      clientv3.Watcher
+	prefix       string
+	priority     int
+	revision     int64
+	values       map[string][]byte
+	watching     bool
+	watchStop    chan struct{}
+	logger       logger.Logger
+	errorHandler errors.ErrorHandler
+	options      EtcdSourceOptions
+	mu           sync.RWMutex
+}
+
+// EtcdSourceOptions contains options for etcd configuration sources.
+type EtcdSourceOptions struct {
+	Name          string
+	Endpoints     []string
+	Username      string
+	Password      string
+	Priority      int
+	WatchEnabled  bool
+	DialTimeout   time.Duration
+	Timeout       time.Duration
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+	TLS           *EtcdTLSConfig
+	// Client reuses an existing client instead of dialing Endpoints. The
+	// caller keeps ownership of it.
+	Client       *clientv3.Client
+	Logger       logger.Logger
+	ErrorHandler errors.ErrorHandler
+}
+
+// EtcdSourceConfig contains configuration for creating etcd sources.
+type EtcdSourceConfig struct {
+	Endpoints     []string       `json:"endpoints"       yaml:"endpoints"`
+	Username      string         `json:"username"        yaml:"username"`
+	Password      string         `json:"password"        yaml:"password"`
+	Prefix        string         `json:"prefix"          yaml:"prefix"`
+	Priority      int            `json:"priority"        yaml:"priority"`
+	WatchEnabled  bool           `json:"watch_enabled"   yaml:"watch_enabled"`
+	DialTimeout   time.Duration  `json:"dial_timeout"    yaml:"dial_timeout"`
+	Timeout       time.Duration  `json:"timeout"         yaml:"timeout"`
+	RetryDelay    time.Duration  `json:"retry_delay"     yaml:"retry_delay"`
+	MaxRetryDelay time.Duration  `json:"max_retry_delay" yaml:"max_retry_delay"`
+	TLS           *EtcdTLSConfig `json:"tls"             yaml:"tls"`
+}
+
+// EtcdTLSConfig contains TLS configuration for etcd.
+type EtcdTLSConfig struct {
+	Enabled            bool   `json:"enabled"              yaml:"enabled"`
+	CertFile           string `json:"cert_file"            yaml:"cert_file"`
+	KeyFile            string `json:"key_file"             yaml:"key_file"`
+	CAFile             string `json:"ca_file"              yaml:"ca_file"`
+	ServerName         string `json:"server_name"          yaml:"server_name"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify" yaml:"insecure_skip_verify"`
+}
+
+// NewEtcdSource creates a new etcd configuration source for the keys under
+// prefix. Keys are split on "/" into nested sections.
+func NewEtcdSource(prefix string, options EtcdSourceOptions) (configcore.ConfigSource, error) {
+	if len(options.Endpoints) == 0 {
+		options.Endpoints = []string{"127.0.0.1:2379"}
+	}
+
+	if options.DialTimeout == 0 {
+		options.DialTimeout = 5 * time.Second
+	}
+
+	if options.Timeout == 0 {
+		options.Timeout = 10 * time.Second
+	}
+
+	if options.RetryDelay == 0 {
+		options.RetryDelay = time.Second
+	}
+
+	if options.MaxRetryDelay == 0 {
+		options.MaxRetryDelay = time.Minute
+	}
+
+	name := options.Name
+	if name == "" {
+		name = "etcd:" + prefix
+	}
+
+	client := options.Client
+	ownsClient := false
+
+	if client == nil {
+		etcdConfig := clientv3.Config{
+			Endpoints:   options.Endpoints,
+			DialTimeout: options.DialTimeout,
+			Username:    options.Username,
+			Password:    options.Password,
+		}
+
+		if options.TLS != nil && options.TLS.Enabled {
+			tlsConfig, err := buildHTTPTLSConfig(&HTTPTLSConfig{
+				CertFile:           options.TLS.CertFile,
+				KeyFile:            options.TLS.KeyFile,
+				CAFile:             options.TLS.CAFile,
+				ServerName:         options.TLS.ServerName,
+				InsecureSkipVerify: options.TLS.InsecureSkipVerify,
+			})
+			if err != nil {
+				return nil, configcore.ErrConfigError("failed to configure TLS for etcd", err)
+			}
+
+			etcdConfig.TLS = tlsConfig
+		}
+
+		var err error
+
+		client, err = clientv3.New(etcdConfig)
+		if err != nil {
+			return nil, configcore.ErrConfigError(fmt.Sprintf("failed to create etcd client: %v", err), err)
+		}
+
+		ownsClient = true
+	}
+
+	return &EtcdSource{
+		name:         name,
+		client:       client,
+		ownsClient:   ownsClient,
+		kv:           client.KV,
+		watcher:      client.Watcher,
+		prefix:       prefix,
+		priority:     options.Priority,
+		logger:       options.Logger,
+		errorHandler: options.ErrorHandler,
+		options:      options,
+	}, nil
+}
+
+// Name returns the source name.
+func (es *EtcdSource) Name() string {
+	return es.name
+}
+
+// GetName returns the source name (alias for Name).
+func (es *EtcdSource) GetName() string {
+	return es.name
+}
+
+// GetType returns the source type.
+func (es *EtcdSource) GetType() string {
+	return "etcd"
+}
+
+// IsAvailable checks if etcd answers requests for the prefix.
+func (es *EtcdSource) IsAvailable(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, es.options.Timeout)
+	defer cancel()
+
+	_, err := es.kv.Get(ctx, es.keyPrefix(), clientv3.WithPrefix(), clientv3.WithCountOnly())
+
+	return err == nil
+}
+
+// Priority returns the source priority.
+func (es *EtcdSource) Priority() int {
+	return es.priority
+}
+
+// Load loads configuration from etcd.
+func (es *EtcdSource) Load(ctx context.Context) (map[string]any, error) {
+	if es.logger != nil {
+		es.logger.Debug("loading configuration from etcd",
+			logger.String("prefix", es.prefix),
+			logger.String("endpoints", strings.Join(es.options.Endpoints, ",")),
+		)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, es.options.Timeout)
+	defer cancel()
+
+	resp, err := es.kv.Get(ctx, es.keyPrefix(), clientv3.WithPrefix())
+	if err != nil {
+		return nil, configcore.ErrConfigError(fmt.Sprintf("failed to query etcd: %v", err), err)
+	}
+
+	values := make(map[string][]byte, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		values[string(kv.Key)] = kv.Value
+	}
+
+	es.mu.Lock()
+	es.values = values
+	es.revision = resp.Header.Revision
+	config := es.buildConfig()
+	es.mu.Unlock()
+
+	if es.logger != nil {
+		es.logger.Info("configuration loaded from etcd",
+			logger.String("prefix", es.prefix),
+			logger.Int("keys", len(resp.Kvs)),
+			logger.Int64("revision", resp.Header.Revision),
+		)
+	}
+
+	return config, nil
+}
+
+// Watch starts watching etcd for changes.
+func (es *EtcdSource) Watch(ctx context.Context, callback func(map[string]any)) error {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	if es.watching {
+		return configcore.ErrConfigError("already watching etcd", nil)
+	}
+
+	if !es.IsWatchable() {
+		return configcore.ErrConfigError("etcd watching is not enabled", nil)
+	}
+
+	es.watchStop = make(chan struct{})
+	es.watching = true
+
+	go es.watchLoop(ctx, es.watchStop, callback)
+
+	if es.logger != nil {
+		es.logger.Info("started watching etcd",
+			logger.String("prefix", es.prefix),
+		)
+	}
+
+	return nil
+}
+
+// StopWatch stops watching etcd.
+func (es *EtcdSource) StopWatch() error {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	if !es.watching {
+		return nil
+	}
+
+	if es.watchStop != nil {
+		close(es.watchStop)
+		es.watchStop = nil
+	}
+
+	es.watching = false
+
+	if es.logger != nil {
+		es.logger.Info("stopped watching etcd",
+			logger.String("prefix", es.prefix),
+		)
+	}
+
+	return nil
+}
+
+// Reload forces a reload of etcd configuration.
+func (es *EtcdSource) Reload(ctx context.Context) error {
+	if es.logger != nil {
+		es.logger.Info("reloading etcd configuration",
+			logger.String("prefix", es.prefix),
+		)
+	}
+
+	_, err := es.Load(ctx)
+
+	return err
+}
+
+// IsWatchable returns true if etcd watching is enabled.
+func (es *EtcdSource) IsWatchable() bool {
+	return es.options.WatchEnabled
+}
+
+// SupportsSecrets returns true (etcd can store secrets).
+func (es *EtcdSource) SupportsSecrets() bool {
+	return true
+}
+
+// GetSecret retrieves a secret from etcd.
+func (es *EtcdSource) GetSecret(ctx context.Context, key string) (string, error) {
+	secretKey := key
+	if !strings.HasPrefix(key, es.prefix) {
+		secretKey = es.keyPrefix() + key
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, es.options.Timeout)
+	defer cancel()
+
+	resp, err := es.kv.Get(ctx, secretKey)
+	if err != nil {
+		return "", configcore.ErrConfigError(fmt.Sprintf("failed to get secret from etcd: %v", err), err)
+	}
+
+	if len(resp.Kvs) == 0 {
+		return "", configcore.ErrConfigError("secret not found in etcd: "+key, nil)
+	}
+
+	return string(resp.Kvs[0].Value), nil
+}
+
+// GetRevision returns the etcd revision of the last load or watch event.
+func (es *EtcdSource) GetRevision() int64 {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+
+	return es.revision
+}
+
+// Close closes the etcd client if the source created it.
+func (es *EtcdSource) Close() error {
+	if es.ownsClient && es.client != nil {
+		return es.client.Close()
+	}
+
+	return nil
+}
+
+// ReportMetadata reports the last seen revision.
+func (es *EtcdSource) ReportMetadata(metadata *configcore.SourceMetadata) {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+
+	if metadata.Properties == nil {
+		metadata.Properties = make(map[string]any)
+	}
+
+	metadata.Properties["revision"] = es.revision
+	metadata.Properties["keys"] = len(es.values)
+}
+
+// watchLoop watches the prefix, resuming from the last seen revision after
+// errors and reconnects, and reloading fully when that revision was compacted.
+func (es *EtcdSource) watchLoop(ctx context.Context, stop chan struct{}, callback func(map[string]any)) {
+	defer func() {
+		if r := recover(); r != nil {
+			if es.logger != nil {
+				es.logger.Error("panic in etcd watch loop",
+					logger.String("prefix", es.prefix),
+					logger.Any("panic", r),
+				)
+			}
+		}
+	}()
+
+	if es.GetRevision() == 0 {
+		if _, err := es.Load(ctx); err != nil {
+			es.handleWatchError(err)
+		}
+	}
+
+	failures := 0
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		default:
+		}
+
+		progressed, err := es.watchOnce(ctx, stop, callback)
+		if progressed {
+			failures = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		default:
+		}
+
+		if err == nil {
+			continue
+		}
+
+		failures++
+		delay := backoffDelay(failures, es.options.RetryDelay, es.options.MaxRetryDelay)
+
+		es.handleWatchError(err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// watchOnce runs a single watch stream until it fails or is stopped. It
+// reports whether any response was applied.
+func (es *EtcdSource) watchOnce(ctx context.Context, stop chan struct{}, callback func(map[string]any)) (bool, error) {
+	watchCtx, cancel := context.WithCancel(clientv3.WithRequireLeader(ctx))
+	defer cancel()
+
+	revision := es.GetRevision()
+	watchChan := es.watcher.Watch(watchCtx, es.keyPrefix(), clientv3.WithPrefix(), clientv3.WithRev(revision+1))
+
+	progressed := false
+
+	for {
+		var (
+			resp clientv3.WatchResponse
+			ok   bool
+		)
+
+		select {
+		case <-ctx.Done():
+			return progressed, nil
+		case <-stop:
+			return progressed, nil
+		case resp, ok = <-watchChan:
+		}
+
+		if !ok {
+			return progressed, configcore.ErrConfigError("etcd watch channel closed", nil)
+		}
+
+		if resp.CompactRevision != 0 {
+			// Missed history: resynchronise and resume from the new revision
+			if es.logger != nil {
+				es.logger.Warn("etcd watch revision compacted, reloading",
+					logger.String("prefix", es.prefix),
+					logger.Int64("compact_revision", resp.CompactRevision),
+				)
+			}
+
+			config, err := es.Load(ctx)
+			if err != nil {
+				return progressed, err
+			}
+
+			if callback != nil {
+				callback(config)
+			}
+
+			return true, nil
+		}
+
+		if err := resp.Err(); err != nil {
+			return progressed, err
+		}
+
+		if resp.IsProgressNotify() || len(resp.Events) == 0 {
+			continue
+		}
+
+		progressed = true
+
+		es.mu.Lock()
+		for _, event := range resp.Events {
+			key := string(event.Kv.Key)
+
+			if event.Type == clientv3.EventTypeDelete {
+				delete(es.values, key)
+			} else {
+				es.values[key] = event.Kv.Value
+			}
+		}
+
+		es.revision = resp.Header.Revision
+		config := es.buildConfig()
+		es.mu.Unlock()
+
+		if es.logger != nil {
+			es.logger.Info("etcd changes detected",
+				logger.String("prefix", es.prefix),
+				logger.Int64("revision", resp.Header.Revision),
+				logger.Int("events", len(resp.Events)),
+			)
+		}
+
+		if callback != nil {
+			callback(config)
+		}
+	}
+}
+
+// buildConfig converts the current key/value snapshot to nested
+// configuration. Callers must hold the lock.
+func (es *EtcdSource) buildConfig() map[string]any {
+	if es.values == nil {
+		es.values = make(map[string][]byte)
+	}
+
+	keys := make([]string, 0, len(es.values))
+	for key := range es.values {
+		keys = append(keys, key)
+	}
+
+	// Parents before children so that nested keys win over scalar parents
+	sort.Strings(keys)
+
+	config := make(map[string]any)
+	keyPrefix := es.keyPrefix()
+
+	for _, fullKey := range keys {
+		key := strings.Trim(strings.TrimPrefix(fullKey, keyPrefix), "/")
+		if key == "" {
+			continue
+		}
+
+		es.setNestedValue(config, key, parseKVValue(es.values[fullKey]))
+	}
+
+	return config
+}
+
+// keyPrefix returns the prefix used for range requests, ending with "/" so
+// that "app" does not match "app2".
+func (es *EtcdSource) keyPrefix() string {
+	if es.prefix == "" {
+		return ""
+	}
+
+	return strings.TrimSuffix(es.prefix, "/") + "/"
+}
+
+// parseKVValue parses a key/value store value, attempting JSON first, then
+// YAML documents, then treating it as a string.
+func parseKVValue(data []byte) any {
+	if len(data) == 0 {
+		return ""
+	}
+
+	var jsonValue any
+	if err := json.Unmarshal(data, &jsonValue); err == nil {
+		return jsonValue
+	}
+
+	// Only structured YAML is used; YAML scalars would reinterpret plain strings
+	var yamlValue any
+	if err := yaml.Unmarshal(data, &yamlValue); err == nil {
+		switch yamlValue.(type) {
+		case map[string]any, []any:
+			return yamlValue
+		}
+	}
+
+	return string(data)
+}
+
+// setNestedValue sets a nested configuration value using slash notation.
+func (es *EtcdSource) setNestedValue(config map[string]any, key string, value any) {
+	setNestedPath(config, strings.Split(key, "/"), value)
+}
+
+// handleWatchError handles errors during watching. The watch resumes from
+// the last seen revision with backoff.
+func (es *EtcdSource) handleWatchError(err error) {
+	if es.logger != nil {
+		es.logger.Warn("etcd watch error, resuming",
+			logger.String("prefix", es.prefix),
+			logger.Int64("revision", es.GetRevision()),
+			logger.Error(err),
+		)
+	}
+
+	if es.errorHandler != nil {
+		_ = es.errorHandler.HandleError(context.Background(), configcore.ErrConfigError("etcd watch error for prefix "+es.prefix, err))
+	}
+}
+
+// EtcdSourceFactory creates etcd configuration sources.
+type EtcdSourceFactory struct {
+	logger       logger.Logger
+	errorHandler errors.ErrorHandler
+}
+
+// NewEtcdSourceFactory creates a new etcd source factory.
+func NewEtcdSourceFactory(logger logger.Logger, errorHandler errors.ErrorHandler) *EtcdSourceFactory {
+	return &EtcdSourceFactory{
+		logger:       logger,
+		errorHandler: errorHandler,
+	}
+}
+
+// CreateFromConfig creates an etcd source from configuration.
+func (factory *EtcdSourceFactory) CreateFromConfig(config EtcdSourceConfig) (configcore.ConfigSource, error) {
+	options := EtcdSourceOptions{
+		Endpoints:     append([]string(nil), config.Endpoints...),
+		Username:      config.Username,
+		Password:      config.Password,
+		Priority:      config.Priority,
+		WatchEnabled:  config.WatchEnabled,
+		DialTimeout:   config.DialTimeout,
+		Timeout:       config.Timeout,
+		RetryDelay:    config.RetryDelay,
+		MaxRetryDelay: config.MaxRetryDelay,
+		TLS:           config.TLS,
+		Logger:        factory.logger,
+		ErrorHandler:  factory.errorHandler,
+	}
+
+	return NewEtcdSource(config.Prefix, options)
+}
@@ -0,0 +1,273 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// fakeEtcd implements clientv3.KV and clientv3.Watcher in memory.
+type fakeEtcd struct {
+	mu        sync.Mutex
+	kvs       map[string]string
+	revision  int64
+	getErr    error
+	watches   chan clientv3.WatchChan
+	watchRevs []int64
+	streams   []chan clientv3.WatchResponse
+}
+
+func newFakeEtcd(kvs map[string]string) *fakeEtcd {
+	return &fakeEtcd{kvs: kvs, revision: 10, watches: make(chan clientv3.WatchChan, 8)}
+}
+
+func (f *fakeEtcd) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.getErr != nil {
+		return nil, f.getErr
+	}
+
+	op := clientv3.OpGet(key, opts...)
+	resp := &clientv3.GetResponse{Header: &etcdserverpb.ResponseHeader{Revision: f.revision}}
+
+	for k, v := range f.kvs {
+		if k == key || (op.RangeBytes() != nil && len(k) >= len(key) && k[:len(key)] == key) {
+			resp.Kvs = append(resp.Kvs, &mvccpb.KeyValue{Key: []byte(k), Value: []byte(v)})
+		}
+	}
+
+	resp.Count = int64(len(resp.Kvs))
+
+	return resp, nil
+}
+
+func (f *fakeEtcd) Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeEtcd) Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeEtcd) Compact(ctx context.Context, rev int64, opts ...clientv3.CompactOption) (*clientv3.CompactResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeEtcd) Do(ctx context.Context, op clientv3.Op) (clientv3.OpResponse, error) {
+	return clientv3.OpResponse{}, errors.New("not implemented")
+}
+
+func (f *fakeEtcd) Txn(ctx context.Context) clientv3.Txn { return nil }
+
+func (f *fakeEtcd) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	stream := make(chan clientv3.WatchResponse, 4)
+
+	f.mu.Lock()
+	f.watchRevs = append(f.watchRevs, clientv3.OpGet(key, opts...).Rev())
+	f.streams = append(f.streams, stream)
+	f.mu.Unlock()
+
+	f.watches <- stream
+
+	return stream
+}
+
+func (f *fakeEtcd) RequestProgress(ctx context.Context) error { return nil }
+func (f *fakeEtcd) Close() error                              { return nil }
+
+// stream returns the most recent watch stream.
+func (f *fakeEtcd) stream() chan clientv3.WatchResponse {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.streams[len(f.streams)-1]
+}
+
+// newTestEtcdSource creates a watchable etcd source backed by fake.
+func newTestEtcdSource(t *testing.T, fake *fakeEtcd) *EtcdSource {
+	t.Helper()
+
+	client := clientv3.NewCtxClient(context.Background())
+
+	source, err := NewEtcdSource("apps/api", EtcdSourceOptions{
+		Client:        client,
+		WatchEnabled:  true,
+		RetryDelay:    10 * time.Millisecond,
+		MaxRetryDelay: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewEtcdSource() error = %v", err)
+	}
+
+	es := source.(*EtcdSource)
+	es.kv = fake
+	es.watcher = fake
+
+	return es
+}
+
+func putEvent(key, value string, revision int64) *clientv3.Event {
+	return &clientv3.Event{Type: mvccpb.PUT, Kv: &mvccpb.KeyValue{Key: []byte(key), Value: []byte(value), ModRevision: revision}}
+}
+
+func TestEtcdSource_Load(t *testing.T) {
+	fake := newFakeEtcd(map[string]string{
+		"apps/api/database/host": "db.internal",
+		"apps/api/database/port": "5432",
+		"apps/api/features":      `{"search": true}`,
+		"apps/api/smtp":          "host: mail\nport: 25\n",
+		"apps/api/motd":          "Welcome aboard",
+		"apps/api2/ignored":      "x",
+	})
+	source := newTestEtcdSource(t, fake)
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := map[string]any{
+		"database": map[string]any{"host": "db.internal", "port": float64(5432)},
+		"features": map[string]any{"search": true},
+		"smtp":     map[string]any{"host": "mail", "port": 25},
+		"motd":     "Welcome aboard",
+	}
+
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("Load() = %#v, want %#v", data, want)
+	}
+
+	if source.GetRevision() != 10 {
+		t.Errorf("GetRevision() = %d, want 10", source.GetRevision())
+	}
+
+	secret, err := source.GetSecret(context.Background(), "database/host")
+	if err != nil || secret != "db.internal" {
+		t.Errorf("GetSecret() = %q, %v", secret, err)
+	}
+}
+
+func TestEtcdSource_WatchResumesFromRevision(t *testing.T) {
+	fake := newFakeEtcd(map[string]string{"apps/api/level": "info"})
+	source := newTestEtcdSource(t, fake)
+
+	if _, err := source.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	updates := make(chan map[string]any, 4)
+	if err := source.Watch(context.Background(), func(data map[string]any) { updates <- data }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer func() { _ = source.StopWatch() }()
+
+	<-fake.watches
+	fake.stream() <- clientv3.WatchResponse{
+		Header: etcdserverpb.ResponseHeader{Revision: 12},
+		Events: []*clientv3.Event{putEvent("apps/api/level", "debug", 12)},
+	}
+
+	select {
+	case data := <-updates:
+		if data["level"] != "debug" {
+			t.Errorf("watched data = %v, want level=debug", data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for etcd update")
+	}
+
+	// Simulate a lost connection; the watch must resume after revision 12.
+	close(fake.stream())
+
+	select {
+	case <-fake.watches:
+	case <-time.After(2 * time.Second):
+		t.Fatal("watch was not re-established")
+	}
+
+	fake.mu.Lock()
+	revs := append([]int64(nil), fake.watchRevs...)
+	fake.mu.Unlock()
+
+	if !reflect.DeepEqual(revs, []int64{11, 13}) {
+		t.Errorf("watch revisions = %v, want [11 13]", revs)
+	}
+
+	fake.stream() <- clientv3.WatchResponse{
+		Header: etcdserverpb.ResponseHeader{Revision: 14},
+		Events: []*clientv3.Event{{Type: mvccpb.DELETE, Kv: &mvccpb.KeyValue{Key: []byte("apps/api/level")}}},
+	}
+
+	select {
+	case data := <-updates:
+		if _, ok := data["level"]; ok {
+			t.Errorf("watched data = %v, want level deleted", data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for etcd delete")
+	}
+}
+
+func TestEtcdSource_WatchCompactionReloads(t *testing.T) {
+	fake := newFakeEtcd(map[string]string{"apps/api/level": "info"})
+	source := newTestEtcdSource(t, fake)
+
+	_, _ = source.Load(context.Background())
+
+	updates := make(chan map[string]any, 4)
+	_ = source.Watch(context.Background(), func(data map[string]any) { updates <- data })
+	defer func() { _ = source.StopWatch() }()
+
+	<-fake.watches
+
+	fake.mu.Lock()
+	fake.kvs["apps/api/level"] = "warn"
+	fake.revision = 50
+	fake.mu.Unlock()
+
+	fake.stream() <- clientv3.WatchResponse{CompactRevision: 40, Canceled: true}
+
+	select {
+	case data := <-updates:
+		if data["level"] != "warn" {
+			t.Errorf("reloaded data = %v, want level=warn", data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for compaction reload")
+	}
+
+	select {
+	case <-fake.watches:
+	case <-time.After(2 * time.Second):
+		t.Fatal("watch was not re-established after compaction")
+	}
+
+	fake.mu.Lock()
+	lastRev := fake.watchRevs[len(fake.watchRevs)-1]
+	fake.mu.Unlock()
+
+	if lastRev != 51 {
+		t.Errorf("watch revision after compaction = %d, want 51", lastRev)
+	}
+}
+
+func TestEtcdSourceFactory_TLSError(t *testing.T) {
+	factory := NewEtcdSourceFactory(nil, nil)
+
+	_, err := factory.CreateFromConfig(EtcdSourceConfig{
+		Prefix: "apps/api",
+		TLS:    &EtcdTLSConfig{Enabled: true, CAFile: "/does/not/exist.pem"},
+	})
+	if err == nil {
+		t.Error("CreateFromConfig() with missing CA file should fail")
+	}
+}
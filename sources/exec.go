@@ -0,0 +1,531 @@
+package sources
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xraph/confy/formats"
+	configcore "github.com/xraph/confy/internal"
+	errors "github.com/xraph/go-utils/errs"
+	logger "github.com/xraph/go-utils/log"
+)
+
+// ExecSource represents configuration produced by an external command, such
+// as a credential helper (`aws configure export-credentials`, `op read`).
+type ExecSource struct {
+	name         string
+	command      string
+	priority     int
+	processor    formats.FormatProcessor
+	lastData     map[string]any
+	lastStderr   string
+	lastError    error
+	lastRun      time.Time
+	lastExitCode int
+	watching     bool
+	watchStop    chan struct{}
+	logger       logger.Logger
+	errorHandler errors.ErrorHandler
+	options      ExecSourceOptions
+	mu           sync.RWMutex
+}
+
+// ExecSourceOptions contains options for exec sources.
+type ExecSourceOptions struct {
+	Name string
+	// Format of the command output. Defaults to JSON.
+	Format string
+	// RawKey stores the trimmed output as a single string value under this
+	// dot-separated key instead of parsing it, e.g. for `op read`.
+	RawKey   string
+	Priority int
+	Args     []string
+	// Env is added to the current environment, or replaces it with ClearEnv.
+	Env      map[string]string
+	ClearEnv bool
+	Dir      string
+	// Timeout bounds each command run. Defaults to 30 seconds.
+	Timeout time.Duration
+	// WatchEnabled re-runs the command every WatchInterval (default 5 minutes).
+	WatchEnabled  bool
+	WatchInterval time.Duration
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+	Logger        logger.Logger
+	ErrorHandler  errors.ErrorHandler
+}
+
+// ExecSourceConfig contains configuration for creating exec sources.
+type ExecSourceConfig struct {
+	Command       string            `json:"command"         yaml:"command"`
+	Args          []string          `json:"args"            yaml:"args"`
+	Env           map[string]string `json:"env"             yaml:"env"`
+	ClearEnv      bool              `json:"clear_env"       yaml:"clear_env"`
+	Dir           string            `json:"dir"             yaml:"dir"`
+	Format        string            `json:"format"          yaml:"format"`
+	RawKey        string            `json:"raw_key"         yaml:"raw_key"`
+	Priority      int               `json:"priority"        yaml:"priority"`
+	Timeout       time.Duration     `json:"timeout"         yaml:"timeout"`
+	WatchEnabled  bool              `json:"watch_enabled"   yaml:"watch_enabled"`
+	WatchInterval time.Duration     `json:"watch_interval"  yaml:"watch_interval"`
+	RetryDelay    time.Duration     `json:"retry_delay"     yaml:"retry_delay"`
+	MaxRetryDelay time.Duration     `json:"max_retry_delay" yaml:"max_retry_delay"`
+}
+
+// NewExecSource creates a source that runs command and parses its standard
+// output. Standard error is captured and reported in the source metadata.
+// Values of the output are also served through GetSecret.
+func NewExecSource(command string, options ExecSourceOptions) (configcore.ConfigSource, error) {
+	if command == "" {
+		return nil, configcore.ErrConfigError("exec source command cannot be empty", nil)
+	}
+
+	var processor formats.FormatProcessor
+
+	if options.RawKey == "" {
+		format := options.Format
+		if format == "" {
+			format = "json"
+		}
+
+		var err error
+
+		processor, err = getFormatProcessor(format)
+		if err != nil {
+			return nil, configcore.ErrConfigError("unsupported format: "+format, err)
+		}
+	}
+
+	if options.Timeout == 0 {
+		options.Timeout = 30 * time.Second
+	}
+
+	if options.WatchInterval == 0 {
+		options.WatchInterval = 5 * time.Minute
+	}
+
+	if options.RetryDelay == 0 {
+		options.RetryDelay = time.Second
+	}
+
+	if options.MaxRetryDelay == 0 {
+		options.MaxRetryDelay = 2 * time.Minute
+	}
+
+	name := options.Name
+	if name == "" {
+		name = "exec:" + filepath.Base(command)
+	}
+
+	return &ExecSource{
+		name:         name,
+		command:      command,
+		priority:     options.Priority,
+		processor:    processor,
+		logger:       options.Logger,
+		errorHandler: options.ErrorHandler,
+		options:      options,
+	}, nil
+}
+
+// Name returns the source name.
+func (es *ExecSource) Name() string {
+	return es.name
+}
+
+// GetName returns the source name (alias for Name).
+func (es *ExecSource) GetName() string {
+	return es.name
+}
+
+// GetType returns the source type.
+func (es *ExecSource) GetType() string {
+	return "exec"
+}
+
+// IsAvailable checks if the command can be found.
+func (es *ExecSource) IsAvailable(ctx context.Context) bool {
+	_, err := exec.LookPath(es.command)
+
+	return err == nil
+}
+
+// Priority returns the source priority.
+func (es *ExecSource) Priority() int {
+	return es.priority
+}
+
+// Load runs the command and parses its output.
+func (es *ExecSource) Load(ctx context.Context) (map[string]any, error) {
+	if es.logger != nil {
+		es.logger.Debug("loading configuration from command",
+			logger.String("command", es.command),
+		)
+	}
+
+	data, err := es.run(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return copyConfigMap(data), nil
+}
+
+// Watch re-runs the command on an interval and reports changed output.
+func (es *ExecSource) Watch(ctx context.Context, callback func(map[string]any)) error {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	if es.watching {
+		return configcore.ErrConfigError("already watching exec source", nil)
+	}
+
+	if !es.IsWatchable() {
+		return configcore.ErrConfigError("exec watching is not enabled", nil)
+	}
+
+	es.watchStop = make(chan struct{})
+	es.watching = true
+
+	go es.watchLoop(ctx, es.watchStop, callback)
+
+	if es.logger != nil {
+		es.logger.Info("started watching exec source",
+			logger.String("command", es.command),
+			logger.Duration("interval", es.options.WatchInterval),
+		)
+	}
+
+	return nil
+}
+
+// StopWatch stops re-running the command.
+func (es *ExecSource) StopWatch() error {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	if !es.watching {
+		return nil
+	}
+
+	if es.watchStop != nil {
+		close(es.watchStop)
+		es.watchStop = nil
+	}
+
+	es.watching = false
+
+	if es.logger != nil {
+		es.logger.Info("stopped watching exec source",
+			logger.String("command", es.command),
+		)
+	}
+
+	return nil
+}
+
+// Reload runs the command again.
+func (es *ExecSource) Reload(ctx context.Context) error {
+	_, err := es.run(ctx)
+
+	return err
+}
+
+// IsWatchable returns true if re-running is enabled.
+func (es *ExecSource) IsWatchable() bool {
+	return es.options.WatchEnabled
+}
+
+// SupportsSecrets returns true; command output is commonly credentials.
+func (es *ExecSource) SupportsSecrets() bool {
+	return true
+}
+
+// GetSecret returns the value at the dot-separated key of the command
+// output. The command is run if it has not produced output yet.
+func (es *ExecSource) GetSecret(ctx context.Context, key string) (string, error) {
+	es.mu.RLock()
+	data := es.lastData
+	es.mu.RUnlock()
+
+	if data == nil {
+		var err error
+
+		data, err = es.run(ctx)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	var current any = data
+
+	for _, part := range strings.Split(key, ".") {
+		section, ok := current.(map[string]any)
+		if !ok {
+			return "", configcore.ErrConfigError("secret not found: "+key, nil)
+		}
+
+		current, ok = section[part]
+		if !ok {
+			return "", configcore.ErrConfigError("secret not found: "+key, nil)
+		}
+	}
+
+	if value, ok := current.(string); ok {
+		return value, nil
+	}
+
+	return fmt.Sprint(current), nil
+}
+
+// GetCommand returns the command.
+func (es *ExecSource) GetCommand() string {
+	return es.command
+}
+
+// ReportMetadata reports the last run. A failed run sets LastError from the
+// command error and its standard error output.
+func (es *ExecSource) ReportMetadata(metadata *configcore.SourceMetadata) {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+
+	if metadata.Properties == nil {
+		metadata.Properties = make(map[string]any)
+	}
+
+	metadata.Properties["command"] = es.command
+	metadata.Properties["exit_code"] = es.lastExitCode
+
+	if !es.lastRun.IsZero() {
+		metadata.Properties["last_run"] = es.lastRun
+	}
+
+	if es.lastStderr != "" {
+		metadata.Properties["stderr"] = es.lastStderr
+	}
+
+	if es.lastError != nil {
+		metadata.LastError = es.lastError.Error()
+	}
+}
+
+// run executes the command, records the outcome and returns parsed output.
+func (es *ExecSource) run(ctx context.Context) (map[string]any, error) {
+	ctx, cancel := context.WithTimeout(ctx, es.options.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, es.command, es.options.Args...) //nolint:gosec // G204: command is configured by the application
+	cmd.Dir = es.options.Dir
+	cmd.Env = es.environment()
+	// Do not wait forever for children that keep the output pipes open
+	cmd.WaitDelay = time.Second
+
+	var stdout, stderr bytes.Buffer
+
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	runErr := cmd.Run()
+	stderrText := strings.TrimSpace(stderr.String())
+
+	var data map[string]any
+
+	err := runErr
+	if err != nil {
+		message := "command " + es.command + " failed"
+		if stderrText != "" {
+			message += ": " + stderrText
+		}
+
+		err = configcore.ErrConfigError(message, runErr)
+	} else {
+		data, err = es.parse(stdout.Bytes())
+	}
+
+	es.mu.Lock()
+	es.lastRun = time.Now()
+	es.lastStderr = stderrText
+	es.lastError = err
+
+	if cmd.ProcessState != nil {
+		es.lastExitCode = cmd.ProcessState.ExitCode()
+	} else {
+		es.lastExitCode = -1
+	}
+
+	if err == nil {
+		es.lastData = data
+	}
+	es.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if stderrText != "" && es.logger != nil {
+		es.logger.Debug("command wrote to stderr",
+			logger.String("command", es.command),
+			logger.String("stderr", stderrText),
+		)
+	}
+
+	return data, nil
+}
+
+// parse converts command output to configuration data.
+func (es *ExecSource) parse(output []byte) (map[string]any, error) {
+	if es.options.RawKey != "" {
+		data := make(map[string]any)
+		setNestedPath(data, strings.Split(es.options.RawKey, "."), strings.TrimSpace(string(output)))
+
+		return data, nil
+	}
+
+	data, err := es.processor.Parse(output)
+	if err != nil {
+		return nil, configcore.ErrConfigError("failed to parse output of command "+es.command, err)
+	}
+
+	if data == nil {
+		data = make(map[string]any)
+	}
+
+	return data, nil
+}
+
+// environment returns the command environment.
+func (es *ExecSource) environment() []string {
+	var env []string
+	if !es.options.ClearEnv {
+		env = os.Environ()
+	}
+
+	for key, value := range es.options.Env {
+		env = append(env, key+"="+value)
+	}
+
+	return env
+}
+
+// watchLoop re-runs the command until stopped.
+func (es *ExecSource) watchLoop(ctx context.Context, stop chan struct{}, callback func(map[string]any)) {
+	defer func() {
+		if r := recover(); r != nil {
+			if es.logger != nil {
+				es.logger.Error("panic in exec watch loop",
+					logger.String("command", es.command),
+					logger.Any("panic", r),
+				)
+			}
+		}
+	}()
+
+	es.mu.RLock()
+	previous := es.lastData
+	es.mu.RUnlock()
+
+	failures := 0
+
+	for {
+		delay := es.options.WatchInterval
+		if failures > 0 {
+			delay = backoffDelay(failures, es.options.RetryDelay, es.options.MaxRetryDelay)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-time.After(delay):
+		}
+
+		data, err := es.run(ctx)
+		if err != nil {
+			failures++
+
+			es.handleWatchError(err, failures)
+
+			continue
+		}
+
+		failures = 0
+
+		if reflect.DeepEqual(previous, data) {
+			continue
+		}
+
+		previous = data
+
+		if callback != nil {
+			if es.logger != nil {
+				es.logger.Info("exec configuration change detected",
+					logger.String("command", es.command),
+				)
+			}
+
+			callback(copyConfigMap(data))
+		}
+	}
+}
+
+// handleWatchError handles errors during watching. The watch keeps running
+// and retries with backoff.
+func (es *ExecSource) handleWatchError(err error, attempt int) {
+	if es.logger != nil {
+		es.logger.Warn("exec watch error, retrying",
+			logger.String("command", es.command),
+			logger.Int("attempt", attempt),
+			logger.Error(err),
+		)
+	}
+
+	if es.errorHandler != nil {
+		_ = es.errorHandler.HandleError(context.Background(), configcore.ErrConfigError("exec watch error for "+es.command, err))
+	}
+}
+
+// ExecSourceFactory creates exec configuration sources.
+type ExecSourceFactory struct {
+	logger       logger.Logger
+	errorHandler errors.ErrorHandler
+}
+
+// NewExecSourceFactory creates a new exec source factory.
+func NewExecSourceFactory(logger logger.Logger, errorHandler errors.ErrorHandler) *ExecSourceFactory {
+	return &ExecSourceFactory{
+		logger:       logger,
+		errorHandler: errorHandler,
+	}
+}
+
+// CreateFromConfig creates an exec source from configuration.
+func (factory *ExecSourceFactory) CreateFromConfig(config ExecSourceConfig) (configcore.ConfigSource, error) {
+	options := ExecSourceOptions{
+		Format:        config.Format,
+		RawKey:        config.RawKey,
+		Priority:      config.Priority,
+		Args:          append([]string(nil), config.Args...),
+		Env:           maps.Clone(config.Env),
+		ClearEnv:      config.ClearEnv,
+		Dir:           config.Dir,
+		Timeout:       config.Timeout,
+		WatchEnabled:  config.WatchEnabled,
+		WatchInterval: config.WatchInterval,
+		RetryDelay:    config.RetryDelay,
+		MaxRetryDelay: config.MaxRetryDelay,
+		Logger:        factory.logger,
+		ErrorHandler:  factory.errorHandler,
+	}
+
+	return NewExecSource(config.Command, options)
+}
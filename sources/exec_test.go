@@ -0,0 +1,165 @@
+package sources
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	configcore "github.com/xraph/confy/internal"
+)
+
+// writeScript writes an executable shell script and returns its path.
+func writeScript(t *testing.T, dir, name, body string) string {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not supported on windows")
+	}
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0755); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+
+	return path
+}
+
+func TestExecSource_Load(t *testing.T) {
+	script := writeScript(t, t.TempDir(), "creds.sh", `
+echo "warming up" >&2
+printf '{"aws": {"access_key_id": "%s", "region": "%s"}}' "$1" "$REGION"
+`)
+
+	source, err := NewExecSource(script, ExecSourceOptions{
+		Args: []string{"AKIA123"},
+		Env:  map[string]string{"REGION": "eu-west-1"},
+	})
+	if err != nil {
+		t.Fatalf("NewExecSource() error = %v", err)
+	}
+
+	if source.Name() != "exec:creds.sh" {
+		t.Errorf("Name() = %s, want exec:creds.sh", source.Name())
+	}
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	aws, _ := data["aws"].(map[string]any)
+	if aws["access_key_id"] != "AKIA123" || aws["region"] != "eu-west-1" {
+		t.Errorf("Load() = %v, want args and env applied", data)
+	}
+
+	secret, err := source.GetSecret(context.Background(), "aws.access_key_id")
+	if err != nil || secret != "AKIA123" {
+		t.Errorf("GetSecret() = %q, %v", secret, err)
+	}
+
+	if _, err := source.GetSecret(context.Background(), "aws.missing"); err == nil {
+		t.Error("GetSecret() for missing key should fail")
+	}
+
+	metadata := &configcore.SourceMetadata{}
+	source.(configcore.SourceMetadataReporter).ReportMetadata(metadata)
+
+	if metadata.Properties["stderr"] != "warming up" || metadata.LastError != "" {
+		t.Errorf("metadata = %+v, want stderr captured without error", metadata)
+	}
+}
+
+func TestExecSource_FailureCapturesStderr(t *testing.T) {
+	script := writeScript(t, t.TempDir(), "fail.sh", `
+echo "token expired" >&2
+exit 3
+`)
+
+	source, _ := NewExecSource(script, ExecSourceOptions{})
+
+	_, err := source.Load(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "token expired") {
+		t.Fatalf("Load() error = %v, want stderr in error", err)
+	}
+
+	metadata := &configcore.SourceMetadata{}
+	source.(configcore.SourceMetadataReporter).ReportMetadata(metadata)
+
+	if !strings.Contains(metadata.LastError, "token expired") {
+		t.Errorf("LastError = %q, want stderr", metadata.LastError)
+	}
+
+	if metadata.Properties["exit_code"] != 3 {
+		t.Errorf("exit_code = %v, want 3", metadata.Properties["exit_code"])
+	}
+}
+
+func TestExecSource_RawKeyAndTimeout(t *testing.T) {
+	dir := t.TempDir()
+
+	raw := writeScript(t, dir, "read.sh", "echo 's3cr3t'\n")
+
+	source, _ := NewExecSource(raw, ExecSourceOptions{RawKey: "database.password"})
+
+	secret, err := source.GetSecret(context.Background(), "database.password")
+	if err != nil || secret != "s3cr3t" {
+		t.Errorf("GetSecret() = %q, %v", secret, err)
+	}
+
+	slow := writeScript(t, dir, "slow.sh", "sleep 5\n")
+
+	source, _ = NewExecSource(slow, ExecSourceOptions{Timeout: 50 * time.Millisecond})
+
+	start := time.Now()
+	if _, err := source.Load(context.Background()); err == nil {
+		t.Error("Load() should fail on timeout")
+	}
+
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Load() took %v, want timeout to stop the command", elapsed)
+	}
+}
+
+func TestExecSource_Watch(t *testing.T) {
+	dir := t.TempDir()
+	valueFile := filepath.Join(dir, "value")
+
+	if err := os.WriteFile(valueFile, []byte("1"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	script := writeScript(t, dir, "version.sh", `printf '{"version": "%s"}' "$(cat "$VALUE_FILE")"`)
+
+	source, _ := NewExecSource(script, ExecSourceOptions{
+		Env:           map[string]string{"VALUE_FILE": valueFile},
+		WatchEnabled:  true,
+		WatchInterval: 20 * time.Millisecond,
+	})
+
+	if _, err := source.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	updates := make(chan map[string]any, 4)
+	if err := source.Watch(context.Background(), func(data map[string]any) { updates <- data }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer func() { _ = source.StopWatch() }()
+
+	if err := os.WriteFile(valueFile, []byte("2"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case data := <-updates:
+		if data["version"] != "2" {
+			t.Errorf("watched data = %v, want version 2", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for exec watch update")
+	}
+}
@@ -5,9 +5,11 @@ package sources
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -28,8 +30,12 @@ type FileSource struct {
 	processor     formats.FormatProcessor
 	watcher       *fsnotify.Watcher
 	lastModTime   time.Time
+	fingerprint   fileFingerprint
+	includeFiles  map[string]fileFingerprint
+	includePaths  []string
 	watchCallback func(map[string]any)
 	watching      bool
+	watchStop     chan struct{}
 	mu            sync.RWMutex
 	logger        logger.Logger
 	errorHandler  errors.ErrorHandler
@@ -37,14 +43,27 @@ type FileSource struct {
 }
 
 // FileSourceOptions contains options for file sources.
+//
+// PollInterval enables an mtime/hash polling fallback for filesystems that do
+// not deliver change notifications, such as NFS or overlayfs; zero disables it.
+//
+// ResolveIncludes expands $include keys and YAML !include tags, merging the
+// named files (globs allowed) under the including map.
+//
+// IncludeRoot is the directory includes may not escape. It defaults to the
+// directory of the including file, so "../shared/base.yaml" is rejected
+// unless IncludeRoot is set to a common parent.
 type FileSourceOptions struct {
 	Name            string
 	Format          string
 	Priority        int
 	WatchEnabled    bool
 	WatchInterval   time.Duration
+	PollInterval    time.Duration
 	ExpandEnvVars   bool
 	ExpandSecrets   bool
+	ResolveIncludes bool
+	IncludeRoot     string
 	RequireFile     bool
 	BackupEnabled   bool
 	BackupDir       string
@@ -56,16 +75,19 @@ type FileSourceOptions struct {
 
 // FileSourceConfig contains configuration for creating file sources.
 type FileSourceConfig struct {
-	Path          string        `json:"path"            yaml:"path"`
-	Format        string        `json:"format"          yaml:"format"`
-	Priority      int           `json:"priority"        yaml:"priority"`
-	WatchEnabled  bool          `json:"watch_enabled"   yaml:"watch_enabled"`
-	WatchInterval time.Duration `json:"watch_interval"  yaml:"watch_interval"`
-	ExpandEnvVars bool          `json:"expand_env_vars" yaml:"expand_env_vars"`
-	ExpandSecrets bool          `json:"expand_secrets"  yaml:"expand_secrets"`
-	RequireFile   bool          `json:"require_file"    yaml:"require_file"`
-	BackupEnabled bool          `json:"backup_enabled"  yaml:"backup_enabled"`
-	BackupDir     string        `json:"backup_dir"      yaml:"backup_dir"`
+	Path            string        `json:"path"             yaml:"path"`
+	Format          string        `json:"format"           yaml:"format"`
+	Priority        int           `json:"priority"         yaml:"priority"`
+	WatchEnabled    bool          `json:"watch_enabled"    yaml:"watch_enabled"`
+	WatchInterval   time.Duration `json:"watch_interval"   yaml:"watch_interval"`
+	PollInterval    time.Duration `json:"poll_interval"    yaml:"poll_interval"`
+	ExpandEnvVars   bool          `json:"expand_env_vars"  yaml:"expand_env_vars"`
+	ExpandSecrets   bool          `json:"expand_secrets"   yaml:"expand_secrets"`
+	ResolveIncludes bool          `json:"resolve_includes" yaml:"resolve_includes"`
+	IncludeRoot     string        `json:"include_root"     yaml:"include_root"`
+	RequireFile     bool          `json:"require_file"     yaml:"require_file"`
+	BackupEnabled   bool          `json:"backup_enabled"   yaml:"backup_enabled"`
+	BackupDir       string        `json:"backup_dir"       yaml:"backup_dir"`
 }
 
 // NewFileSource creates a new file-based configuration source.
@@ -188,19 +210,21 @@ func (fs *FileSource) Load(ctx context.Context) (map[string]any, error) {
 		return nil, configcore.ErrConfigError("failed to resolve symlinks for "+path, err)
 	}
 
-	// Read file content using scoped file access to prevent directory traversal (Go 1.24+)
-	dir := filepath.Dir(resolvedPath)
-	filename := filepath.Base(resolvedPath)
-	root, err := os.OpenRoot(dir)
-	if err != nil {
-		return nil, configcore.ErrConfigError("failed to open root directory "+dir, err)
-	}
-	defer func() { _ = root.Close() }()
-	content, err := root.ReadFile(filename)
+	content, err := readResolvedFile(resolvedPath)
 	if err != nil {
 		return nil, configcore.ErrConfigError("failed to read file "+path, err)
 	}
 
+	// Remember what was read so watchers can tell real changes from noise
+	fs.mu.Lock()
+	fs.fingerprint = fileFingerprint{
+		resolved: resolvedPath,
+		size:     stat.Size(),
+		modTime:  stat.ModTime(),
+		hash:     sha256.Sum256(content),
+	}
+	fs.mu.Unlock()
+
 	// Create backup if enabled
 	if fs.options.BackupEnabled {
 		if err := fs.createBackup(content); err != nil {
@@ -213,12 +237,26 @@ func (fs *FileSource) Load(ctx context.Context) (map[string]any, error) {
 		}
 	}
 
+	if fs.options.ResolveIncludes && fs.format == "yaml" {
+		if content, err = rewriteIncludeTags(content); err != nil {
+			return nil, configcore.ErrConfigError("failed to expand include tags in "+path, err)
+		}
+	}
+
 	// Parse content
 	data, err := fs.processor.Parse(content)
 	if err != nil {
 		return nil, configcore.ErrConfigError("failed to parse file "+path, err)
 	}
 
+	// Resolve $include directives if enabled
+	if fs.options.ResolveIncludes {
+		data, err = fs.resolveIncludes(resolvedPath, data)
+		if err != nil {
+			return nil, configcore.ErrConfigError("failed to resolve includes in "+path, err)
+		}
+	}
+
 	// Expand environment variables if enabled
 	if fs.options.ExpandEnvVars {
 		data = fs.expandEnvironmentVariables(data)
@@ -253,6 +291,13 @@ func (fs *FileSource) Load(ctx context.Context) (map[string]any, error) {
 }
 
 // Watch starts watching the file for changes.
+//
+// The file's directory is watched rather than the file itself, so atomic
+// replacements (editors writing a temporary file and renaming it over the
+// original, Kubernetes swapping its ..data symlink) are picked up. Symlinks
+// are re-resolved after every event and the directory holding the current
+// target is watched as well. When PollInterval is set the file is also
+// polled, which covers filesystems that never deliver notifications.
 func (fs *FileSource) Watch(ctx context.Context, callback func(map[string]any)) error {
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
@@ -265,36 +310,70 @@ func (fs *FileSource) Watch(ctx context.Context, callback func(map[string]any))
 		return configcore.ErrConfigError("file watching is not enabled", nil)
 	}
 
-	watcher, err := fsnotify.NewWatcher()
-	if err != nil {
-		return configcore.ErrConfigError("failed to create file watcher", err)
+	if fs.fingerprint.resolved == "" {
+		if fingerprint, err := readFingerprint(fs.path); err == nil {
+			fs.fingerprint = fingerprint
+		}
 	}
 
-	// Watch the file's directory to catch file recreations
 	dir := filepath.Dir(fs.path)
-	if err := watcher.Add(dir); err != nil {
-		_ = watcher.Close()
 
-		return configcore.ErrConfigError("failed to watch directory "+dir, err)
+	watcher, err := fs.newDirectoryWatcher(dir)
+	if err != nil {
+		if fs.options.PollInterval <= 0 {
+			return err
+		}
+
+		if fs.logger != nil {
+			fs.logger.Warn("file notifications unavailable, falling back to polling",
+				logger.String("path", fs.path),
+				logger.Duration("interval", fs.options.PollInterval),
+				logger.Error(err),
+			)
+		}
 	}
 
+	if watcher != nil {
+		fs.syncWatches(watcher, watchDirectories(fs.path, fs.fingerprint.resolved, fs.includeFiles, fs.includePaths))
+	}
+
+	stop := make(chan struct{})
+
 	fs.watcher = watcher
 	fs.watchCallback = callback
+	fs.watchStop = stop
 	fs.watching = true
 
 	// Start watching goroutine
-	go fs.watchLoop(ctx)
+	go fs.watchLoop(ctx, watcher, stop, callback)
 
 	if fs.logger != nil {
 		fs.logger.Info("started watching file",
 			logger.String("path", fs.path),
 			logger.String("directory", dir),
+			logger.Duration("poll_interval", fs.options.PollInterval),
 		)
 	}
 
 	return nil
 }
 
+// newDirectoryWatcher creates an fsnotify watcher on dir.
+func (fs *FileSource) newDirectoryWatcher(dir string) (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, configcore.ErrConfigError("failed to create file watcher", err)
+	}
+
+	if err := watcher.Add(dir); err != nil {
+		_ = watcher.Close()
+
+		return nil, configcore.ErrConfigError("failed to watch directory "+dir, err)
+	}
+
+	return watcher, nil
+}
+
 // StopWatch stops watching the file.
 func (fs *FileSource) StopWatch() error {
 	fs.mu.Lock()
@@ -304,6 +383,11 @@ func (fs *FileSource) StopWatch() error {
 		return nil
 	}
 
+	if fs.watchStop != nil {
+		close(fs.watchStop)
+		fs.watchStop = nil
+	}
+
 	if fs.watcher != nil {
 		if err := fs.watcher.Close(); err != nil {
 			if fs.logger != nil {
@@ -365,8 +449,9 @@ func (fs *FileSource) GetSecret(ctx context.Context, key string) (string, error)
 	return value, nil
 }
 
-// watchLoop is the main watching loop.
-func (fs *FileSource) watchLoop(ctx context.Context) {
+// watchLoop is the main watching loop. watcher is nil when only polling is
+// available.
+func (fs *FileSource) watchLoop(ctx context.Context, watcher *fsnotify.Watcher, stop <-chan struct{}, callback func(map[string]any)) {
 	defer func() {
 		if r := recover(); r != nil {
 			if fs.logger != nil {
@@ -378,61 +463,164 @@ func (fs *FileSource) watchLoop(ctx context.Context) {
 		}
 	}()
 
+	var (
+		events      <-chan fsnotify.Event
+		watchErrors <-chan error
+		poll        <-chan time.Time
+	)
+
+	if watcher != nil {
+		events = watcher.Events
+		watchErrors = watcher.Errors
+	}
+
+	if fs.options.PollInterval > 0 {
+		ticker := time.NewTicker(fs.options.PollInterval)
+		defer ticker.Stop()
+
+		poll = ticker.C
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case event, ok := <-fs.watcher.Events:
+		case <-stop:
+			return
+		case event, ok := <-events:
 			if !ok {
 				return
 			}
 
-			fs.handleFileEvent(ctx, event)
-		case err, ok := <-fs.watcher.Errors:
+			fs.handleFileEvent(ctx, watcher, event, callback)
+		case err, ok := <-watchErrors:
 			if !ok {
 				return
 			}
 
 			fs.handleWatchError(err)
+		case <-poll:
+			fs.checkForChange(ctx, watcher, true, callback)
 		}
 	}
 }
 
 // handleFileEvent handles file system events.
-func (fs *FileSource) handleFileEvent(ctx context.Context, event fsnotify.Event) {
-	// Check if this event is for our file
-	if !fs.isOurFile(event.Name) {
-		return
-	}
+//
+// Any event in a watched directory may mean the file changed: a rename over
+// it, a symlink swap further up the chain, or a write to the current target.
+// Events naming the file, its target or an include force a content
+// comparison; anything else only triggers one when a resolved path, size or
+// mtime moved.
+func (fs *FileSource) handleFileEvent(ctx context.Context, watcher *fsnotify.Watcher, event fsnotify.Event, callback func(map[string]any)) {
+	fs.mu.RLock()
+	path := fs.path
+	resolved := fs.fingerprint.resolved
+	includes := fs.includeFiles
+	patterns := fs.includePaths
+	fs.mu.RUnlock()
 
-	if fs.logger != nil {
+	name := filepath.Clean(event.Name)
+	ours := name == filepath.Clean(path) || name == resolved || matchesInclude(name, includes, patterns)
+
+	if ours && fs.logger != nil {
 		fs.logger.Debug("file event received",
 			logger.String("path", event.Name),
 			logger.String("operation", event.Op.String()),
 		)
 	}
 
-	// Check if file was modified
-	if event.Op&fsnotify.Write == fsnotify.Write || event.Op&fsnotify.Create == fsnotify.Create {
-		// Check modification time to avoid duplicate events
-		if stat, err := os.Stat(fs.path); err == nil {
+	fs.checkForChange(ctx, watcher, ours, callback)
+}
+
+// checkForChange re-resolves the file, reloads it when its content differs
+// from what was last loaded, and moves the directory watches to follow the
+// current symlink target and includes.
+func (fs *FileSource) checkForChange(ctx context.Context, watcher *fsnotify.Watcher, force bool, callback func(map[string]any)) {
+	fs.mu.RLock()
+	path := fs.path
+	previous := fs.fingerprint
+	includes := fs.includeFiles
+	patterns := fs.includePaths
+	fs.mu.RUnlock()
+
+	if watcher != nil {
+		defer func() {
 			fs.mu.RLock()
-			lastMod := fs.lastModTime
+			dirs := watchDirectories(fs.path, fs.fingerprint.resolved, fs.includeFiles, fs.includePaths)
 			fs.mu.RUnlock()
 
-			if !stat.ModTime().After(lastMod) {
-				return // No actual change
-			}
+			fs.syncWatches(watcher, dirs)
+		}()
+	}
+
+	current, err := statFingerprint(path)
+	if err != nil {
+		// A missing file is usually an atomic replace in flight; wait for
+		// the event that brings it back.
+		if !os.IsNotExist(err) {
+			fs.handleWatchError(err)
 		}
 
-		// Load new configuration
-		if data, err := fs.Load(ctx); err == nil {
-			if fs.watchCallback != nil {
-				fs.watchCallback(data)
-			}
-		} else {
+		return
+	}
+
+	if !force && current.sameStat(previous) && !includesChanged(includes, patterns, false) {
+		return
+	}
+
+	current, err = readFingerprint(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
 			fs.handleWatchError(err)
 		}
+
+		return
+	}
+
+	if current.hash == previous.hash && !includesChanged(includes, patterns, true) {
+		fs.mu.Lock()
+		fs.fingerprint = current
+		fs.mu.Unlock()
+
+		return
+	}
+
+	data, err := fs.Load(ctx)
+	if err != nil {
+		fs.handleWatchError(err)
+
+		return
+	}
+
+	if callback != nil {
+		callback(data)
+	}
+}
+
+// syncWatches makes sure every wanted directory is watched, re-adding
+// watches lost when a directory was replaced and dropping the ones left
+// behind by a symlink swap.
+func (fs *FileSource) syncWatches(watcher *fsnotify.Watcher, wanted []string) {
+	watched := watcher.WatchList()
+
+	for _, dir := range wanted {
+		if slices.Contains(watched, dir) {
+			continue
+		}
+
+		if err := watcher.Add(dir); err == nil && fs.logger != nil {
+			fs.logger.Debug("watching directory",
+				logger.String("path", fs.path),
+				logger.String("directory", dir),
+			)
+		}
+	}
+
+	for _, dir := range watched {
+		if !slices.Contains(wanted, dir) {
+			_ = watcher.Remove(dir)
+		}
 	}
 }
 
@@ -450,38 +638,92 @@ func (fs *FileSource) handleWatchError(err error) {
 	}
 }
 
-// isOurFile checks if the event is for our configuration file.
-func (fs *FileSource) isOurFile(eventPath string) bool {
-	// Clean paths for comparison
-	cleanEventPath := filepath.Clean(eventPath)
-	cleanFilePath := filepath.Clean(fs.path)
+// fileFingerprint identifies the content last loaded from a file.
+type fileFingerprint struct {
+	resolved string
+	size     int64
+	modTime  time.Time
+	hash     [sha256.Size]byte
+}
+
+// sameStat reports whether two fingerprints point at the same target with
+// the same size and modification time.
+func (f fileFingerprint) sameStat(other fileFingerprint) bool {
+	return f.resolved == other.resolved && f.size == other.size && f.modTime.Equal(other.modTime)
+}
+
+// statFingerprint resolves path and stats its target without reading it.
+func statFingerprint(path string) (fileFingerprint, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fileFingerprint{}, err
+	}
+
+	stat, err := os.Stat(resolved)
+	if err != nil {
+		return fileFingerprint{}, err
+	}
 
-	return cleanEventPath == cleanFilePath
+	return fileFingerprint{resolved: resolved, size: stat.Size(), modTime: stat.ModTime()}, nil
+}
+
+// readFingerprint resolves path and hashes the content of its target.
+func readFingerprint(path string) (fileFingerprint, error) {
+	fingerprint, err := statFingerprint(path)
+	if err != nil {
+		return fingerprint, err
+	}
+
+	content, err := readResolvedFile(fingerprint.resolved)
+	if err != nil {
+		return fingerprint, err
+	}
+
+	fingerprint.hash = sha256.Sum256(content)
+
+	return fingerprint, nil
+}
+
+// readResolvedFile reads a file using scoped file access to prevent directory
+// traversal (Go 1.24+).
+func readResolvedFile(resolvedPath string) ([]byte, error) {
+	root, err := os.OpenRoot(filepath.Dir(resolvedPath))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = root.Close() }()
+
+	return root.ReadFile(filepath.Base(resolvedPath))
 }
 
 // expandEnvironmentVariables recursively expands environment variables.
 func (fs *FileSource) expandEnvironmentVariables(data map[string]any) map[string]any {
+	return expandEnvInMap(data)
+}
+
+// expandEnvInMap recursively expands environment variables in a map.
+func expandEnvInMap(data map[string]any) map[string]any {
 	result := make(map[string]any)
 
 	for key, value := range data {
-		result[key] = fs.expandValue(value)
+		result[key] = expandEnvInValue(value)
 	}
 
 	return result
 }
 
-// expandValue recursively expands environment variables in a value.
+// expandEnvInValue recursively expands environment variables in a value.
 // Supports both standard ${VAR} and bash-style ${VAR:-default} syntax.
-func (fs *FileSource) expandValue(value any) any {
+func expandEnvInValue(value any) any {
 	switch v := value.(type) {
 	case string:
 		return expandEnvWithDefaults(v)
 	case map[string]any:
-		return fs.expandEnvironmentVariables(v)
+		return expandEnvInMap(v)
 	case []any:
 		result := make([]any, len(v))
 		for i, item := range v {
-			result[i] = fs.expandValue(item)
+			result[i] = expandEnvInValue(item)
 		}
 
 		return result
@@ -702,6 +944,15 @@ func getFormatProcessor(format string) (formats.FormatProcessor, error) {
 	}
 }
 
+// defaultFormatLoader holds the built-in format processors for lookups by extension.
+var defaultFormatLoader = formats.NewLoader(formats.LoaderConfig{})
+
+// processorForExtension returns the format processor registered for a file
+// extension such as ".yaml" or ".json".
+func processorForExtension(ext string) (formats.FormatProcessor, bool) {
+	return defaultFormatLoader.GetProcessorByExtension(strings.ToLower(ext))
+}
+
 // FileSourceFactory creates file sources.
 type FileSourceFactory struct {
 	logger       logger.Logger
@@ -719,18 +970,21 @@ func NewFileSourceFactory(logger logger.Logger, errorHandler errors.ErrorHandler
 // CreateFromConfig creates a file source from configuration.
 func (factory *FileSourceFactory) CreateFromConfig(config FileSourceConfig) (configcore.ConfigSource, error) {
 	options := FileSourceOptions{
-		Name:          "file:" + filepath.Base(config.Path),
-		Format:        config.Format,
-		Priority:      config.Priority,
-		WatchEnabled:  config.WatchEnabled,
-		WatchInterval: config.WatchInterval,
-		ExpandEnvVars: config.ExpandEnvVars,
-		ExpandSecrets: config.ExpandSecrets,
-		RequireFile:   config.RequireFile,
-		BackupEnabled: config.BackupEnabled,
-		BackupDir:     config.BackupDir,
-		Logger:        factory.logger,
-		ErrorHandler:  factory.errorHandler,
+		Name:            "file:" + filepath.Base(config.Path),
+		Format:          config.Format,
+		Priority:        config.Priority,
+		WatchEnabled:    config.WatchEnabled,
+		WatchInterval:   config.WatchInterval,
+		PollInterval:    config.PollInterval,
+		ExpandEnvVars:   config.ExpandEnvVars,
+		ExpandSecrets:   config.ExpandSecrets,
+		ResolveIncludes: config.ResolveIncludes,
+		IncludeRoot:     config.IncludeRoot,
+		RequireFile:     config.RequireFile,
+		BackupEnabled:   config.BackupEnabled,
+		BackupDir:       config.BackupDir,
+		Logger:          factory.logger,
+		ErrorHandler:    factory.errorHandler,
 	}
 
 	return NewFileSource(config.Path, options)
@@ -1064,3 +1064,175 @@ func TestFileSource_Symlink(t *testing.T) {
 		t.Errorf("key = %v, want value", data["key"])
 	}
 }
+
+func TestFileSource_Watch_AtomicRename(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping watch test on Windows due to rename semantics")
+	}
+
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "config.yaml")
+	_ = os.WriteFile(testFile, []byte("key: initial\n"), 0644)
+
+	source, err := NewFileSource(testFile, FileSourceOptions{WatchEnabled: true})
+	if err != nil {
+		t.Fatalf("NewFileSource() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, _ = source.Load(ctx)
+
+	changes := make(chan map[string]any, 10)
+	if err := source.Watch(ctx, func(data map[string]any) { changes <- data }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer func() { _ = source.StopWatch() }()
+
+	// Editors save by writing a temporary file and renaming it over the
+	// original; an older mtime must not hide the change.
+	tmpFile := filepath.Join(tmpDir, ".config.yaml.tmp")
+	_ = os.WriteFile(tmpFile, []byte("key: renamed\n"), 0644)
+	past := time.Now().Add(-time.Hour)
+	_ = os.Chtimes(tmpFile, past, past)
+
+	if err := os.Rename(tmpFile, testFile); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+
+	waitForFileValue(t, changes, "renamed")
+
+	// The watch must survive the rename.
+	_ = os.WriteFile(testFile, []byte("key: rewritten\n"), 0644)
+	waitForFileValue(t, changes, "rewritten")
+}
+
+func TestFileSource_Watch_SymlinkSwap(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping symlink test on Windows")
+	}
+
+	// Lay the directory out the way Kubernetes mounts a ConfigMap:
+	// config.yaml -> ..data/config.yaml, ..data -> ..v1
+	tmpDir := t.TempDir()
+	writeVersion := func(version, value string) {
+		dir := filepath.Join(tmpDir, version)
+		_ = os.MkdirAll(dir, 0755)
+		_ = os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("key: "+value+"\n"), 0644)
+	}
+	swapData := func(version string) {
+		tmpLink := filepath.Join(tmpDir, "..data_tmp")
+		if err := os.Symlink(version, tmpLink); err != nil {
+			t.Fatalf("Symlink() error = %v", err)
+		}
+
+		if err := os.Rename(tmpLink, filepath.Join(tmpDir, "..data")); err != nil {
+			t.Fatalf("Rename() error = %v", err)
+		}
+	}
+
+	writeVersion("..v1", "v1")
+	if err := os.Symlink("..v1", filepath.Join(tmpDir, "..data")); err != nil {
+		t.Skip("Cannot create symlink:", err)
+	}
+
+	testFile := filepath.Join(tmpDir, "config.yaml")
+	_ = os.Symlink(filepath.Join("..data", "config.yaml"), testFile)
+
+	source, err := NewFileSource(testFile, FileSourceOptions{WatchEnabled: true})
+	if err != nil {
+		t.Fatalf("NewFileSource() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	data, err := source.Load(ctx)
+	if err != nil || data["key"] != "v1" {
+		t.Fatalf("Load() = %v, %v, want key v1", data, err)
+	}
+
+	changes := make(chan map[string]any, 10)
+	if err := source.Watch(ctx, func(data map[string]any) { changes <- data }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer func() { _ = source.StopWatch() }()
+
+	writeVersion("..v2", "v2")
+	swapData("..v2")
+	_ = os.RemoveAll(filepath.Join(tmpDir, "..v1"))
+
+	waitForFileValue(t, changes, "v2")
+
+	// Writes to the new target are seen once the watch follows the symlink.
+	_ = os.WriteFile(filepath.Join(tmpDir, "..v2", "config.yaml"), []byte("key: v2-edited\n"), 0644)
+	waitForFileValue(t, changes, "v2-edited")
+}
+
+func TestFileSource_Watch_PollingFallback(t *testing.T) {
+	// The directory does not exist yet, so notifications cannot be set up;
+	// polling picks the file up once it appears.
+	tmpDir := filepath.Join(t.TempDir(), "mount")
+	testFile := filepath.Join(tmpDir, "config.yaml")
+
+	source, err := NewFileSource(testFile, FileSourceOptions{WatchEnabled: true})
+	if err != nil {
+		t.Fatalf("NewFileSource() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := source.Watch(ctx, func(map[string]any) {}); err == nil {
+		t.Fatal("Watch() should fail without a directory when polling is disabled")
+	}
+
+	source, err = NewFileSource(testFile, FileSourceOptions{
+		WatchEnabled: true,
+		PollInterval: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewFileSource() error = %v", err)
+	}
+
+	changes := make(chan map[string]any, 10)
+	if err := source.Watch(ctx, func(data map[string]any) { changes <- data }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	_ = os.MkdirAll(tmpDir, 0755)
+	_ = os.WriteFile(testFile, []byte("key: polled\n"), 0644)
+
+	waitForFileValue(t, changes, "polled")
+
+	// Rewriting identical content is not a change.
+	_ = os.WriteFile(testFile, []byte("key: polled\n"), 0644)
+
+	select {
+	case data := <-changes:
+		t.Errorf("unexpected change %v for identical content", data)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if err := source.StopWatch(); err != nil {
+		t.Errorf("StopWatch() error = %v", err)
+	}
+}
+
+func waitForFileValue(t *testing.T, changes <-chan map[string]any, want string) {
+	t.Helper()
+
+	timeout := time.After(3 * time.Second)
+
+	for {
+		select {
+		case data := <-changes:
+			if data["key"] == want {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("change to key=%s not detected", want)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package sources
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strings"
+
+	configcore "github.com/xraph/confy/internal"
+)
+
+// FilterOptions selects and renames keys of a source.
+//
+// Patterns are dot-separated key paths. Within a segment, the wildcards of
+// path.Match apply ("db_*"); a "**" segment matches any number of segments.
+// A pattern matching a section selects everything below it.
+type FilterOptions struct {
+	// Include keeps only matching keys. Empty includes everything.
+	Include []string
+	// Exclude drops matching keys, after Include is applied.
+	Exclude []string
+	// Rename moves keys or whole sections, e.g. {"db": "database"}.
+	// The longest matching source path wins.
+	Rename map[string]string
+}
+
+// FilterSource restricts and renames the keys of a source.
+type FilterSource struct {
+	source  configcore.ConfigSource
+	options FilterOptions
+}
+
+// Filter returns a source exposing only the keys of source selected by
+// options, optionally renamed. Name, priority, watching, secrets and metadata
+// are passed through to the wrapped source.
+func Filter(source configcore.ConfigSource, options FilterOptions) configcore.ConfigSource {
+	return &FilterSource{
+		source:  source,
+		options: options,
+	}
+}
+
+// Name returns the wrapped source name.
+func (fs *FilterSource) Name() string {
+	return fs.source.Name()
+}
+
+// GetName returns the source name (alias for Name).
+func (fs *FilterSource) GetName() string {
+	return fs.source.Name()
+}
+
+// GetType returns the wrapped source type.
+func (fs *FilterSource) GetType() string {
+	return fs.source.GetType()
+}
+
+// IsAvailable checks if the wrapped source is available.
+func (fs *FilterSource) IsAvailable(ctx context.Context) bool {
+	return fs.source.IsAvailable(ctx)
+}
+
+// Priority returns the wrapped source priority.
+func (fs *FilterSource) Priority() int {
+	return fs.source.Priority()
+}
+
+// Load loads the wrapped source and applies the filter.
+func (fs *FilterSource) Load(ctx context.Context) (map[string]any, error) {
+	data, err := fs.source.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return fs.apply(data), nil
+}
+
+// Watch watches the wrapped source, filtering every update.
+func (fs *FilterSource) Watch(ctx context.Context, callback func(map[string]any)) error {
+	return fs.source.Watch(ctx, func(data map[string]any) {
+		if callback != nil {
+			callback(fs.apply(data))
+		}
+	})
+}
+
+// StopWatch stops watching the wrapped source.
+func (fs *FilterSource) StopWatch() error {
+	return fs.source.StopWatch()
+}
+
+// Reload reloads the wrapped source.
+func (fs *FilterSource) Reload(ctx context.Context) error {
+	return fs.source.Reload(ctx)
+}
+
+// IsWatchable returns true if the wrapped source is watchable.
+func (fs *FilterSource) IsWatchable() bool {
+	return fs.source.IsWatchable()
+}
+
+// SupportsSecrets returns true if the wrapped source supports secrets.
+func (fs *FilterSource) SupportsSecrets() bool {
+	return fs.source.SupportsSecrets()
+}
+
+// GetSecret retrieves a secret from the wrapped source. Keys the filter
+// excludes are not readable through it; renamed keys are mapped back first.
+func (fs *FilterSource) GetSecret(ctx context.Context, key string) (string, error) {
+	sourceKey, err := fs.sourceKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	return fs.source.GetSecret(ctx, sourceKey)
+}
+
+// IsWritable returns true if the wrapped source accepts writes.
+func (fs *FilterSource) IsWritable() bool {
+	_, ok := writableInner(fs.source)
+
+	return ok
+}
+
+// Persist forwards the write to the wrapped source under the key's original
+// name. Writes to keys, or into sections, that the filter excludes fail.
+func (fs *FilterSource) Persist(ctx context.Context, key string, value any) error {
+	writable, ok := writableInner(fs.source)
+	if !ok {
+		return configcore.ErrConfigError("source "+fs.source.Name()+" is not writable", nil)
+	}
+
+	sourceKey, err := fs.sourceKey(key)
+	if err != nil {
+		return err
+	}
+
+	if nested, ok := value.(map[string]any); ok {
+		leaves := make(map[string]any)
+		flattenConfig(nested, key, leaves)
+
+		for leaf := range leaves {
+			leafSource, err := fs.sourceKey(leaf)
+			if err != nil {
+				return err
+			}
+
+			if leafSource != sourceKey+strings.TrimPrefix(leaf, key) {
+				return configcore.ErrConfigError("key "+leaf+" is renamed differently from "+key, nil)
+			}
+		}
+	}
+
+	return writable.Persist(ctx, sourceKey, value)
+}
+
+// Unwrap returns the wrapped source.
+func (fs *FilterSource) Unwrap() configcore.ConfigSource {
+	return fs.source
+}
+
+// ReportMetadata reports the wrapped source metadata.
+func (fs *FilterSource) ReportMetadata(metadata *configcore.SourceMetadata) {
+	if reporter, ok := fs.source.(configcore.SourceMetadataReporter); ok {
+		reporter.ReportMetadata(metadata)
+	}
+}
+
+// apply filters and renames data.
+func (fs *FilterSource) apply(data map[string]any) map[string]any {
+	leaves := make(map[string]any)
+	flattenConfig(data, "", leaves)
+
+	result := make(map[string]any)
+
+	keys := make([]string, 0, len(leaves))
+	for key := range leaves {
+		keys = append(keys, key)
+	}
+
+	// Sort for deterministic results when renames collide.
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !fs.selects(key) {
+			continue
+		}
+
+		setNestedPath(result, strings.Split(fs.rename(key), "."), leaves[key])
+	}
+
+	return result
+}
+
+// rename applies the longest matching rename rule to key.
+func (fs *FilterSource) rename(key string) string {
+	best := ""
+
+	for from := range fs.options.Rename {
+		if (key == from || strings.HasPrefix(key, from+".")) && len(from) > len(best) {
+			best = from
+		}
+	}
+
+	if best == "" {
+		return key
+	}
+
+	return fs.options.Rename[best] + strings.TrimPrefix(key, best)
+}
+
+// sourceKey maps a key as exposed by the filter back to the wrapped source's
+// key, failing if the filter does not expose it.
+func (fs *FilterSource) sourceKey(key string) (string, error) {
+	froms := make([]string, 0, len(fs.options.Rename))
+	for from := range fs.options.Rename {
+		froms = append(froms, from)
+	}
+
+	sort.Strings(froms)
+
+	sourceKey, bestTarget := key, ""
+
+	for _, from := range froms {
+		to := fs.options.Rename[from]
+		if (key == to || strings.HasPrefix(key, to+".")) && len(to) > len(bestTarget) {
+			sourceKey, bestTarget = from+strings.TrimPrefix(key, to), to
+		}
+	}
+
+	if fs.rename(sourceKey) != key || !fs.selects(sourceKey) {
+		return "", configcore.ErrConfigError("key "+key+" is excluded by the filter", nil)
+	}
+
+	return sourceKey, nil
+}
+
+// selects reports whether the filter keeps the source key.
+func (fs *FilterSource) selects(key string) bool {
+	if len(fs.options.Include) > 0 && !matchesAnyKeyPattern(fs.options.Include, key) {
+		return false
+	}
+
+	return !matchesAnyKeyPattern(fs.options.Exclude, key)
+}
+
+// flattenConfig collects leaf values of data keyed by dot-separated path.
+// Empty maps are kept as leaves so that they survive filtering.
+func flattenConfig(data map[string]any, prefix string, leaves map[string]any) {
+	for key, value := range data {
+		fullKey := key
+		if prefix != "" {
+			fullKey = prefix + "." + key
+		}
+
+		if nested, ok := value.(map[string]any); ok && len(nested) > 0 {
+			flattenConfig(nested, fullKey, leaves)
+
+			continue
+		}
+
+		leaves[fullKey] = value
+	}
+}
+
+// matchesAnyKeyPattern reports whether key, or a section containing it,
+// matches one of the patterns.
+func matchesAnyKeyPattern(patterns []string, key string) bool {
+	segments := strings.Split(key, ".")
+
+	for _, pattern := range patterns {
+		patternSegments := strings.Split(pattern, ".")
+
+		for end := 1; end <= len(segments); end++ {
+			if matchKeySegments(patternSegments, segments[:end]) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// matchKeySegments matches key segments against pattern segments.
+func matchKeySegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchKeySegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+
+	if ok, err := path.Match(pattern[0], segments[0]); err != nil || !ok {
+		return false
+	}
+
+	return matchKeySegments(pattern[1:], segments[1:])
+}
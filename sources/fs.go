@@ -0,0 +1,251 @@
+package sources
+
+import (
+	"context"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/xraph/confy/formats"
+	configcore "github.com/xraph/confy/internal"
+	errors "github.com/xraph/go-utils/errs"
+	logger "github.com/xraph/go-utils/log"
+)
+
+// FSSource represents a configuration source backed by an fs.FS, such as
+// defaults compiled into the binary with //go:embed.
+type FSSource struct {
+	name         string
+	fsys         fs.FS
+	pattern      string
+	priority     int
+	logger       logger.Logger
+	errorHandler errors.ErrorHandler
+	options      FSSourceOptions
+}
+
+// FSSourceOptions contains options for fs.FS sources.
+type FSSourceOptions struct {
+	Name string
+	// Format forces a format for all matched files. By default the format is
+	// detected from each file extension.
+	Format string
+	// Priority defaults to 0, below every other source, so that compiled-in
+	// defaults are overridden by files and environment variables.
+	Priority int
+	// RequireFiles fails loading when the pattern matches no file.
+	RequireFiles  bool
+	ExpandEnvVars bool
+	Logger        logger.Logger
+	ErrorHandler  errors.ErrorHandler
+}
+
+// NewFSSource creates a source reading every file of fsys matching pattern
+// (fs.Glob syntax). Matched files are parsed with the registered format
+// processors and merged in lexical order, later files overriding earlier ones.
+func NewFSSource(fsys fs.FS, pattern string, options FSSourceOptions) (configcore.ConfigSource, error) {
+	if fsys == nil {
+		return nil, configcore.ErrConfigError("file system cannot be nil", nil)
+	}
+
+	if pattern == "" {
+		return nil, configcore.ErrConfigError("fs pattern cannot be empty", nil)
+	}
+
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, configcore.ErrConfigError("invalid fs pattern "+pattern, err)
+	}
+
+	if options.Format != "" {
+		if _, err := getFormatProcessor(options.Format); err != nil {
+			return nil, configcore.ErrConfigError("unsupported format: "+options.Format, err)
+		}
+	}
+
+	name := options.Name
+	if name == "" {
+		name = "fs:" + pattern
+	}
+
+	return &FSSource{
+		name:         name,
+		fsys:         fsys,
+		pattern:      pattern,
+		priority:     options.Priority,
+		logger:       options.Logger,
+		errorHandler: options.ErrorHandler,
+		options:      options,
+	}, nil
+}
+
+// Name returns the source name.
+func (fss *FSSource) Name() string {
+	return fss.name
+}
+
+// GetName returns the source name (alias for Name).
+func (fss *FSSource) GetName() string {
+	return fss.name
+}
+
+// GetType returns the source type.
+func (fss *FSSource) GetType() string {
+	return "fs"
+}
+
+// IsAvailable checks if the pattern matches at least one file.
+func (fss *FSSource) IsAvailable(ctx context.Context) bool {
+	matches, err := fss.matches()
+
+	return err == nil && len(matches) > 0
+}
+
+// Priority returns the source priority.
+func (fss *FSSource) Priority() int {
+	return fss.priority
+}
+
+// Load loads and merges all files matching the pattern.
+func (fss *FSSource) Load(ctx context.Context) (map[string]any, error) {
+	matches, err := fss.matches()
+	if err != nil {
+		return nil, configcore.ErrConfigError("failed to match fs pattern "+fss.pattern, err)
+	}
+
+	if len(matches) == 0 {
+		if fss.options.RequireFiles {
+			return nil, configcore.ErrConfigError("no files match fs pattern "+fss.pattern, nil)
+		}
+
+		return make(map[string]any), nil
+	}
+
+	merger := configcore.NewMergeUtil()
+	result := make(map[string]any)
+
+	for _, name := range matches {
+		data, err := fss.loadFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		merger.MergeInPlace(result, data)
+	}
+
+	if fss.logger != nil {
+		fss.logger.Debug("configuration loaded from fs",
+			logger.String("pattern", fss.pattern),
+			logger.Int("files", len(matches)),
+			logger.Int("keys", len(result)),
+		)
+	}
+
+	return result, nil
+}
+
+// Watch is not supported; fs.FS has no change notification.
+func (fss *FSSource) Watch(ctx context.Context, callback func(map[string]any)) error {
+	return configcore.ErrConfigError("fs source does not support watching", nil)
+}
+
+// StopWatch is a no-op for fs sources.
+func (fss *FSSource) StopWatch() error {
+	return nil
+}
+
+// Reload reloads the configuration.
+func (fss *FSSource) Reload(ctx context.Context) error {
+	_, err := fss.Load(ctx)
+
+	return err
+}
+
+// IsWatchable returns false; fs.FS content is treated as immutable.
+func (fss *FSSource) IsWatchable() bool {
+	return false
+}
+
+// SupportsSecrets returns false.
+func (fss *FSSource) SupportsSecrets() bool {
+	return false
+}
+
+// GetSecret is not supported by fs sources.
+func (fss *FSSource) GetSecret(ctx context.Context, key string) (string, error) {
+	return "", configcore.ErrConfigError("fs source does not support secrets", nil)
+}
+
+// GetPattern returns the glob pattern.
+func (fss *FSSource) GetPattern() string {
+	return fss.pattern
+}
+
+// matches returns the regular files matching the pattern in lexical order.
+func (fss *FSSource) matches() ([]string, error) {
+	matches, err := fs.Glob(fss.fsys, fss.pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(matches))
+
+	for _, name := range matches {
+		info, err := fs.Stat(fss.fsys, name)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		files = append(files, name)
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// loadFile reads and parses a single file.
+func (fss *FSSource) loadFile(name string) (map[string]any, error) {
+	processor, err := fss.processorFor(name)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := fs.ReadFile(fss.fsys, name)
+	if err != nil {
+		return nil, configcore.ErrConfigError("failed to read fs file "+name, err)
+	}
+
+	data, err := processor.Parse(content)
+	if err != nil {
+		return nil, configcore.ErrConfigError("failed to parse fs file "+name, err)
+	}
+
+	if data == nil {
+		data = make(map[string]any)
+	}
+
+	if err := processor.Validate(data); err != nil {
+		return nil, configcore.ErrConfigError("validation failed for fs file "+name, err)
+	}
+
+	if fss.options.ExpandEnvVars {
+		data = expandEnvInMap(data)
+	}
+
+	return data, nil
+}
+
+// processorFor returns the processor for a file, honouring the forced format.
+func (fss *FSSource) processorFor(name string) (formats.FormatProcessor, error) {
+	if fss.options.Format != "" {
+		return getFormatProcessor(fss.options.Format)
+	}
+
+	ext := strings.ToLower(path.Ext(name))
+	if processor, ok := processorForExtension(ext); ok {
+		return processor, nil
+	}
+
+	return nil, configcore.ErrConfigError("cannot detect format of fs file "+name, nil)
+}
@@ -0,0 +1,93 @@
+package sources
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFSSource_LoadAndMerge(t *testing.T) {
+	fsys := fstest.MapFS{
+		"defaults/00-base.yaml":  {Data: []byte("server:\n  port: 8080\n  host: 0.0.0.0\n")},
+		"defaults/10-extra.json": {Data: []byte(`{"server": {"port": 9090}, "debug": true}`)},
+		"defaults/README.md":     {Data: []byte("# not config")},
+	}
+
+	// README.md has no registered processor, so loading everything fails.
+	all, _ := NewFSSource(fsys, "defaults/*", FSSourceOptions{})
+	if _, err := all.Load(context.Background()); err == nil {
+		t.Error("Load() should fail for files without a known format")
+	}
+
+	source, err := NewFSSource(fsys, "defaults/*.[jy]*", FSSourceOptions{})
+	if err != nil {
+		t.Fatalf("NewFSSource() error = %v", err)
+	}
+
+	if source.Priority() != 0 {
+		t.Errorf("Priority() = %d, want 0", source.Priority())
+	}
+
+	if source.IsWatchable() {
+		t.Error("IsWatchable() = true, want false")
+	}
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	server, ok := data["server"].(map[string]any)
+	if !ok {
+		t.Fatalf("server section missing: %v", data)
+	}
+
+	if server["host"] != "0.0.0.0" {
+		t.Errorf("server.host = %v, want 0.0.0.0", server["host"])
+	}
+
+	if port, _ := server["port"].(float64); port != 9090 {
+		t.Errorf("server.port = %v, want 9090 from later file", server["port"])
+	}
+
+	if data["debug"] != true {
+		t.Errorf("debug = %v, want true", data["debug"])
+	}
+}
+
+func TestFSSource_NoMatches(t *testing.T) {
+	fsys := fstest.MapFS{}
+
+	source, err := NewFSSource(fsys, "config.yaml", FSSourceOptions{})
+	if err != nil {
+		t.Fatalf("NewFSSource() error = %v", err)
+	}
+
+	if source.IsAvailable(context.Background()) {
+		t.Error("IsAvailable() = true for empty fs")
+	}
+
+	data, err := source.Load(context.Background())
+	if err != nil || len(data) != 0 {
+		t.Errorf("Load() = %v, %v; want empty config", data, err)
+	}
+
+	required, _ := NewFSSource(fsys, "config.yaml", FSSourceOptions{RequireFiles: true})
+	if _, err := required.Load(context.Background()); err == nil {
+		t.Error("Load() with RequireFiles should fail when nothing matches")
+	}
+}
+
+func TestFSSource_InvalidArguments(t *testing.T) {
+	if _, err := NewFSSource(nil, "*.yaml", FSSourceOptions{}); err == nil {
+		t.Error("NewFSSource(nil) should fail")
+	}
+
+	if _, err := NewFSSource(fstest.MapFS{}, "[", FSSourceOptions{}); err == nil {
+		t.Error("NewFSSource() with bad pattern should fail")
+	}
+
+	if _, err := NewFSSource(fstest.MapFS{}, "*.cfg", FSSourceOptions{Format: "ini"}); err == nil {
+		t.Error("NewFSSource() with unknown format should fail")
+	}
+}
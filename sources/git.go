@@ -0,0 +1,725 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/xraph/confy/formats"
+	configcore "github.com/xraph/confy/internal"
+	errors "github.com/xraph/go-utils/errs"
+	logger "github.com/xraph/go-utils/log"
+)
+
+// GitSource represents a configuration source backed by files in a git
+// repository at a branch, tag or commit.
+type GitSource struct {
+	name         string
+	url          string
+	ref          string
+	paths        []string
+	priority     int
+	repo         *git.Repository
+	cloned       bool
+	commit       plumbing.Hash
+	files        []string
+	lastSync     time.Time
+	lastError    error
+	watching     bool
+	watchStop    chan struct{}
+	logger       logger.Logger
+	errorHandler errors.ErrorHandler
+	options      GitSourceOptions
+	repoMu       sync.Mutex
+	mu           sync.RWMutex
+}
+
+// GitSourceOptions contains options for git configuration sources.
+type GitSourceOptions struct {
+	Name string
+	// Ref is a branch, tag or commit SHA. Defaults to the remote's default
+	// branch, or HEAD for local repositories.
+	Ref string
+	// Paths are file paths or glob patterns relative to the repository root.
+	// Matches are merged in order, later files overriding earlier ones.
+	Paths []string
+	// Format forces a format instead of detecting it from file extensions.
+	Format        string
+	Priority      int
+	Username      string
+	Password      string
+	SSHKeyFile    string
+	SSHKeyPass    string
+	SSHUser       string
+	Depth         int
+	ExpandEnvVars bool
+	WatchEnabled  bool
+	WatchInterval time.Duration
+	Timeout       time.Duration
+	Logger        logger.Logger
+	ErrorHandler  errors.ErrorHandler
+}
+
+// GitSourceConfig contains configuration for creating git sources.
+type GitSourceConfig struct {
+	URL           string        `json:"url"             yaml:"url"`
+	Ref           string        `json:"ref"             yaml:"ref"`
+	Paths         []string      `json:"paths"           yaml:"paths"`
+	Format        string        `json:"format"          yaml:"format"`
+	Priority      int           `json:"priority"        yaml:"priority"`
+	Username      string        `json:"username"        yaml:"username"`
+	Password      string        `json:"password"        yaml:"password"`
+	SSHKeyFile    string        `json:"ssh_key_file"    yaml:"ssh_key_file"`
+	SSHKeyPass    string        `json:"ssh_key_pass"    yaml:"ssh_key_pass"`
+	SSHUser       string        `json:"ssh_user"        yaml:"ssh_user"`
+	Depth         int           `json:"depth"           yaml:"depth"`
+	ExpandEnvVars bool          `json:"expand_env_vars" yaml:"expand_env_vars"`
+	WatchEnabled  bool          `json:"watch_enabled"   yaml:"watch_enabled"`
+	WatchInterval time.Duration `json:"watch_interval"  yaml:"watch_interval"`
+	Timeout       time.Duration `json:"timeout"         yaml:"timeout"`
+}
+
+// NewGitSource creates a new git configuration source. A url naming a local
+// directory opens that repository in place and reads committed content only;
+// any other url is cloned into memory and fetched again on reload and watch.
+func NewGitSource(url string, options GitSourceOptions) (configcore.ConfigSource, error) {
+	if url == "" {
+		return nil, configcore.ErrConfigError("git repository url is required", nil)
+	}
+
+	if len(options.Paths) == 0 {
+		return nil, configcore.ErrConfigError("at least one git path is required", nil)
+	}
+
+	for _, pattern := range options.Paths {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, configcore.ErrConfigError("invalid git path pattern "+pattern, err)
+		}
+	}
+
+	if options.Format != "" {
+		if _, err := getFormatProcessor(options.Format); err != nil {
+			return nil, err
+		}
+	}
+
+	if options.WatchInterval == 0 {
+		options.WatchInterval = time.Minute
+	}
+
+	if options.Timeout == 0 {
+		options.Timeout = time.Minute
+	}
+
+	if options.SSHUser == "" {
+		options.SSHUser = "git"
+	}
+
+	name := options.Name
+	if name == "" {
+		name = "git:" + url
+	}
+
+	return &GitSource{
+		name:         name,
+		url:          url,
+		ref:          options.Ref,
+		paths:        append([]string(nil), options.Paths...),
+		priority:     options.Priority,
+		logger:       options.Logger,
+		errorHandler: options.ErrorHandler,
+		options:      options,
+	}, nil
+}
+
+// Name returns the source name.
+func (gs *GitSource) Name() string {
+	return gs.name
+}
+
+// GetName returns the source name (alias for Name).
+func (gs *GitSource) GetName() string {
+	return gs.name
+}
+
+// GetType returns the source type.
+func (gs *GitSource) GetType() string {
+	return "git"
+}
+
+// IsAvailable checks if the repository can be opened and the ref resolved.
+func (gs *GitSource) IsAvailable(ctx context.Context) bool {
+	_, err := gs.sync(ctx)
+
+	return err == nil
+}
+
+// Priority returns the source priority.
+func (gs *GitSource) Priority() int {
+	return gs.priority
+}
+
+// Load fetches the repository and loads the configured paths at the ref.
+func (gs *GitSource) Load(ctx context.Context) (map[string]any, error) {
+	if gs.logger != nil {
+		gs.logger.Debug("loading configuration from git",
+			logger.String("url", gs.url),
+			logger.String("ref", gs.currentRef()),
+		)
+	}
+
+	commit, err := gs.sync(ctx)
+	if err != nil {
+		gs.recordError(err)
+
+		return nil, err
+	}
+
+	config, files, err := gs.read(commit)
+	if err != nil {
+		gs.recordError(err)
+
+		return nil, err
+	}
+
+	gs.mu.Lock()
+	gs.commit = commit
+	gs.files = files
+	gs.lastSync = time.Now()
+	gs.lastError = nil
+	gs.mu.Unlock()
+
+	if gs.logger != nil {
+		gs.logger.Info("configuration loaded from git",
+			logger.String("url", gs.url),
+			logger.String("commit", commit.String()),
+			logger.Int("files", len(files)),
+		)
+	}
+
+	return config, nil
+}
+
+// Watch starts polling the repository for ref changes.
+func (gs *GitSource) Watch(ctx context.Context, callback func(map[string]any)) error {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if gs.watching {
+		return configcore.ErrConfigError("already watching git repository", nil)
+	}
+
+	if !gs.IsWatchable() {
+		return configcore.ErrConfigError("git watching is not enabled", nil)
+	}
+
+	gs.watchStop = make(chan struct{})
+	gs.watching = true
+
+	go gs.watchLoop(ctx, gs.watchStop, callback)
+
+	if gs.logger != nil {
+		gs.logger.Info("started watching git repository",
+			logger.String("url", gs.url),
+			logger.Duration("interval", gs.options.WatchInterval),
+		)
+	}
+
+	return nil
+}
+
+// StopWatch stops watching the repository.
+func (gs *GitSource) StopWatch() error {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if !gs.watching {
+		return nil
+	}
+
+	if gs.watchStop != nil {
+		close(gs.watchStop)
+		gs.watchStop = nil
+	}
+
+	gs.watching = false
+
+	if gs.logger != nil {
+		gs.logger.Info("stopped watching git repository",
+			logger.String("url", gs.url),
+		)
+	}
+
+	return nil
+}
+
+// Reload forces a fetch and reload of the configuration.
+func (gs *GitSource) Reload(ctx context.Context) error {
+	_, err := gs.Load(ctx)
+
+	return err
+}
+
+// IsWatchable returns true if git watching is enabled.
+func (gs *GitSource) IsWatchable() bool {
+	return gs.options.WatchEnabled
+}
+
+// SupportsSecrets returns false.
+func (gs *GitSource) SupportsSecrets() bool {
+	return false
+}
+
+// GetSecret is not supported by git sources.
+func (gs *GitSource) GetSecret(ctx context.Context, key string) (string, error) {
+	return "", configcore.ErrConfigError("git source does not support secrets", nil)
+}
+
+// GetCommit returns the commit SHA of the last successful load.
+func (gs *GitSource) GetCommit() string {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+
+	if gs.commit.IsZero() {
+		return ""
+	}
+
+	return gs.commit.String()
+}
+
+// ReportMetadata reports the loaded commit and files.
+func (gs *GitSource) ReportMetadata(metadata *configcore.SourceMetadata) {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+
+	if metadata.Properties == nil {
+		metadata.Properties = make(map[string]any)
+	}
+
+	metadata.Properties["url"] = gs.url
+	metadata.Properties["ref"] = gs.ref
+	metadata.Properties["files"] = append([]string(nil), gs.files...)
+
+	if !gs.commit.IsZero() {
+		metadata.Properties["commit"] = gs.commit.String()
+	}
+
+	if !gs.lastSync.IsZero() {
+		metadata.Properties["last_sync"] = gs.lastSync
+	}
+
+	if gs.lastError != nil {
+		metadata.LastError = gs.lastError.Error()
+	}
+}
+
+// sync opens or fetches the repository and resolves the ref to a commit.
+func (gs *GitSource) sync(ctx context.Context) (plumbing.Hash, error) {
+	gs.repoMu.Lock()
+	defer gs.repoMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, gs.options.Timeout)
+	defer cancel()
+
+	if gs.repo == nil {
+		if err := gs.open(ctx); err != nil {
+			return plumbing.ZeroHash, err
+		}
+	} else if gs.cloned {
+		if err := gs.fetch(ctx); err != nil {
+			return plumbing.ZeroHash, err
+		}
+	}
+
+	return gs.resolve()
+}
+
+// open opens a local repository or clones a remote one into memory.
+func (gs *GitSource) open(ctx context.Context) error {
+	if info, err := os.Stat(gs.url); err == nil && info.IsDir() {
+		repo, err := git.PlainOpenWithOptions(gs.url, &git.PlainOpenOptions{DetectDotGit: true})
+		if err != nil {
+			return configcore.ErrConfigError("failed to open git repository "+gs.url, err)
+		}
+
+		gs.repo = repo
+
+		return nil
+	}
+
+	auth, err := gs.auth()
+	if err != nil {
+		return err
+	}
+
+	repo, err := git.CloneContext(ctx, memory.NewStorage(), nil, &git.CloneOptions{
+		URL:   gs.url,
+		Auth:  auth,
+		Depth: gs.options.Depth,
+		Tags:  git.AllTags,
+	})
+	if err != nil {
+		return configcore.ErrConfigError(fmt.Sprintf("failed to clone git repository %s: %v", gs.url, err), err)
+	}
+
+	if gs.currentRef() == "" {
+		// Track the remote default branch rather than the static local copy
+		head, err := repo.Head()
+		if err != nil {
+			return configcore.ErrConfigError("failed to resolve default branch of "+gs.url, err)
+		}
+
+		gs.mu.Lock()
+		gs.ref = head.Name().Short()
+		gs.mu.Unlock()
+	}
+
+	gs.repo = repo
+	gs.cloned = true
+
+	return nil
+}
+
+// currentRef returns the ref, which open fills in with the default branch
+// when none was configured.
+func (gs *GitSource) currentRef() string {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+
+	return gs.ref
+}
+
+// fetch updates remote branches and tags of a cloned repository.
+func (gs *GitSource) fetch(ctx context.Context) error {
+	auth, err := gs.auth()
+	if err != nil {
+		return err
+	}
+
+	err = gs.repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: git.DefaultRemoteName,
+		RefSpecs:   []config.RefSpec{"+refs/heads/*:refs/remotes/origin/*"},
+		Auth:       auth,
+		Depth:      gs.options.Depth,
+		Tags:       git.AllTags,
+		Force:      true,
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return configcore.ErrConfigError(fmt.Sprintf("failed to fetch git repository %s: %v", gs.url, err), err)
+	}
+
+	return nil
+}
+
+// resolve resolves the ref to a commit, preferring remote-tracking branches
+// of cloned repositories so that fetched updates are seen.
+func (gs *GitSource) resolve() (plumbing.Hash, error) {
+	ref := gs.currentRef()
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	var candidates []string
+	if gs.cloned {
+		candidates = append(candidates, "refs/remotes/"+git.DefaultRemoteName+"/"+ref)
+	}
+
+	candidates = append(candidates, ref)
+
+	var lastErr error
+
+	for _, candidate := range candidates {
+		hash, err := gs.repo.ResolveRevision(plumbing.Revision(candidate))
+		if err == nil {
+			return *hash, nil
+		}
+
+		lastErr = err
+	}
+
+	return plumbing.ZeroHash, configcore.ErrConfigError("failed to resolve git ref "+ref, lastErr)
+}
+
+// read loads and merges the configured paths from a commit.
+func (gs *GitSource) read(hash plumbing.Hash) (map[string]any, []string, error) {
+	gs.repoMu.Lock()
+	defer gs.repoMu.Unlock()
+
+	commit, err := gs.repo.CommitObject(hash)
+	if err != nil {
+		return nil, nil, configcore.ErrConfigError("failed to read git commit "+hash.String(), err)
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, nil, configcore.ErrConfigError("failed to read git tree "+hash.String(), err)
+	}
+
+	merger := configcore.NewMergeUtil()
+	result := make(map[string]any)
+
+	var loaded []string
+
+	for _, pattern := range gs.paths {
+		files, err := gs.matchFiles(tree, pattern)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, file := range files {
+			data, err := gs.parseFile(file)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			merger.MergeInPlace(result, data)
+			loaded = append(loaded, file.Name)
+		}
+	}
+
+	return result, loaded, nil
+}
+
+// matchFiles returns the files matching a pattern in lexical order. A
+// literal path that does not exist is an error; an empty glob is not.
+func (gs *GitSource) matchFiles(tree *object.Tree, pattern string) ([]*object.File, error) {
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	if !strings.ContainsAny(pattern, "*?[") {
+		file, err := tree.File(pattern)
+		if err != nil {
+			return nil, configcore.ErrConfigError("git path not found: "+pattern, err)
+		}
+
+		return []*object.File{file}, nil
+	}
+
+	var files []*object.File
+
+	err := tree.Files().ForEach(func(file *object.File) error {
+		if matched, _ := path.Match(pattern, file.Name); matched {
+			files = append(files, file)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, configcore.ErrConfigError("failed to list git tree", err)
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	return files, nil
+}
+
+// parseFile parses a single file from the tree.
+func (gs *GitSource) parseFile(file *object.File) (map[string]any, error) {
+	processor, err := gs.processorFor(file.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := file.Contents()
+	if err != nil {
+		return nil, configcore.ErrConfigError("failed to read git file "+file.Name, err)
+	}
+
+	data, err := processor.Parse([]byte(content))
+	if err != nil {
+		return nil, configcore.ErrConfigError("failed to parse git file "+file.Name, err)
+	}
+
+	if data == nil {
+		data = make(map[string]any)
+	}
+
+	if err := processor.Validate(data); err != nil {
+		return nil, configcore.ErrConfigError("validation failed for git file "+file.Name, err)
+	}
+
+	if gs.options.ExpandEnvVars {
+		data = expandEnvInMap(data)
+	}
+
+	return data, nil
+}
+
+// processorFor returns the processor for a file, honouring the forced format.
+func (gs *GitSource) processorFor(name string) (formats.FormatProcessor, error) {
+	if gs.options.Format != "" {
+		return getFormatProcessor(gs.options.Format)
+	}
+
+	if processor, ok := processorForExtension(strings.ToLower(path.Ext(name))); ok {
+		return processor, nil
+	}
+
+	return nil, configcore.ErrConfigError("cannot detect format of git file "+name, nil)
+}
+
+// auth returns the transport credentials from the options.
+func (gs *GitSource) auth() (transport.AuthMethod, error) {
+	if gs.options.SSHKeyFile != "" {
+		keys, err := gitssh.NewPublicKeysFromFile(gs.options.SSHUser, gs.options.SSHKeyFile, gs.options.SSHKeyPass)
+		if err != nil {
+			return nil, configcore.ErrConfigError("failed to load git ssh key "+gs.options.SSHKeyFile, err)
+		}
+
+		return keys, nil
+	}
+
+	if gs.options.Username != "" || gs.options.Password != "" {
+		return &githttp.BasicAuth{
+			Username: gs.options.Username,
+			Password: gs.options.Password,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// watchLoop polls the repository and reloads when the ref moves.
+func (gs *GitSource) watchLoop(ctx context.Context, stop chan struct{}, callback func(map[string]any)) {
+	defer func() {
+		if r := recover(); r != nil {
+			if gs.logger != nil {
+				gs.logger.Error("panic in git watch loop",
+					logger.String("url", gs.url),
+					logger.Any("panic", r),
+				)
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(gs.options.WatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-ticker.C:
+			gs.checkForChanges(ctx, callback)
+		}
+	}
+}
+
+// checkForChanges fetches the repository and reloads if the ref moved.
+func (gs *GitSource) checkForChanges(ctx context.Context, callback func(map[string]any)) {
+	commit, err := gs.sync(ctx)
+	if err != nil {
+		gs.recordError(err)
+		gs.handleWatchError(err)
+
+		return
+	}
+
+	gs.mu.RLock()
+	previous := gs.commit
+	gs.mu.RUnlock()
+
+	if commit == previous {
+		return
+	}
+
+	config, files, err := gs.read(commit)
+	if err != nil {
+		gs.recordError(err)
+		gs.handleWatchError(err)
+
+		return
+	}
+
+	gs.mu.Lock()
+	gs.commit = commit
+	gs.files = files
+	gs.lastSync = time.Now()
+	gs.lastError = nil
+	gs.mu.Unlock()
+
+	if gs.logger != nil {
+		gs.logger.Info("git ref moved, configuration reloaded",
+			logger.String("url", gs.url),
+			logger.String("previous", previous.String()),
+			logger.String("commit", commit.String()),
+		)
+	}
+
+	if callback != nil {
+		callback(config)
+	}
+}
+
+// recordError stores the last sync error for metadata.
+func (gs *GitSource) recordError(err error) {
+	gs.mu.Lock()
+	gs.lastError = err
+	gs.mu.Unlock()
+}
+
+// handleWatchError handles errors during watching.
+func (gs *GitSource) handleWatchError(err error) {
+	if gs.logger != nil {
+		gs.logger.Error("git watch error",
+			logger.String("url", gs.url),
+			logger.Error(err),
+		)
+	}
+
+	if gs.errorHandler != nil {
+		_ = gs.errorHandler.HandleError(context.Background(), configcore.ErrConfigError("git watch error for "+gs.url, err))
+	}
+}
+
+// GitSourceFactory creates git configuration sources.
+type GitSourceFactory struct {
+	logger       logger.Logger
+	errorHandler errors.ErrorHandler
+}
+
+// NewGitSourceFactory creates a new git source factory.
+func NewGitSourceFactory(logger logger.Logger, errorHandler errors.ErrorHandler) *GitSourceFactory {
+	return &GitSourceFactory{
+		logger:       logger,
+		errorHandler: errorHandler,
+	}
+}
+
+// CreateFromConfig creates a git source from configuration.
+func (factory *GitSourceFactory) CreateFromConfig(config GitSourceConfig) (configcore.ConfigSource, error) {
+	options := GitSourceOptions{
+		Ref:           config.Ref,
+		Paths:         append([]string(nil), config.Paths...),
+		Format:        config.Format,
+		Priority:      config.Priority,
+		Username:      config.Username,
+		Password:      config.Password,
+		SSHKeyFile:    config.SSHKeyFile,
+		SSHKeyPass:    config.SSHKeyPass,
+		SSHUser:       config.SSHUser,
+		Depth:         config.Depth,
+		ExpandEnvVars: config.ExpandEnvVars,
+		WatchEnabled:  config.WatchEnabled,
+		WatchInterval: config.WatchInterval,
+		Timeout:       config.Timeout,
+		Logger:        factory.logger,
+		ErrorHandler:  factory.errorHandler,
+	}
+
+	return NewGitSource(config.URL, options)
+}
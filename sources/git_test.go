@@ -0,0 +1,239 @@
+package sources
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	configcore "github.com/xraph/confy/internal"
+)
+
+// testGitRepo is a repository with a worktree for committing fixtures.
+type testGitRepo struct {
+	t    *testing.T
+	dir  string
+	repo *git.Repository
+}
+
+func newTestGitRepo(t *testing.T) *testGitRepo {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to init repository: %v", err)
+	}
+
+	return &testGitRepo{t: t, dir: dir, repo: repo}
+}
+
+// commit writes files and commits them, returning the commit SHA.
+func (r *testGitRepo) commit(files map[string]string) string {
+	r.t.Helper()
+
+	worktree, err := r.repo.Worktree()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+
+	for name, content := range files {
+		path := filepath.Join(r.dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			r.t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			r.t.Fatal(err)
+		}
+
+		if _, err := worktree.Add(name); err != nil {
+			r.t.Fatal(err)
+		}
+	}
+
+	hash, err := worktree.Commit("update config", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		r.t.Fatal(err)
+	}
+
+	return hash.String()
+}
+
+func TestGitSource_LoadRefs(t *testing.T) {
+	origin := newTestGitRepo(t)
+
+	first := origin.commit(map[string]string{
+		"env/prod/app.yaml":   "server:\n  port: 8080\n",
+		"env/prod/db.json":    `{"database": {"host": "db-1"}}`,
+		"env/staging/app.yml": "server:\n  port: 9090\n",
+	})
+
+	if _, err := origin.repo.CreateTag("v1", plumbing.NewHash(first), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	second := origin.commit(map[string]string{
+		"env/prod/db.json": `{"database": {"host": "db-2"}}`,
+	})
+
+	tests := []struct {
+		name   string
+		ref    string
+		host   string
+		commit string
+	}{
+		{name: "head", ref: "", host: "db-2", commit: second},
+		{name: "branch", ref: "master", host: "db-2", commit: second},
+		{name: "tag", ref: "v1", host: "db-1", commit: first},
+		{name: "commit", ref: first, host: "db-1", commit: first},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := NewGitSource(origin.dir, GitSourceOptions{
+				Ref:   tt.ref,
+				Paths: []string{"env/prod/*"},
+			})
+			if err != nil {
+				t.Fatalf("NewGitSource() error = %v", err)
+			}
+
+			data, err := source.Load(context.Background())
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			database, _ := data["database"].(map[string]any)
+			server, _ := data["server"].(map[string]any)
+
+			if database["host"] != tt.host || server["port"] != 8080 {
+				t.Errorf("Load() = %v, want host %s and prod port", data, tt.host)
+			}
+
+			metadata := &configcore.SourceMetadata{}
+			source.(configcore.SourceMetadataReporter).ReportMetadata(metadata)
+
+			if metadata.Properties["commit"] != tt.commit {
+				t.Errorf("metadata commit = %v, want %s", metadata.Properties["commit"], tt.commit)
+			}
+		})
+	}
+}
+
+func TestGitSource_MissingPath(t *testing.T) {
+	origin := newTestGitRepo(t)
+	origin.commit(map[string]string{"config.yaml": "a: 1\n"})
+
+	source, _ := NewGitSource(origin.dir, GitSourceOptions{Paths: []string{"missing.yaml"}})
+
+	if _, err := source.Load(context.Background()); err == nil {
+		t.Error("Load() should fail for a missing literal path")
+	}
+
+	metadata := &configcore.SourceMetadata{}
+	source.(configcore.SourceMetadataReporter).ReportMetadata(metadata)
+
+	if metadata.LastError == "" {
+		t.Error("metadata should report the load error")
+	}
+}
+
+func TestGitSource_CloneAndWatch(t *testing.T) {
+	// The file transport runs git-upload-pack
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is required for the file transport")
+	}
+
+	origin := newTestGitRepo(t)
+	origin.commit(map[string]string{"config.yaml": "level: info\n"})
+
+	source, err := NewGitSource("file://"+origin.dir, GitSourceOptions{
+		Paths:         []string{"config.yaml"},
+		WatchEnabled:  true,
+		WatchInterval: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewGitSource() error = %v", err)
+	}
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if data["level"] != "info" {
+		t.Fatalf("Load() = %v, want level=info", data)
+	}
+
+	updates := make(chan map[string]any, 4)
+	if err := source.Watch(context.Background(), func(data map[string]any) { updates <- data }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer func() { _ = source.StopWatch() }()
+
+	moved := origin.commit(map[string]string{"config.yaml": "level: debug\n"})
+
+	select {
+	case data := <-updates:
+		if data["level"] != "debug" {
+			t.Errorf("watched data = %v, want level=debug", data)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for git watch update")
+	}
+
+	if commit := source.(*GitSource).GetCommit(); commit != moved {
+		t.Errorf("GetCommit() = %s, want %s", commit, moved)
+	}
+}
+
+func TestGitSource_DefaultBranchConcurrentAccess(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is required for the file transport")
+	}
+
+	origin := newTestGitRepo(t)
+	origin.commit(map[string]string{"config.yaml": "level: info\n"})
+
+	source, err := NewGitSource("file://"+origin.dir, GitSourceOptions{Paths: []string{"config.yaml"}})
+	if err != nil {
+		t.Fatalf("NewGitSource() error = %v", err)
+	}
+
+	// The default branch is filled in by the first Load while metadata is read
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		_, _ = source.Load(context.Background())
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		for range 50 {
+			source.(*GitSource).ReportMetadata(&configcore.SourceMetadata{})
+		}
+	}()
+
+	wg.Wait()
+
+	metadata := &configcore.SourceMetadata{}
+	source.(*GitSource).ReportMetadata(metadata)
+
+	if metadata.Properties["ref"] == "" {
+		t.Error("ref metadata is empty after loading the default branch")
+	}
+}
@@ -0,0 +1,705 @@
+package sources
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xraph/confy/formats"
+	configcore "github.com/xraph/confy/internal"
+	errors "github.com/xraph/go-utils/errs"
+	logger "github.com/xraph/go-utils/log"
+)
+
+// defaultHTTPMaxBodySize bounds the size of a fetched document.
+const defaultHTTPMaxBodySize = 10 << 20
+
+// HTTPSource represents a configuration document served over HTTP(S).
+type HTTPSource struct {
+	name         string
+	url          string
+	priority     int
+	client       *http.Client
+	etag         string
+	lastModified string
+	lastData     map[string]any
+	lastFormat   string
+	watching     bool
+	watchStop    chan struct{}
+	logger       logger.Logger
+	errorHandler errors.ErrorHandler
+	options      HTTPSourceOptions
+	mu           sync.RWMutex
+}
+
+// HTTPSourceOptions contains options for HTTP configuration sources.
+type HTTPSourceOptions struct {
+	Name            string
+	Format          string
+	Priority        int
+	Headers         map[string]string
+	BearerToken     string
+	BearerTokenFile string
+	TLS             *HTTPTLSConfig
+	Timeout         time.Duration
+	WatchEnabled    bool
+	WatchInterval   time.Duration
+	LongPoll        bool
+	LongPollTimeout time.Duration
+	LongPollParam   string
+	RetryDelay      time.Duration
+	MaxRetryDelay   time.Duration
+	MaxBodySize     int64
+	Client          *http.Client
+	Logger          logger.Logger
+	ErrorHandler    errors.ErrorHandler
+}
+
+// HTTPSourceConfig contains configuration for creating HTTP sources.
+type HTTPSourceConfig struct {
+	Name            string            `json:"name"              yaml:"name"`
+	URL             string            `json:"url"               yaml:"url"`
+	Format          string            `json:"format"            yaml:"format"`
+	Priority        int               `json:"priority"          yaml:"priority"`
+	Headers         map[string]string `json:"headers"           yaml:"headers"`
+	BearerToken     string            `json:"bearer_token"      yaml:"bearer_token"`
+	BearerTokenFile string            `json:"bearer_token_file" yaml:"bearer_token_file"`
+	TLS             *HTTPTLSConfig    `json:"tls"               yaml:"tls"`
+	Timeout         time.Duration     `json:"timeout"           yaml:"timeout"`
+	WatchEnabled    bool              `json:"watch_enabled"     yaml:"watch_enabled"`
+	WatchInterval   time.Duration     `json:"watch_interval"    yaml:"watch_interval"`
+	LongPoll        bool              `json:"long_poll"         yaml:"long_poll"`
+	LongPollTimeout time.Duration     `json:"long_poll_timeout" yaml:"long_poll_timeout"`
+	LongPollParam   string            `json:"long_poll_param"   yaml:"long_poll_param"`
+	RetryDelay      time.Duration     `json:"retry_delay"       yaml:"retry_delay"`
+	MaxRetryDelay   time.Duration     `json:"max_retry_delay"   yaml:"max_retry_delay"`
+	MaxBodySize     int64             `json:"max_body_size"     yaml:"max_body_size"`
+}
+
+// HTTPTLSConfig contains TLS configuration for HTTP sources. Setting both
+// CertFile and KeyFile enables mutual TLS.
+type HTTPTLSConfig struct {
+	CertFile           string `json:"cert_file"            yaml:"cert_file"`
+	KeyFile            string `json:"key_file"             yaml:"key_file"`
+	CAFile             string `json:"ca_file"              yaml:"ca_file"`
+	ServerName         string `json:"server_name"          yaml:"server_name"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify" yaml:"insecure_skip_verify"`
+}
+
+// NewHTTPSource creates a new HTTP configuration source.
+//
+// The document format is taken from options.Format, then from the response
+// Content-Type, then from the URL path extension, defaulting to YAML. When
+// watching, the source issues conditional requests (If-None-Match and
+// If-Modified-Since) every WatchInterval, or, with LongPoll enabled, keeps a
+// request open for up to LongPollTimeout by appending LongPollParam (default
+// "wait") to the query. Servers implementing long-poll reply as soon as the
+// document changes and with 304 Not Modified when the wait expires. A
+// long-poll reply that comes back early without a change is treated as a
+// server ignoring the wait, and the next request waits WatchInterval.
+// Responses larger than MaxBodySize (default 10 MiB) are rejected.
+func NewHTTPSource(rawURL string, options HTTPSourceOptions) (configcore.ConfigSource, error) {
+	if rawURL == "" {
+		return nil, configcore.ErrConfigError("HTTP source URL cannot be empty", nil)
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, configcore.ErrConfigError("invalid HTTP source URL: "+rawURL, err)
+	}
+
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, configcore.ErrConfigError("HTTP source URL must use http or https: "+rawURL, nil)
+	}
+
+	if options.Format != "" {
+		if _, err := getFormatProcessor(options.Format); err != nil {
+			return nil, configcore.ErrConfigError("unsupported format: "+options.Format, err)
+		}
+	}
+
+	if options.Timeout == 0 {
+		options.Timeout = 30 * time.Second
+	}
+
+	if options.WatchInterval == 0 {
+		options.WatchInterval = 30 * time.Second
+	}
+
+	if options.LongPollTimeout == 0 {
+		options.LongPollTimeout = 60 * time.Second
+	}
+
+	if options.LongPollParam == "" {
+		options.LongPollParam = "wait"
+	}
+
+	if options.RetryDelay == 0 {
+		options.RetryDelay = time.Second
+	}
+
+	if options.MaxRetryDelay == 0 {
+		options.MaxRetryDelay = 2 * time.Minute
+	}
+
+	if options.MaxBodySize <= 0 {
+		options.MaxBodySize = defaultHTTPMaxBodySize
+	}
+
+	name := options.Name
+	if name == "" {
+		name = "http:" + parsed.Host + parsed.Path
+	}
+
+	client := options.Client
+	if client == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+
+		if options.TLS != nil {
+			tlsConfig, err := buildHTTPTLSConfig(options.TLS)
+			if err != nil {
+				return nil, configcore.ErrConfigError("failed to configure TLS for HTTP source", err)
+			}
+
+			transport.TLSClientConfig = tlsConfig
+		}
+
+		// Per-request timeouts are applied through contexts so that long-poll
+		// requests can outlive the regular timeout.
+		client = &http.Client{Transport: transport}
+	}
+
+	return &HTTPSource{
+		name:         name,
+		url:          rawURL,
+		priority:     options.Priority,
+		client:       client,
+		logger:       options.Logger,
+		errorHandler: options.ErrorHandler,
+		options:      options,
+	}, nil
+}
+
+// Name returns the source name.
+func (hs *HTTPSource) Name() string {
+	return hs.name
+}
+
+// GetName returns the source name (alias for Name).
+func (hs *HTTPSource) GetName() string {
+	return hs.name
+}
+
+// GetType returns the source type.
+func (hs *HTTPSource) GetType() string {
+	return "http"
+}
+
+// IsAvailable checks if the source is available.
+func (hs *HTTPSource) IsAvailable(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, hs.options.Timeout)
+	defer cancel()
+
+	req, err := hs.newRequest(ctx, http.MethodHead, hs.url)
+	if err != nil {
+		return false
+	}
+
+	resp, err := hs.client.Do(req)
+	if err != nil {
+		return false
+	}
+
+	_ = resp.Body.Close()
+
+	return resp.StatusCode < http.StatusInternalServerError
+}
+
+// Priority returns the source priority.
+func (hs *HTTPSource) Priority() int {
+	return hs.priority
+}
+
+// Load fetches the configuration document. Unchanged documents are served
+// from the last successful response.
+func (hs *HTTPSource) Load(ctx context.Context) (map[string]any, error) {
+	if hs.logger != nil {
+		hs.logger.Debug("loading configuration from HTTP",
+			logger.String("url", hs.url),
+		)
+	}
+
+	data, _, err := hs.fetch(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Watch starts polling the URL for changes.
+func (hs *HTTPSource) Watch(ctx context.Context, callback func(map[string]any)) error {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	if hs.watching {
+		return configcore.ErrConfigError("already watching HTTP source", nil)
+	}
+
+	if !hs.IsWatchable() {
+		return configcore.ErrConfigError("HTTP watching is not enabled", nil)
+	}
+
+	hs.watchStop = make(chan struct{})
+	hs.watching = true
+
+	go hs.watchLoop(ctx, hs.watchStop, callback)
+
+	if hs.logger != nil {
+		hs.logger.Info("started watching HTTP source",
+			logger.String("url", hs.url),
+			logger.Bool("long_poll", hs.options.LongPoll),
+		)
+	}
+
+	return nil
+}
+
+// StopWatch stops watching the URL.
+func (hs *HTTPSource) StopWatch() error {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	if !hs.watching {
+		return nil
+	}
+
+	if hs.watchStop != nil {
+		close(hs.watchStop)
+		hs.watchStop = nil
+	}
+
+	hs.watching = false
+
+	if hs.logger != nil {
+		hs.logger.Info("stopped watching HTTP source",
+			logger.String("url", hs.url),
+		)
+	}
+
+	return nil
+}
+
+// Reload forces a reload of the document, bypassing conditional requests.
+func (hs *HTTPSource) Reload(ctx context.Context) error {
+	if hs.logger != nil {
+		hs.logger.Info("reloading HTTP configuration",
+			logger.String("url", hs.url),
+		)
+	}
+
+	hs.mu.Lock()
+	hs.etag = ""
+	hs.lastModified = ""
+	hs.mu.Unlock()
+
+	_, err := hs.Load(ctx)
+
+	return err
+}
+
+// IsWatchable returns true if HTTP watching is enabled.
+func (hs *HTTPSource) IsWatchable() bool {
+	return hs.options.WatchEnabled
+}
+
+// SupportsSecrets returns false; HTTP documents are plain configuration.
+func (hs *HTTPSource) SupportsSecrets() bool {
+	return false
+}
+
+// GetSecret is not supported by HTTP sources.
+func (hs *HTTPSource) GetSecret(ctx context.Context, key string) (string, error) {
+	return "", configcore.ErrConfigError("HTTP source does not support secrets: "+key, nil)
+}
+
+// GetURL returns the document URL.
+func (hs *HTTPSource) GetURL() string {
+	return hs.url
+}
+
+// GetETag returns the entity tag of the last successful response.
+func (hs *HTTPSource) GetETag() string {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+
+	return hs.etag
+}
+
+// GetFormat returns the format of the last parsed document.
+func (hs *HTTPSource) GetFormat() string {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+
+	return hs.lastFormat
+}
+
+// fetch performs a conditional GET. With wait > 0 it issues a long-poll
+// request. The returned flag reports whether the parsed document differs
+// from the last one, so servers without validators do not report a change
+// on every poll.
+func (hs *HTTPSource) fetch(ctx context.Context, wait time.Duration) (map[string]any, bool, error) {
+	target := hs.url
+	timeout := hs.options.Timeout
+
+	if wait > 0 {
+		parsed, err := url.Parse(hs.url)
+		if err != nil {
+			return nil, false, configcore.ErrConfigError("invalid HTTP source URL: "+hs.url, err)
+		}
+
+		query := parsed.Query()
+		query.Set(hs.options.LongPollParam, wait.String())
+		parsed.RawQuery = query.Encode()
+		target = parsed.String()
+		timeout += wait
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := hs.newRequest(ctx, http.MethodGet, target)
+	if err != nil {
+		return nil, false, err
+	}
+
+	hs.mu.RLock()
+	etag, lastModified, lastData := hs.etag, hs.lastModified, hs.lastData
+	hs.mu.RUnlock()
+
+	if lastData != nil {
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+
+		if lastModified != "" {
+			req.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+
+	resp, err := hs.client.Do(req)
+	if err != nil {
+		return nil, false, configcore.ErrConfigError("failed to fetch "+hs.url, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotModified && lastData != nil {
+		return copyConfigMap(lastData), false, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+		return nil, false, configcore.ErrConfigError(fmt.Sprintf("unexpected HTTP status %d from %s", resp.StatusCode, hs.url), nil)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, hs.options.MaxBodySize+1))
+	if err != nil {
+		return nil, false, configcore.ErrConfigError("failed to read response from "+hs.url, err)
+	}
+
+	if int64(len(body)) > hs.options.MaxBodySize {
+		return nil, false, configcore.ErrConfigError(fmt.Sprintf("response from %s exceeds %d bytes", hs.url, hs.options.MaxBodySize), nil)
+	}
+
+	processor, err := hs.detectProcessor(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, false, err
+	}
+
+	data, err := processor.Parse(body)
+	if err != nil {
+		return nil, false, configcore.ErrConfigError("failed to parse response from "+hs.url, err)
+	}
+
+	changed := lastData == nil || !reflect.DeepEqual(lastData, data)
+
+	hs.mu.Lock()
+	hs.etag = resp.Header.Get("ETag")
+	hs.lastModified = resp.Header.Get("Last-Modified")
+	hs.lastData = data
+	hs.lastFormat = processor.Name()
+	hs.mu.Unlock()
+
+	if hs.logger != nil {
+		hs.logger.Info("configuration loaded from HTTP",
+			logger.String("url", hs.url),
+			logger.String("format", processor.Name()),
+			logger.Int("keys", len(data)),
+			logger.Int("size", len(body)),
+		)
+	}
+
+	return copyConfigMap(data), changed, nil
+}
+
+// newRequest builds a request carrying the configured headers and credentials.
+func (hs *HTTPSource) newRequest(ctx context.Context, method, target string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return nil, configcore.ErrConfigError("failed to create HTTP request for "+target, err)
+	}
+
+	req.Header.Set("Accept", "application/json, application/yaml, application/toml;q=0.9, */*;q=0.5")
+
+	for key, value := range hs.options.Headers {
+		req.Header.Set(key, value)
+	}
+
+	token := hs.options.BearerToken
+	if hs.options.BearerTokenFile != "" {
+		// Re-read on every request so rotated tokens are picked up.
+		content, err := os.ReadFile(hs.options.BearerTokenFile)
+		if err != nil {
+			return nil, configcore.ErrConfigError("failed to read bearer token file "+hs.options.BearerTokenFile, err)
+		}
+
+		token = strings.TrimSpace(string(content))
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return req, nil
+}
+
+// detectProcessor selects a format processor for a response.
+func (hs *HTTPSource) detectProcessor(contentType string) (formats.FormatProcessor, error) {
+	if hs.options.Format != "" {
+		return getFormatProcessor(hs.options.Format)
+	}
+
+	if contentType != "" {
+		if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+			switch {
+			case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+				return getFormatProcessor("json")
+			case strings.Contains(mediaType, "yaml"):
+				return getFormatProcessor("yaml")
+			case strings.Contains(mediaType, "toml"):
+				return getFormatProcessor("toml")
+			}
+		}
+	}
+
+	if parsed, err := url.Parse(hs.url); err == nil {
+		if processor, ok := processorForExtension(path.Ext(parsed.Path)); ok {
+			return processor, nil
+		}
+	}
+
+	return getFormatProcessor("yaml")
+}
+
+// watchLoop polls or long-polls the URL until stopped.
+func (hs *HTTPSource) watchLoop(ctx context.Context, stop chan struct{}, callback func(map[string]any)) {
+	defer func() {
+		if r := recover(); r != nil {
+			if hs.logger != nil {
+				hs.logger.Error("panic in HTTP watch loop",
+					logger.String("url", hs.url),
+					logger.Any("panic", r),
+				)
+			}
+		}
+	}()
+
+	failures := 0
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		default:
+		}
+
+		var wait time.Duration
+		if hs.options.LongPoll {
+			wait = hs.options.LongPollTimeout
+		}
+
+		started := time.Now()
+		data, changed, err := hs.fetch(ctx, wait)
+
+		delay := hs.options.WatchInterval
+		if hs.options.LongPoll && (changed || time.Since(started) >= wait/2) {
+			// A server that ignores the wait answers at once; poll it instead
+			delay = 0
+		}
+
+		if err != nil {
+			failures++
+			delay = backoffDelay(failures, hs.options.RetryDelay, hs.options.MaxRetryDelay)
+
+			hs.handleWatchError(err, failures, delay)
+		} else {
+			failures = 0
+
+			if changed && callback != nil {
+				if hs.logger != nil {
+					hs.logger.Info("HTTP configuration change detected",
+						logger.String("url", hs.url),
+						logger.String("etag", hs.GetETag()),
+					)
+				}
+
+				callback(data)
+			}
+		}
+
+		if delay > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-stop:
+				return
+			case <-time.After(delay):
+			}
+		}
+	}
+}
+
+// handleWatchError handles errors during watching. The watch keeps running
+// and retries with backoff.
+func (hs *HTTPSource) handleWatchError(err error, attempt int, retryIn time.Duration) {
+	if hs.logger != nil {
+		hs.logger.Warn("HTTP watch error, retrying",
+			logger.String("url", hs.url),
+			logger.Int("attempt", attempt),
+			logger.Duration("retry_in", retryIn),
+			logger.Error(err),
+		)
+	}
+
+	if hs.errorHandler != nil {
+		_ = hs.errorHandler.HandleError(context.Background(), configcore.ErrConfigError("HTTP watch error for "+hs.url, err))
+	}
+}
+
+// buildHTTPTLSConfig creates a TLS configuration from HTTPTLSConfig.
+func buildHTTPTLSConfig(config *HTTPTLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         config.ServerName,
+		InsecureSkipVerify: config.InsecureSkipVerify, //nolint:gosec // G402: explicitly opted in by configuration
+	}
+
+	if config.CAFile != "" {
+		caPEM, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file %s: %w", config.CAFile, err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", config.CAFile)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.CertFile != "" || config.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// copyConfigMap returns a deep copy of configuration data so that cached
+// results cannot be mutated by callers.
+func copyConfigMap(data map[string]any) map[string]any {
+	result := make(map[string]any, len(data))
+
+	for key, value := range data {
+		result[key] = copyConfigValue(value)
+	}
+
+	return result
+}
+
+// copyConfigValue deep copies maps and slices within configuration data.
+func copyConfigValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		return copyConfigMap(v)
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = copyConfigValue(item)
+		}
+
+		return result
+	default:
+		return value
+	}
+}
+
+// HTTPSourceFactory creates HTTP configuration sources.
+type HTTPSourceFactory struct {
+	logger       logger.Logger
+	errorHandler errors.ErrorHandler
+}
+
+// NewHTTPSourceFactory creates a new HTTP source factory.
+func NewHTTPSourceFactory(logger logger.Logger, errorHandler errors.ErrorHandler) *HTTPSourceFactory {
+	return &HTTPSourceFactory{
+		logger:       logger,
+		errorHandler: errorHandler,
+	}
+}
+
+// CreateFromConfig creates an HTTP source from configuration.
+func (factory *HTTPSourceFactory) CreateFromConfig(config HTTPSourceConfig) (configcore.ConfigSource, error) {
+	options := HTTPSourceOptions{
+		Name:            config.Name,
+		Format:          config.Format,
+		Priority:        config.Priority,
+		Headers:         maps.Clone(config.Headers),
+		BearerToken:     config.BearerToken,
+		BearerTokenFile: config.BearerTokenFile,
+		TLS:             config.TLS,
+		Timeout:         config.Timeout,
+		WatchEnabled:    config.WatchEnabled,
+		WatchInterval:   config.WatchInterval,
+		LongPoll:        config.LongPoll,
+		LongPollTimeout: config.LongPollTimeout,
+		LongPollParam:   config.LongPollParam,
+		RetryDelay:      config.RetryDelay,
+		MaxRetryDelay:   config.MaxRetryDelay,
+		MaxBodySize:     config.MaxBodySize,
+		Logger:          factory.logger,
+		ErrorHandler:    factory.errorHandler,
+	}
+
+	return NewHTTPSource(config.URL, options)
+}
@@ -0,0 +1,476 @@
+package sources
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// configServer serves a mutable document with ETag support.
+type configServer struct {
+	mu          sync.Mutex
+	body        string
+	contentType string
+	version     int
+	changed     chan struct{}
+	requests    atomic.Int32
+	failures    atomic.Int32
+	lastHeaders http.Header
+}
+
+func newConfigServer(body, contentType string) *configServer {
+	return &configServer{body: body, contentType: contentType, version: 1, changed: make(chan struct{})}
+}
+
+func (s *configServer) set(body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.body = body
+	s.version++
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *configServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests.Add(1)
+
+	if s.failures.Load() > 0 {
+		s.failures.Add(-1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+
+		return
+	}
+
+	s.mu.Lock()
+	s.lastHeaders = r.Header.Clone()
+	etag := fmt.Sprintf(`"v%d"`, s.version)
+	changed := s.changed
+	s.mu.Unlock()
+
+	if r.Header.Get("If-None-Match") == etag {
+		if wait, err := time.ParseDuration(r.URL.Query().Get("wait")); err == nil {
+			select {
+			case <-changed:
+			case <-time.After(wait):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		s.mu.Lock()
+		etag = fmt.Sprintf(`"v%d"`, s.version)
+		s.mu.Unlock()
+
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+	}
+
+	s.mu.Lock()
+	body, contentType := s.body, s.contentType
+	s.mu.Unlock()
+
+	w.Header().Set("ETag", etag)
+
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+
+	_, _ = w.Write([]byte(body))
+}
+
+func (s *configServer) headers() http.Header {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lastHeaders
+}
+
+func TestHTTPSource_Load(t *testing.T) {
+	server := newConfigServer(`{"server":{"port":8080}}`, "application/json")
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	source, err := NewHTTPSource(ts.URL+"/config", HTTPSourceOptions{Priority: 10})
+	if err != nil {
+		t.Fatalf("NewHTTPSource() error = %v", err)
+	}
+
+	if source.GetType() != "http" {
+		t.Errorf("GetType() = %v, want http", source.GetType())
+	}
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	serverCfg, ok := data["server"].(map[string]any)
+	if !ok || serverCfg["port"] != float64(8080) {
+		t.Errorf("Load() server = %v, want port 8080", data["server"])
+	}
+
+	if got := source.(*HTTPSource).GetFormat(); got != "json" {
+		t.Errorf("GetFormat() = %v, want json", got)
+	}
+
+	// Second load is conditional and served from the cached document.
+	data, err = source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if server.headers().Get("If-None-Match") != `"v1"` {
+		t.Errorf("If-None-Match = %q, want \"v1\"", server.headers().Get("If-None-Match"))
+	}
+
+	if _, ok := data["server"].(map[string]any); !ok {
+		t.Errorf("cached Load() = %v, want server section", data)
+	}
+}
+
+func TestHTTPSource_FormatDetection(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		format      string
+		want        string
+	}{
+		{name: "yaml content type", path: "/cfg", contentType: "application/yaml", body: "key: value\n", want: "yaml"},
+		{name: "toml content type", path: "/cfg", contentType: "application/toml", body: "key = \"value\"\n", want: "toml"},
+		{name: "json suffix content type", path: "/cfg", contentType: "application/vnd.app+json; charset=utf-8", body: `{"key":"value"}`, want: "json"},
+		{name: "extension fallback", path: "/cfg.toml", contentType: "text/plain", body: "key = \"value\"\n", want: "toml"},
+		{name: "explicit format", path: "/cfg.json", contentType: "application/json", body: "key: value\n", format: "yaml", want: "yaml"},
+		{name: "default yaml", path: "/cfg", body: "key: value\n", want: "yaml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				} else {
+					w.Header()["Content-Type"] = nil
+				}
+
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer ts.Close()
+
+			source, err := NewHTTPSource(ts.URL+tt.path, HTTPSourceOptions{Format: tt.format})
+			if err != nil {
+				t.Fatalf("NewHTTPSource() error = %v", err)
+			}
+
+			data, err := source.Load(context.Background())
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if data["key"] != "value" {
+				t.Errorf("Load() key = %v, want value", data["key"])
+			}
+
+			if got := source.(*HTTPSource).GetFormat(); got != tt.want {
+				t.Errorf("GetFormat() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPSource_HeadersAndBearerToken(t *testing.T) {
+	server := newConfigServer("key: value\n", "application/yaml")
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	_ = os.WriteFile(tokenFile, []byte("first\n"), 0600)
+
+	source, err := NewHTTPSource(ts.URL, HTTPSourceOptions{
+		Headers:         map[string]string{"X-Team": "payments"},
+		BearerTokenFile: tokenFile,
+	})
+	if err != nil {
+		t.Fatalf("NewHTTPSource() error = %v", err)
+	}
+
+	if _, err := source.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if got := server.headers().Get("X-Team"); got != "payments" {
+		t.Errorf("X-Team = %q, want payments", got)
+	}
+
+	if got := server.headers().Get("Authorization"); got != "Bearer first" {
+		t.Errorf("Authorization = %q, want Bearer first", got)
+	}
+
+	// Rotated tokens are picked up on the next request.
+	_ = os.WriteFile(tokenFile, []byte("second"), 0600)
+
+	if err := source.Reload(context.Background()); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	if got := server.headers().Get("Authorization"); got != "Bearer second" {
+		t.Errorf("Authorization = %q, want Bearer second", got)
+	}
+}
+
+func TestHTTPSource_TLS(t *testing.T) {
+	server := newConfigServer("key: secure\n", "application/yaml")
+	ts := httptest.NewTLSServer(server)
+	defer ts.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	_ = os.WriteFile(caFile, caPEM, 0600)
+
+	source, err := NewHTTPSource(ts.URL, HTTPSourceOptions{
+		TLS: &HTTPTLSConfig{CAFile: caFile},
+	})
+	if err != nil {
+		t.Fatalf("NewHTTPSource() error = %v", err)
+	}
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if data["key"] != "secure" {
+		t.Errorf("Load() key = %v, want secure", data["key"])
+	}
+
+	// Without the CA the certificate is rejected.
+	untrusted, _ := NewHTTPSource(ts.URL, HTTPSourceOptions{})
+	if _, err := untrusted.Load(context.Background()); err == nil {
+		t.Error("Load() without CA should fail")
+	}
+
+	if _, err := NewHTTPSource(ts.URL, HTTPSourceOptions{
+		TLS: &HTTPTLSConfig{CertFile: "missing.pem", KeyFile: "missing.key"},
+	}); err == nil {
+		t.Error("NewHTTPSource() with missing client certificate should fail")
+	}
+}
+
+func TestHTTPSource_WatchPolling(t *testing.T) {
+	server := newConfigServer("key: one\n", "application/yaml")
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	source, err := NewHTTPSource(ts.URL, HTTPSourceOptions{
+		WatchEnabled:  true,
+		WatchInterval: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewHTTPSource() error = %v", err)
+	}
+
+	if _, err := source.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	updates := make(chan map[string]any, 4)
+	if err := source.Watch(context.Background(), func(data map[string]any) { updates <- data }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer func() { _ = source.StopWatch() }()
+
+	if err := source.Watch(context.Background(), nil); err == nil {
+		t.Error("second Watch() should fail")
+	}
+
+	server.set("key: two\n")
+
+	select {
+	case data := <-updates:
+		if data["key"] != "two" {
+			t.Errorf("watch data key = %v, want two", data["key"])
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for change")
+	}
+}
+
+func TestHTTPSource_WatchLongPoll(t *testing.T) {
+	server := newConfigServer("key: one\n", "application/yaml")
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	source, err := NewHTTPSource(ts.URL, HTTPSourceOptions{
+		WatchEnabled:    true,
+		LongPoll:        true,
+		LongPollTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewHTTPSource() error = %v", err)
+	}
+
+	if _, err := source.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	updates := make(chan map[string]any, 4)
+	if err := source.Watch(context.Background(), func(data map[string]any) { updates <- data }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer func() { _ = source.StopWatch() }()
+
+	time.Sleep(50 * time.Millisecond)
+
+	requestsBefore := server.requests.Load()
+
+	server.set("key: two\n")
+
+	select {
+	case data := <-updates:
+		if data["key"] != "two" {
+			t.Errorf("watch data key = %v, want two", data["key"])
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for long-poll change")
+	}
+
+	// The change is delivered by the pending request, not by a new poll.
+	if got := server.requests.Load() - requestsBefore; got > 1 {
+		t.Errorf("requests after change = %d, want at most 1", got)
+	}
+}
+
+func TestHTTPSource_WatchWithoutValidators(t *testing.T) {
+	var requests atomic.Int32
+
+	// No ETag or Last-Modified, and the wait parameter is ignored.
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/yaml")
+		_, _ = w.Write([]byte("key: one\n"))
+	}))
+	defer ts.Close()
+
+	source, err := NewHTTPSource(ts.URL, HTTPSourceOptions{
+		WatchEnabled:    true,
+		WatchInterval:   50 * time.Millisecond,
+		LongPoll:        true,
+		LongPollTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewHTTPSource() error = %v", err)
+	}
+
+	if _, err := source.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	var callbacks atomic.Int32
+	if err := source.Watch(context.Background(), func(map[string]any) { callbacks.Add(1) }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	_ = source.StopWatch()
+
+	if got := callbacks.Load(); got != 0 {
+		t.Errorf("callbacks = %d, want none for an unchanged document", got)
+	}
+
+	if got := requests.Load(); got > 10 {
+		t.Errorf("requests = %d, want the watch to back off to WatchInterval", got)
+	}
+}
+
+func TestHTTPSource_WatchBackoff(t *testing.T) {
+	server := newConfigServer("key: one\n", "application/yaml")
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	source, err := NewHTTPSource(ts.URL, HTTPSourceOptions{
+		WatchEnabled:  true,
+		WatchInterval: 10 * time.Millisecond,
+		RetryDelay:    10 * time.Millisecond,
+		MaxRetryDelay: 40 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewHTTPSource() error = %v", err)
+	}
+
+	server.failures.Store(3)
+
+	updates := make(chan map[string]any, 4)
+	if err := source.Watch(context.Background(), func(data map[string]any) { updates <- data }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer func() { _ = source.StopWatch() }()
+
+	select {
+	case data := <-updates:
+		if data["key"] != "one" {
+			t.Errorf("watch data key = %v, want one", data["key"])
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("watch did not recover after errors")
+	}
+}
+
+func TestHTTPSource_Errors(t *testing.T) {
+	if _, err := NewHTTPSource("", HTTPSourceOptions{}); err == nil {
+		t.Error("NewHTTPSource() with empty URL should fail")
+	}
+
+	if _, err := NewHTTPSource("ftp://example.com/config", HTTPSourceOptions{}); err == nil {
+		t.Error("NewHTTPSource() with ftp URL should fail")
+	}
+
+	if _, err := NewHTTPSource("http://example.com", HTTPSourceOptions{Format: "ini"}); err == nil {
+		t.Error("NewHTTPSource() with unsupported format should fail")
+	}
+
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	source, _ := NewHTTPSource(ts.URL, HTTPSourceOptions{})
+	if _, err := source.Load(context.Background()); err == nil {
+		t.Error("Load() on 404 should fail")
+	}
+
+	if !source.IsAvailable(context.Background()) {
+		t.Error("IsAvailable() should be true for a reachable server")
+	}
+
+	large := httptest.NewServer(newConfigServer("key: "+strings.Repeat("x", 64)+"\n", "application/yaml"))
+	defer large.Close()
+
+	source, _ = NewHTTPSource(large.URL, HTTPSourceOptions{MaxBodySize: 32})
+	if _, err := source.Load(context.Background()); err == nil || !strings.Contains(err.Error(), "exceeds 32 bytes") {
+		t.Errorf("Load() of an oversized body error = %v, want size limit error", err)
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	base := 100 * time.Millisecond
+	limit := time.Second
+
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 400 * time.Millisecond, 10: time.Second} {
+		got := backoffDelay(attempt, base, limit)
+		if got > want || got < want*4/5 {
+			t.Errorf("backoffDelay(%d) = %v, want within 20%% below %v", attempt, got, want)
+		}
+	}
+}
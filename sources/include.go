@@ -0,0 +1,437 @@
+package sources
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/xraph/confy/formats"
+	configcore "github.com/xraph/confy/internal"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// includeKey pulls other files into the map that contains it:
+	//
+	//	$include: ["base.yaml", "db/*.yaml"]
+	includeKey = "$include"
+
+	// includeTag is the YAML tag form, which replaces a value with the
+	// content of the named file:
+	//
+	//	database: !include db.yaml
+	includeTag = "!include"
+
+	// maxIncludeDepth bounds nesting so symlink aliases cannot recurse forever.
+	maxIncludeDepth = 32
+)
+
+// includeResolver expands include directives for a FileSource. Every read
+// goes through an os.Root, so includes cannot escape the include root.
+type includeResolver struct {
+	root     *os.Root
+	rootDir  string
+	fallback formats.FormatProcessor
+	merge    *configcore.MergeUtil
+	stack    []string
+	files    map[string]fileFingerprint
+	patterns []string
+}
+
+// resolveIncludes expands include directives in data, which was parsed from
+// the file at resolvedPath. Included files are merged in the order they are
+// listed (glob matches in lexical order) and the including map is merged on
+// top, so its own keys always win.
+func (fs *FileSource) resolveIncludes(resolvedPath string, data map[string]any) (map[string]any, error) {
+	file, err := filepath.Abs(resolvedPath)
+	if err != nil {
+		return nil, err
+	}
+
+	rootDir := filepath.Dir(file)
+	if fs.options.IncludeRoot != "" {
+		if rootDir, err = includeRootDir(fs.options.IncludeRoot); err != nil {
+			return nil, err
+		}
+	}
+
+	rel, err := filepath.Rel(rootDir, file)
+	if err != nil || !filepath.IsLocal(rel) {
+		return nil, configcore.ErrConfigError("file "+file+" is outside include root "+rootDir, err)
+	}
+
+	root, err := os.OpenRoot(rootDir)
+	if err != nil {
+		return nil, configcore.ErrConfigError("failed to open include root "+rootDir, err)
+	}
+	defer func() { _ = root.Close() }()
+
+	resolver := &includeResolver{
+		root:     root,
+		rootDir:  rootDir,
+		fallback: fs.processor,
+		merge:    configcore.NewMergeUtil(),
+		stack:    []string{rel},
+		files:    make(map[string]fileFingerprint),
+	}
+
+	resolved, err := resolver.resolve(data, filepath.Dir(rel))
+	if err != nil {
+		return nil, err
+	}
+
+	fs.mu.Lock()
+	fs.includeFiles = resolver.files
+	fs.includePaths = resolver.patterns
+	fs.mu.Unlock()
+
+	return resolved, nil
+}
+
+// resolve expands the include directives in data. dir is the root-relative
+// directory of the file data came from.
+func (r *includeResolver) resolve(data map[string]any, dir string) (map[string]any, error) {
+	own := make(map[string]any, len(data))
+
+	for key, value := range data {
+		if key == includeKey {
+			continue
+		}
+
+		resolved, err := r.resolveValue(value, dir)
+		if err != nil {
+			return nil, err
+		}
+
+		own[key] = resolved
+	}
+
+	raw, ok := data[includeKey]
+	if !ok {
+		return own, nil
+	}
+
+	specs, err := includeSpecs(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make(map[string]any)
+
+	for _, spec := range specs {
+		files, err := r.expand(spec, dir)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			included, err := r.load(file)
+			if err != nil {
+				return nil, err
+			}
+
+			r.merge.MergeInPlace(merged, included)
+		}
+	}
+
+	r.merge.MergeInPlace(merged, own)
+
+	return merged, nil
+}
+
+// resolveValue expands include directives in maps nested inside value.
+func (r *includeResolver) resolveValue(value any, dir string) (any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		return r.resolve(v, dir)
+	case []any:
+		result := make([]any, len(v))
+
+		for i, item := range v {
+			resolved, err := r.resolveValue(item, dir)
+			if err != nil {
+				return nil, err
+			}
+
+			result[i] = resolved
+		}
+
+		return result, nil
+	default:
+		return value, nil
+	}
+}
+
+// expand turns an include spec into root-relative file paths. Literal paths
+// must exist; glob patterns may match nothing.
+func (r *includeResolver) expand(spec, dir string) ([]string, error) {
+	path := filepath.FromSlash(spec)
+
+	rel := filepath.Join(dir, path)
+	if filepath.IsAbs(path) {
+		var err error
+		if rel, err = filepath.Rel(r.rootDir, path); err != nil {
+			rel = path
+		}
+	}
+
+	if !filepath.IsLocal(rel) {
+		return nil, configcore.ErrConfigError("include "+spec+" escapes include root "+r.rootDir, nil)
+	}
+
+	r.patterns = append(r.patterns, filepath.Join(r.rootDir, rel))
+
+	if !hasGlobMeta(rel) {
+		return []string{rel}, nil
+	}
+
+	matches, err := fs.Glob(r.root.FS(), filepath.ToSlash(rel))
+	if err != nil {
+		return nil, configcore.ErrConfigError("invalid include pattern "+spec, err)
+	}
+
+	files := make([]string, 0, len(matches))
+
+	for _, match := range matches {
+		match = filepath.FromSlash(match)
+		if info, err := r.root.Stat(match); err == nil && !info.IsDir() {
+			files = append(files, match)
+		}
+	}
+
+	return files, nil
+}
+
+// load reads, parses and resolves a root-relative include.
+func (r *includeResolver) load(rel string) (map[string]any, error) {
+	if slices.Contains(r.stack, rel) {
+		chain := strings.Join(append(slices.Clone(r.stack), rel), " -> ")
+
+		return nil, configcore.ErrConfigError("include cycle: "+chain, nil)
+	}
+
+	if len(r.stack) >= maxIncludeDepth {
+		return nil, configcore.ErrConfigError("includes nested too deeply at "+rel, nil)
+	}
+
+	content, err := r.root.ReadFile(rel)
+	if err != nil {
+		return nil, configcore.ErrConfigError("failed to read include "+rel, err)
+	}
+
+	file := filepath.Join(r.rootDir, rel)
+	if fingerprint, err := statFingerprint(file); err == nil {
+		fingerprint.hash = sha256.Sum256(content)
+		r.files[file] = fingerprint
+	}
+
+	processor := r.fallback
+	if p, ok := processorForExtension(filepath.Ext(rel)); ok {
+		processor = p
+	}
+
+	if processor.Name() == "yaml" {
+		if content, err = rewriteIncludeTags(content); err != nil {
+			return nil, configcore.ErrConfigError("failed to expand include tags in "+rel, err)
+		}
+	}
+
+	data, err := processor.Parse(content)
+	if err != nil {
+		return nil, configcore.ErrConfigError("failed to parse include "+rel, err)
+	}
+
+	r.stack = append(r.stack, rel)
+	defer func() { r.stack = r.stack[:len(r.stack)-1] }()
+
+	return r.resolve(data, filepath.Dir(rel))
+}
+
+// includeSpecs normalises the value of an $include key.
+func includeSpecs(raw any) ([]string, error) {
+	switch v := raw.(type) {
+	case string:
+		return []string{v}, nil
+	case []string:
+		return v, nil
+	case []any:
+		specs := make([]string, 0, len(v))
+
+		for _, item := range v {
+			spec, ok := item.(string)
+			if !ok {
+				return nil, configcore.ErrConfigError(includeKey+" entries must be strings", nil)
+			}
+
+			specs = append(specs, spec)
+		}
+
+		return specs, nil
+	default:
+		return nil, configcore.ErrConfigError(includeKey+" must be a string or a list of strings", nil)
+	}
+}
+
+// includeRootDir returns the absolute, symlink-free form of an include root.
+func includeRootDir(dir string) (string, error) {
+	if expanded, err := expandPath(dir); err == nil {
+		dir = expanded
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", configcore.ErrConfigError("invalid include root "+dir, err)
+	}
+
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", configcore.ErrConfigError("failed to resolve include root "+dir, err)
+	}
+
+	return resolved, nil
+}
+
+// rewriteIncludeTags turns `key: !include path` into `key: {$include: path}`
+// so YAML files can use either form.
+func rewriteIncludeTags(content []byte) ([]byte, error) {
+	if !bytes.Contains(content, []byte(includeTag)) {
+		return content, nil
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		// Leave syntax errors to the format processor
+		return content, nil
+	}
+
+	if !replaceIncludeTags(&document) {
+		return content, nil
+	}
+
+	return yaml.Marshal(&document)
+}
+
+// replaceIncludeTags rewrites !include nodes in place and reports whether any
+// were found.
+func replaceIncludeTags(node *yaml.Node) bool {
+	if node.Tag == includeTag {
+		value := *node
+		value.Tag = ""
+
+		if value.Kind == yaml.ScalarNode {
+			value.Tag = "!!str"
+		}
+
+		*node = yaml.Node{
+			Kind: yaml.MappingNode,
+			Tag:  "!!map",
+			Content: []*yaml.Node{
+				{Kind: yaml.ScalarNode, Tag: "!!str", Value: includeKey},
+				&value,
+			},
+		}
+
+		return true
+	}
+
+	changed := false
+
+	for _, child := range node.Content {
+		if replaceIncludeTags(child) {
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// hasGlobMeta reports whether path contains glob metacharacters.
+func hasGlobMeta(path string) bool {
+	return strings.ContainsAny(path, `*?[\`)
+}
+
+// watchDirectories lists the directories a FileSource must watch: those of
+// the file, its symlink target, every included file and every include
+// pattern without wildcards in its directory part.
+func watchDirectories(path, resolved string, includes map[string]fileFingerprint, patterns []string) []string {
+	dirs := []string{filepath.Dir(path)}
+	if resolved != "" {
+		dirs = append(dirs, filepath.Dir(resolved))
+	}
+
+	for file, fingerprint := range includes {
+		dirs = append(dirs, filepath.Dir(file), filepath.Dir(fingerprint.resolved))
+	}
+
+	for _, pattern := range patterns {
+		if dir := filepath.Dir(pattern); !hasGlobMeta(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	dirs = uniqueStrings(dirs...)
+	slices.Sort(dirs)
+
+	return dirs
+}
+
+// includesChanged reports whether any included file changed, disappeared or,
+// when force is set, whether an include pattern now matches a new file.
+// Without force only size and mtime are compared.
+func includesChanged(includes map[string]fileFingerprint, patterns []string, force bool) bool {
+	for file, previous := range includes {
+		current, err := statFingerprint(file)
+		if err != nil {
+			return true
+		}
+
+		if !force && current.sameStat(previous) {
+			continue
+		}
+
+		current, err = readFingerprint(file)
+		if err != nil || current.hash != previous.hash {
+			return true
+		}
+	}
+
+	if !force {
+		return false
+	}
+
+	for _, pattern := range patterns {
+		matches, _ := filepath.Glob(pattern)
+
+		for _, match := range matches {
+			if _, known := includes[match]; known {
+				continue
+			}
+
+			if info, err := os.Stat(match); err == nil && !info.IsDir() {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// matchesInclude reports whether name is an included file or matches an
+// include pattern.
+func matchesInclude(name string, includes map[string]fileFingerprint, patterns []string) bool {
+	if _, ok := includes[name]; ok {
+		return true
+	}
+
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
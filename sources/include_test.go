@@ -0,0 +1,237 @@
+package sources
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// =============================================================================
+// INCLUDE DIRECTIVE TESTS
+// =============================================================================
+
+func writeIncludeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+}
+
+func TestFileSource_Includes(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeIncludeFiles(t, tmpDir, map[string]string{
+		"config.yaml": `$include: ["base.yaml", "db/*.yaml"]
+name: service
+log:
+  level: debug
+cache: !include cache.json
+`,
+		"base.yaml": `name: base
+log:
+  level: info
+  format: json
+`,
+		"db/a.yaml":  "database:\n  host: a\n  port: 5432\n",
+		"db/b.yaml":  "database:\n  host: b\n",
+		"cache.json": `{"ttl": "5m", "$include": "cache-defaults.yaml"}`,
+		"cache-defaults.yaml": `ttl: 1m
+size: 100
+`,
+	})
+
+	source, err := NewFileSource(filepath.Join(tmpDir, "config.yaml"), FileSourceOptions{ResolveIncludes: true})
+	if err != nil {
+		t.Fatalf("NewFileSource() error = %v", err)
+	}
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if data["name"] != "service" {
+		t.Errorf("name = %v, want the including file to win", data["name"])
+	}
+
+	if _, ok := data[includeKey]; ok {
+		t.Errorf("%s should not be left in the result", includeKey)
+	}
+
+	log, _ := data["log"].(map[string]any)
+	if log["level"] != "debug" || log["format"] != "json" {
+		t.Errorf("log = %v, want level debug merged over base format json", log)
+	}
+
+	database, _ := data["database"].(map[string]any)
+	if database["host"] != "b" || database["port"] != 5432 {
+		t.Errorf("database = %v, want glob matches merged in lexical order", database)
+	}
+
+	cache, _ := data["cache"].(map[string]any)
+	if cache["ttl"] != "5m" || cache["size"] != 100 {
+		t.Errorf("cache = %v, want !include resolved with nested includes", cache)
+	}
+}
+
+func TestFileSource_IncludesDisabled(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeIncludeFiles(t, tmpDir, map[string]string{
+		"config.yaml": "$include: base.yaml\nname: service\n",
+		"base.yaml":   "name: base\nlevel: info\n",
+	})
+
+	source, _ := NewFileSource(filepath.Join(tmpDir, "config.yaml"), FileSourceOptions{})
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if data[includeKey] != "base.yaml" || data["level"] != nil {
+		t.Errorf("data = %v, want includes left untouched when disabled", data)
+	}
+}
+
+func TestFileSource_IncludeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name: "cycle",
+			files: map[string]string{
+				"config.yaml": "$include: a.yaml\n",
+				"a.yaml":      "$include: b.yaml\n",
+				"b.yaml":      "$include: config.yaml\n",
+			},
+			wantErr: "include cycle",
+		},
+		{
+			name: "escapes root",
+			files: map[string]string{
+				"config.yaml": "$include: ../outside.yaml\n",
+			},
+			wantErr: "escapes include root",
+		},
+		{
+			name: "missing file",
+			files: map[string]string{
+				"config.yaml": "$include: missing.yaml\n",
+			},
+			wantErr: "failed to read include",
+		},
+		{
+			name: "invalid spec",
+			files: map[string]string{
+				"config.yaml": "$include: 42\n",
+			},
+			wantErr: "must be a string or a list of strings",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := filepath.Join(t.TempDir(), "config")
+			writeIncludeFiles(t, tmpDir, tt.files)
+			writeIncludeFiles(t, filepath.Dir(tmpDir), map[string]string{"outside.yaml": "secret: true\n"})
+
+			source, _ := NewFileSource(filepath.Join(tmpDir, "config.yaml"), FileSourceOptions{ResolveIncludes: true})
+
+			_, err := source.Load(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFileSource_IncludeRoot(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping symlink test on Windows")
+	}
+
+	baseDir := t.TempDir()
+	tmpDir := filepath.Join(baseDir, "root")
+	writeIncludeFiles(t, tmpDir, map[string]string{
+		"shared.yaml":         "region: eu\n",
+		"service/config.yaml": "$include: ../shared.yaml\nname: service\n",
+		"service/escape.yaml": "$include: link/outside.yaml\n",
+	})
+	writeIncludeFiles(t, baseDir, map[string]string{"outside/outside.yaml": "secret: true\n"})
+
+	configFile := filepath.Join(tmpDir, "service", "config.yaml")
+
+	source, _ := NewFileSource(configFile, FileSourceOptions{ResolveIncludes: true, IncludeRoot: tmpDir})
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if data["region"] != "eu" || data["name"] != "service" {
+		t.Errorf("data = %v, want shared.yaml included from the wider root", data)
+	}
+
+	// A symlink inside the root must not be a way out of it.
+	if err := os.Symlink(filepath.Join(baseDir, "outside"), filepath.Join(tmpDir, "service", "link")); err != nil {
+		t.Skip("Cannot create symlink:", err)
+	}
+
+	source, _ = NewFileSource(filepath.Join(tmpDir, "service", "escape.yaml"), FileSourceOptions{ResolveIncludes: true})
+	if _, err := source.Load(context.Background()); err == nil {
+		t.Error("Load() should refuse an include reached through a symlink out of the root")
+	}
+}
+
+func TestFileSource_Watch_Includes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping watch test on Windows due to timing/platform differences")
+	}
+
+	tmpDir := t.TempDir()
+	writeIncludeFiles(t, tmpDir, map[string]string{
+		"config.yaml":   "$include: [\"conf.d/*.yaml\"]\n",
+		"conf.d/a.yaml": "key: a\n",
+	})
+
+	source, err := NewFileSource(filepath.Join(tmpDir, "config.yaml"), FileSourceOptions{
+		WatchEnabled:    true,
+		ResolveIncludes: true,
+	})
+	if err != nil {
+		t.Fatalf("NewFileSource() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if _, err := source.Load(ctx); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	changes := make(chan map[string]any, 10)
+	if err := source.Watch(ctx, func(data map[string]any) { changes <- data }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer func() { _ = source.StopWatch() }()
+
+	// Editing an included file reloads the including one.
+	writeIncludeFiles(t, tmpDir, map[string]string{"conf.d/a.yaml": "key: edited\n"})
+	waitForFileValue(t, changes, "edited")
+
+	// A new file matching an include pattern is picked up too.
+	writeIncludeFiles(t, tmpDir, map[string]string{"conf.d/b.yaml": "key: added\n"})
+	waitForFileValue(t, changes, "added")
+}
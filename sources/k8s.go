@@ -6,6 +6,9 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -17,45 +20,89 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
-	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/homedir"
 )
 
 // K8sSource represents a Kubernetes ConfigMap/Secret configuration source.
+// Watching sources read through shared informers; sources with the same
+// client and namespace share one informer per resource.
 type K8sSource struct {
 	name           string
 	client         kubernetes.Interface
 	namespace      string
 	configMapNames []string
 	secretNames    []string
+	labelSelector  labels.Selector
+	fieldSelector  fields.Selector
 	priority       int
 	watching       bool
 	watchStop      chan struct{}
+	informers      *k8sSharedInformers
+	registrations  []k8sRegistration
+	synced         bool
+	last           map[string]any
+	reconcileMu    sync.Mutex
 	logger         logger.Logger
 	errorHandler   errors.ErrorHandler
 	options        K8sSourceOptions
 	mu             sync.RWMutex
 }
 
+// k8sRegistration is an event handler added to a shared informer.
+type k8sRegistration struct {
+	informer     cache.SharedIndexInformer
+	registration cache.ResourceEventHandlerRegistration
+}
+
 // K8sSourceOptions contains options for Kubernetes configuration sources.
 type K8sSourceOptions struct {
 	Name           string
 	Namespace      string
 	ConfigMapNames []string
-	SecretNames    []string
-	Priority       int
-	WatchEnabled   bool
-	KubeConfig     string
-	InCluster      bool
-	LabelSelector  string
-	FieldSelector  string
-	RetryCount     int
-	RetryDelay     time.Duration
-	Logger         logger.Logger
-	ErrorHandler   errors.ErrorHandler
+	// SecretNames are read with get. Watching runs one informer per name,
+	// filtered by a metadata.name field selector, so it also needs list and
+	// watch on those Secrets; RBAC can restrict all three verbs to them with
+	// resourceNames.
+	SecretNames  []string
+	Priority     int
+	WatchEnabled bool
+	// Client is used instead of building one from InCluster or KubeConfig,
+	// for example a fake clientset in tests. The connection test is skipped.
+	Client        kubernetes.Interface
+	KubeConfig    string
+	InCluster     bool
+	LabelSelector string
+	FieldSelector string
+	// ResyncPeriod periodically re-evaluates the informer cache while
+	// watching. Zero disables resync; periods below 30s are raised to 30s.
+	ResyncPeriod time.Duration
+	// ConfigMapPath is the dot-separated path each ConfigMap is merged
+	// under; "{name}" is replaced by the ConfigMap name and "." merges at the
+	// root. Defaults to "{name}". ConfigMaps are merged in ConfigMapNames
+	// order, or by name when selected by selectors, so later ones win.
+	ConfigMapPath string
+	// SecretPath is the path each Secret is merged under, as for
+	// ConfigMapPath. Defaults to "secrets.{name}".
+	SecretPath string
+	// ParseDocuments parses keys with a known extension, such as
+	// application.yaml, as whole documents through the format processors and
+	// merges them. Documents are merged in key order, then plain keys are
+	// applied on top.
+	ParseDocuments bool
+	// BinaryData includes ConfigMap binaryData keys.
+	BinaryData bool
+	// Deprecated: informers retry and relist on their own; RetryCount and
+	// RetryDelay are ignored.
+	RetryCount int
+	// Deprecated: see RetryCount.
+	RetryDelay   time.Duration
+	Logger       logger.Logger
+	ErrorHandler errors.ErrorHandler
 }
 
 // K8sSourceConfig contains configuration for creating Kubernetes sources.
@@ -69,6 +116,11 @@ type K8sSourceConfig struct {
 	InCluster      bool          `json:"in_cluster"      yaml:"in_cluster"`
 	LabelSelector  string        `json:"label_selector"  yaml:"label_selector"`
 	FieldSelector  string        `json:"field_selector"  yaml:"field_selector"`
+	ResyncPeriod   time.Duration `json:"resync_period"   yaml:"resync_period"`
+	ConfigMapPath  string        `json:"configmap_path"  yaml:"configmap_path"`
+	SecretPath     string        `json:"secret_path"     yaml:"secret_path"`
+	ParseDocuments bool          `json:"parse_documents" yaml:"parse_documents"`
+	BinaryData     bool          `json:"binary_data"     yaml:"binary_data"`
 	RetryCount     int           `json:"retry_count"     yaml:"retry_count"`
 	RetryDelay     time.Duration `json:"retry_delay"     yaml:"retry_delay"`
 }
@@ -87,40 +139,35 @@ func NewK8sSource(options K8sSourceOptions) (configcore.ConfigSource, error) {
 		options.RetryDelay = 5 * time.Second
 	}
 
+	if options.ConfigMapPath == "" {
+		options.ConfigMapPath = "{name}"
+	}
+
+	if options.SecretPath == "" {
+		options.SecretPath = "secrets.{name}"
+	}
+
 	name := options.Name
 	if name == "" {
 		name = "k8s:" + options.Namespace
 	}
 
-	// Create Kubernetes client
-	var (
-		config *rest.Config
-		err    error
-	)
-
-	if options.InCluster {
-		// Use in-cluster configuration
-		config, err = rest.InClusterConfig()
-	} else {
-		// Use kubeconfig file
-		kubeconfig := options.KubeConfig
-		if kubeconfig == "" {
-			if home := homedir.HomeDir(); home != "" {
-				kubeconfig = filepath.Join(home, ".kube", "config")
-			}
-		}
-
-		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+	labelSelector, err := labels.Parse(options.LabelSelector)
+	if err != nil {
+		return nil, configcore.ErrConfigError("invalid label selector "+options.LabelSelector, err)
 	}
 
+	fieldSelector, err := fields.ParseSelector(options.FieldSelector)
 	if err != nil {
-		return nil, configcore.ErrConfigError(fmt.Sprintf("failed to create Kubernetes config: %v", err), err)
+		return nil, configcore.ErrConfigError("invalid field selector "+options.FieldSelector, err)
 	}
 
-	// Create Kubernetes clientset
-	clientset, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		return nil, configcore.ErrConfigError(fmt.Sprintf("failed to create Kubernetes client: %v", err), err)
+	clientset := options.Client
+	if clientset == nil {
+		clientset, err = newK8sClientset(options.InCluster, options.KubeConfig)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	source := &K8sSource{
@@ -129,20 +176,70 @@ func NewK8sSource(options K8sSourceOptions) (configcore.ConfigSource, error) {
 		namespace:      options.Namespace,
 		configMapNames: options.ConfigMapNames,
 		secretNames:    options.SecretNames,
+		labelSelector:  labelSelector,
+		fieldSelector:  fieldSelector,
 		priority:       options.Priority,
 		logger:         options.Logger,
 		errorHandler:   options.ErrorHandler,
 		options:        options,
 	}
 
-	// Test connection
-	if err := source.testConnection(context.Background()); err != nil {
-		return nil, configcore.ErrConfigError(fmt.Sprintf("failed to connect to Kubernetes: %v", err), err)
+	// Test connection unless the caller supplied the client
+	if options.Client == nil {
+		if err := source.testConnection(context.Background()); err != nil {
+			return nil, configcore.ErrConfigError(fmt.Sprintf("failed to connect to Kubernetes: %v", err), err)
+		}
 	}
 
 	return source, nil
 }
 
+// newK8sClientset creates a clientset from the in-cluster configuration or a
+// kubeconfig file, defaulting to ~/.kube/config.
+func newK8sClientset(inCluster bool, kubeconfig string) (kubernetes.Interface, error) {
+	config, err := newK8sRestConfig(inCluster, kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create Kubernetes clientset
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, configcore.ErrConfigError(fmt.Sprintf("failed to create Kubernetes client: %v", err), err)
+	}
+
+	return clientset, nil
+}
+
+// newK8sRestConfig loads the in-cluster configuration or a kubeconfig file,
+// defaulting to ~/.kube/config.
+func newK8sRestConfig(inCluster bool, kubeconfig string) (*rest.Config, error) {
+	var (
+		config *rest.Config
+		err    error
+	)
+
+	if inCluster {
+		// Use in-cluster configuration
+		config, err = rest.InClusterConfig()
+	} else {
+		// Use kubeconfig file
+		if kubeconfig == "" {
+			if home := homedir.HomeDir(); home != "" {
+				kubeconfig = filepath.Join(home, ".kube", "config")
+			}
+		}
+
+		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+	}
+
+	if err != nil {
+		return nil, configcore.ErrConfigError(fmt.Sprintf("failed to create Kubernetes config: %v", err), err)
+	}
+
+	return config, nil
+}
+
 // Name returns the source name.
 func (ks *K8sSource) Name() string {
 	return ks.name
@@ -158,6 +255,11 @@ func (ks *K8sSource) GetType() string {
 	return "kubernetes"
 }
 
+// Client returns the Kubernetes client used by the source.
+func (ks *K8sSource) Client() kubernetes.Interface {
+	return ks.client
+}
+
 // IsAvailable checks if the source is available.
 func (ks *K8sSource) IsAvailable(ctx context.Context) bool {
 	// TODO: Implement actual Kubernetes availability check
@@ -191,6 +293,10 @@ func (ks *K8sSource) Load(ctx context.Context) (map[string]any, error) {
 		return nil, err
 	}
 
+	ks.mu.Lock()
+	ks.last = copyConfigMap(config)
+	ks.mu.Unlock()
+
 	if ks.logger != nil {
 		ks.logger.Info("configuration loaded from Kubernetes",
 			logger.String("namespace", ks.namespace),
@@ -201,7 +307,9 @@ func (ks *K8sSource) Load(ctx context.Context) (map[string]any, error) {
 	return config, nil
 }
 
-// Watch starts watching Kubernetes ConfigMaps and Secrets for changes.
+// Watch starts watching Kubernetes ConfigMaps and Secrets for changes
+// through shared informers. Once the informers have synced, Load reads from
+// their cache instead of the API server.
 func (ks *K8sSource) Watch(ctx context.Context, callback func(map[string]any)) error {
 	ks.mu.Lock()
 	defer ks.mu.Unlock()
@@ -214,12 +322,43 @@ func (ks *K8sSource) Watch(ctx context.Context, callback func(map[string]any)) e
 		return configcore.ErrConfigError("Kubernetes watching is not enabled", nil)
 	}
 
+	shared := acquireK8sInformers(ks, ks.secretNames)
+
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj any) { ks.handleEvent(ctx, obj, callback) },
+		UpdateFunc: func(_, obj any) { ks.handleEvent(ctx, obj, callback) },
+		DeleteFunc: func(obj any) { ks.handleEvent(ctx, obj, callback) },
+	}
+
+	informers := []cache.SharedIndexInformer{shared.configMaps}
+	for _, name := range ks.secretNames {
+		informers = append(informers, shared.secretFactory(name).Core().V1().Secrets().Informer())
+	}
+
+	registrations := make([]k8sRegistration, 0, len(informers))
+
+	for _, informer := range informers {
+		registration, err := informer.AddEventHandlerWithResyncPeriod(handler, ks.options.ResyncPeriod)
+		if err != nil {
+			for _, added := range registrations {
+				_ = added.informer.RemoveEventHandler(added.registration)
+			}
+
+			shared.release(ks)
+
+			return configcore.ErrConfigError("failed to register Kubernetes informer handler", err)
+		}
+
+		registrations = append(registrations, k8sRegistration{informer: informer, registration: registration})
+	}
+
+	ks.informers = shared
+	ks.registrations = registrations
+	ks.synced = false
 	ks.watchStop = make(chan struct{})
 	ks.watching = true
 
-	// Start watching goroutines
-	go ks.watchConfigMaps(ctx, callback)
-	go ks.watchSecrets(ctx, callback)
+	go ks.awaitSync(ctx, ks.watchStop, registrations, callback)
 
 	if ks.logger != nil {
 		ks.logger.Info("started watching Kubernetes resources",
@@ -233,9 +372,10 @@ func (ks *K8sSource) Watch(ctx context.Context, callback func(map[string]any)) e
 // StopWatch stops watching Kubernetes resources.
 func (ks *K8sSource) StopWatch() error {
 	ks.mu.Lock()
-	defer ks.mu.Unlock()
 
 	if !ks.watching {
+		ks.mu.Unlock()
+
 		return nil
 	}
 
@@ -244,7 +384,23 @@ func (ks *K8sSource) StopWatch() error {
 		ks.watchStop = nil
 	}
 
+	shared, registrations := ks.informers, ks.registrations
+
+	ks.informers = nil
+	ks.registrations = nil
+	ks.synced = false
 	ks.watching = false
+	ks.mu.Unlock()
+
+	// Detach outside the lock: shutting informers down waits for running
+	// handlers, which take the lock
+	for _, added := range registrations {
+		_ = added.informer.RemoveEventHandler(added.registration)
+	}
+
+	if shared != nil {
+		shared.release(ks)
+	}
 
 	if ks.logger != nil {
 		ks.logger.Info("stopped watching Kubernetes resources",
@@ -319,7 +475,7 @@ func (ks *K8sSource) loadConfigMaps(ctx context.Context, config map[string]any)
 
 	// Load specific ConfigMaps
 	for _, name := range ks.configMapNames {
-		configMap, err := ks.client.CoreV1().ConfigMaps(ks.namespace).Get(ctx, name, metav1.GetOptions{})
+		configMap, err := ks.getConfigMap(ctx, name)
 		if err != nil {
 			if ks.logger != nil {
 				ks.logger.Warn("failed to get ConfigMap",
@@ -332,7 +488,9 @@ func (ks *K8sSource) loadConfigMaps(ctx context.Context, config map[string]any)
 			continue
 		}
 
-		ks.parseConfigMap(configMap, config)
+		if err := ks.parseConfigMap(configMap, config); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -340,6 +498,29 @@ func (ks *K8sSource) loadConfigMaps(ctx context.Context, config map[string]any)
 
 // loadConfigMapsWithSelectors loads ConfigMaps using label/field selectors.
 func (ks *K8sSource) loadConfigMapsWithSelectors(ctx context.Context, config map[string]any) error {
+	if shared := ks.syncedInformers(); shared != nil {
+		configMaps, err := shared.factory.Core().V1().ConfigMaps().Lister().ConfigMaps(ks.namespace).List(ks.labelSelector)
+		if err != nil {
+			return configcore.ErrConfigError(fmt.Sprintf("failed to list ConfigMaps: %v", err), err)
+		}
+
+		slices.SortFunc(configMaps, func(a, b *corev1.ConfigMap) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+
+		for _, configMap := range configMaps {
+			if !ks.fieldSelector.Matches(configMapFields(configMap)) {
+				continue
+			}
+
+			if err := ks.parseConfigMap(configMap, config); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
 	listOptions := metav1.ListOptions{}
 
 	if ks.options.LabelSelector != "" {
@@ -355,8 +536,14 @@ func (ks *K8sSource) loadConfigMapsWithSelectors(ctx context.Context, config map
 		return configcore.ErrConfigError(fmt.Sprintf("failed to list ConfigMaps: %v", err), err)
 	}
 
-	for _, configMap := range configMaps.Items {
-		ks.parseConfigMap(&configMap, config)
+	slices.SortFunc(configMaps.Items, func(a, b corev1.ConfigMap) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	for i := range configMaps.Items {
+		if err := ks.parseConfigMap(&configMaps.Items[i], config); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -365,7 +552,7 @@ func (ks *K8sSource) loadConfigMapsWithSelectors(ctx context.Context, config map
 // loadSecrets loads configuration from Secrets.
 func (ks *K8sSource) loadSecrets(ctx context.Context, config map[string]any) error {
 	for _, name := range ks.secretNames {
-		secret, err := ks.client.CoreV1().Secrets(ks.namespace).Get(ctx, name, metav1.GetOptions{})
+		secret, err := ks.getSecret(ctx, name)
 		if err != nil {
 			if ks.logger != nil {
 				ks.logger.Warn("failed to get Secret",
@@ -378,293 +565,284 @@ func (ks *K8sSource) loadSecrets(ctx context.Context, config map[string]any) err
 			continue
 		}
 
-		ks.parseSecret(secret, config)
+		if err := ks.parseSecret(secret, config); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-// parseConfigMap parses a ConfigMap into configuration data.
-func (ks *K8sSource) parseConfigMap(configMap *corev1.ConfigMap, config map[string]any) {
-	if configMap.Data == nil {
-		return
-	}
-
-	// Use ConfigMap name as a namespace in the configuration
-	cmConfig := make(map[string]any)
+// parseConfigMap parses a ConfigMap into configuration data and merges it
+// under ConfigMapPath.
+func (ks *K8sSource) parseConfigMap(configMap *corev1.ConfigMap, config map[string]any) error {
+	data := make(map[string][]byte, len(configMap.Data)+len(configMap.BinaryData))
 
 	for key, value := range configMap.Data {
-		// Try to parse value as different formats
-		parsedValue := ks.parseValue([]byte(value))
-		ks.setNestedValue(cmConfig, key, parsedValue)
+		data[key] = []byte(value)
 	}
 
-	// Merge into main config under ConfigMap name
-	config[configMap.Name] = cmConfig
-}
-
-// parseSecret parses a Secret into configuration data.
-func (ks *K8sSource) parseSecret(secret *corev1.Secret, config map[string]any) {
-	if secret.Data == nil {
-		return
+	if ks.options.BinaryData {
+		for key, value := range configMap.BinaryData {
+			data[key] = value
+		}
 	}
 
-	// Use Secret name as a namespace in the configuration
-	secretConfig := make(map[string]any)
+	if len(data) == 0 {
+		return nil
+	}
 
-	for key, value := range secret.Data {
-		// Secrets are binary data, convert to string
-		parsedValue := ks.parseValue(value)
-		ks.setNestedValue(secretConfig, key, parsedValue)
+	cmConfig, err := ks.parseData(data)
+	if err != nil {
+		return configcore.ErrConfigError("failed to parse ConfigMap "+configMap.Name, err)
 	}
 
-	// Merge into main config under secrets namespace
-	if config["secrets"] == nil {
-		config["secrets"] = make(map[string]any)
+	mergeAtK8sPath(config, ks.options.ConfigMapPath, configMap.Name, cmConfig)
+
+	return nil
+}
+
+// parseSecret parses a Secret into configuration data and merges it under
+// SecretPath.
+func (ks *K8sSource) parseSecret(secret *corev1.Secret, config map[string]any) error {
+	if len(secret.Data) == 0 {
+		return nil
 	}
 
-	if secretsConfig, ok := config["secrets"].(map[string]any); ok {
-		secretsConfig[secret.Name] = secretConfig
+	secretConfig, err := ks.parseData(secret.Data)
+	if err != nil {
+		return configcore.ErrConfigError("failed to parse Secret "+secret.Name, err)
 	}
+
+	mergeAtK8sPath(config, ks.options.SecretPath, secret.Name, secretConfig)
+
+	return nil
 }
 
-// watchConfigMaps watches for ConfigMap changes.
-func (ks *K8sSource) watchConfigMaps(ctx context.Context, callback func(map[string]any)) {
-	defer func() {
-		if r := recover(); r != nil {
-			if ks.logger != nil {
-				ks.logger.Error("panic in Kubernetes ConfigMap watch loop",
-					logger.String("namespace", ks.namespace),
-					logger.Any("panic", r),
-				)
-			}
-		}
-	}()
+// parseData converts the keys of a ConfigMap or Secret to configuration.
+// With ParseDocuments, keys with a known extension are parsed as documents
+// and merged first; other keys are parsed individually and set on top.
+func (ks *K8sSource) parseData(data map[string][]byte) (map[string]any, error) {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
 
-	retryCount := 0
+	sort.Strings(keys)
 
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ks.watchStop:
-			return
-		default:
-		}
+	result := make(map[string]any)
+	merger := configcore.NewMergeUtil()
 
-		var fieldSelector string
-		if len(ks.configMapNames) > 0 {
-			// Watch specific ConfigMaps
-			fieldSelector = fields.OneTermEqualSelector("metadata.name", strings.Join(ks.configMapNames, ",")).String()
-		} else if ks.options.FieldSelector != "" {
-			fieldSelector = ks.options.FieldSelector
-		}
+	var plain []string
 
-		watchOptions := metav1.ListOptions{
-			Watch:         true,
-			FieldSelector: fieldSelector,
-		}
+	for _, key := range keys {
+		processor, ok := processorForExtension(filepath.Ext(key))
+		if !ks.options.ParseDocuments || !ok {
+			plain = append(plain, key)
 
-		if ks.options.LabelSelector != "" {
-			watchOptions.LabelSelector = ks.options.LabelSelector
+			continue
 		}
 
-		watcher, err := ks.client.CoreV1().ConfigMaps(ks.namespace).Watch(ctx, watchOptions)
+		document, err := processor.Parse(data[key])
 		if err != nil {
-			retryCount++
-			if retryCount <= ks.options.RetryCount {
-				if ks.logger != nil {
-					ks.logger.Warn("ConfigMap watch error, retrying",
-						logger.String("namespace", ks.namespace),
-						logger.Int("attempt", retryCount),
-						logger.Error(err),
-					)
-				}
-
-				select {
-				case <-ctx.Done():
-					return
-				case <-ks.watchStop:
-					return
-				case <-time.After(ks.options.RetryDelay):
-					continue
-				}
-			} else {
-				ks.handleWatchError(err)
-
-				return
-			}
+			return nil, fmt.Errorf("failed to parse key %s: %w", key, err)
 		}
 
-		// Reset retry count on successful watch
-		retryCount = 0
+		merger.MergeInPlace(result, document)
+	}
 
-		// Process watch events
-		for event := range watcher.ResultChan() {
-			select {
-			case <-ctx.Done():
-				watcher.Stop()
+	for _, key := range plain {
+		ks.setNestedValue(result, key, ks.parseValue(data[key]))
+	}
 
-				return
-			case <-ks.watchStop:
-				watcher.Stop()
+	return result, nil
+}
 
-				return
-			default:
-			}
+// mergeAtK8sPath merges data into config at a dot-separated path, where
+// "{name}" is replaced by name and "." is the root. The path is split before
+// substitution, so names containing dots stay a single key.
+func mergeAtK8sPath(config map[string]any, path, name string, data map[string]any) {
+	target := config
 
-			if event.Type == watch.Modified || event.Type == watch.Added || event.Type == watch.Deleted {
-				if ks.logger != nil {
-					ks.logger.Info("Kubernetes ConfigMap change detected",
-						logger.String("namespace", ks.namespace),
-						logger.String("event_type", string(event.Type)),
-					)
-				}
+	if path != "." {
+		for _, key := range strings.Split(path, ".") {
+			key = strings.ReplaceAll(key, "{name}", name)
 
-				// Reload all configuration and notify callback
-				if config, err := ks.Load(ctx); err == nil {
-					if callback != nil {
-						go func() {
-							defer func() {
-								if r := recover(); r != nil {
-									if ks.logger != nil {
-										ks.logger.Error("panic in Kubernetes watch callback",
-											logger.Any("panic", r),
-										)
-									}
-								}
-							}()
-
-							callback(config)
-						}()
-					}
-				}
+			next, ok := target[key].(map[string]any)
+			if !ok {
+				next = make(map[string]any)
+				target[key] = next
 			}
-		}
 
-		// Watch connection closed, retry
-		if ks.logger != nil {
-			ks.logger.Warn("ConfigMap watch connection closed, retrying",
-				logger.String("namespace", ks.namespace),
-			)
+			target = next
 		}
 	}
+
+	configcore.NewMergeUtil().MergeInPlace(target, data)
 }
 
-// watchSecrets watches for Secret changes.
-func (ks *K8sSource) watchSecrets(ctx context.Context, callback func(map[string]any)) {
-	if len(ks.secretNames) == 0 {
-		return
+// getConfigMap reads a ConfigMap from the informer cache when synced, and
+// from the API server otherwise.
+func (ks *K8sSource) getConfigMap(ctx context.Context, name string) (*corev1.ConfigMap, error) {
+	if shared := ks.syncedInformers(); shared != nil {
+		return shared.factory.Core().V1().ConfigMaps().Lister().ConfigMaps(ks.namespace).Get(name)
 	}
 
-	defer func() {
-		if r := recover(); r != nil {
-			if ks.logger != nil {
-				ks.logger.Error("panic in Kubernetes Secret watch loop",
-					logger.String("namespace", ks.namespace),
-					logger.Any("panic", r),
-				)
-			}
+	return ks.client.CoreV1().ConfigMaps(ks.namespace).Get(ctx, name, metav1.GetOptions{})
+}
+
+// getSecret reads a Secret from the informer cache when synced, and from
+// the API server otherwise.
+func (ks *K8sSource) getSecret(ctx context.Context, name string) (*corev1.Secret, error) {
+	if shared := ks.syncedInformers(); shared != nil {
+		if factory := shared.secretFactory(name); factory != nil {
+			return factory.Core().V1().Secrets().Lister().Secrets(ks.namespace).Get(name)
 		}
-	}()
+	}
+
+	return ks.client.CoreV1().Secrets(ks.namespace).Get(ctx, name, metav1.GetOptions{})
+}
 
-	retryCount := 0
+// syncedInformers returns the shared informers once their initial list has
+// been delivered to this source.
+func (ks *K8sSource) syncedInformers() *k8sSharedInformers {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	if !ks.synced {
+		return nil
+	}
+
+	return ks.informers
+}
+
+// awaitSync waits for the informers to deliver their initial list, then
+// reconciles once to pick up changes made since the last Load.
+func (ks *K8sSource) awaitSync(ctx context.Context, stop chan struct{}, registrations []k8sRegistration, callback func(map[string]any)) {
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
 
-	for {
 		select {
 		case <-ctx.Done():
-			return
-		case <-ks.watchStop:
-			return
-		default:
+		case <-stop:
 		}
+	}()
 
-		fieldSelector := fields.OneTermEqualSelector("metadata.name", strings.Join(ks.secretNames, ",")).String()
+	synced := make([]cache.InformerSynced, 0, len(registrations))
+	for _, added := range registrations {
+		synced = append(synced, added.registration.HasSynced)
+	}
 
-		watchOptions := metav1.ListOptions{
-			Watch:         true,
-			FieldSelector: fieldSelector,
-		}
+	if !cache.WaitForCacheSync(done, synced...) {
+		return
+	}
 
-		watcher, err := ks.client.CoreV1().Secrets(ks.namespace).Watch(ctx, watchOptions)
-		if err != nil {
-			retryCount++
-			if retryCount <= ks.options.RetryCount {
-				if ks.logger != nil {
-					ks.logger.Warn("Secret watch error, retrying",
-						logger.String("namespace", ks.namespace),
-						logger.Int("attempt", retryCount),
-						logger.Error(err),
-					)
-				}
+	ks.mu.Lock()
 
-				select {
-				case <-ctx.Done():
-					return
-				case <-ks.watchStop:
-					return
-				case <-time.After(ks.options.RetryDelay):
-					continue
-				}
-			} else {
-				ks.handleWatchError(err)
+	if ks.watchStop != stop {
+		ks.mu.Unlock()
 
-				return
-			}
+		return
+	}
+
+	ks.synced = true
+	ks.mu.Unlock()
+
+	if ks.logger != nil {
+		ks.logger.Debug("Kubernetes informers synced",
+			logger.String("namespace", ks.namespace),
+		)
+	}
+
+	ks.reconcile(ctx, callback)
+}
+
+// handleEvent reconciles when an informer event concerns one of the
+// source's ConfigMaps or Secrets. Events are ignored until the initial sync.
+func (ks *K8sSource) handleEvent(ctx context.Context, obj any, callback func(map[string]any)) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	ks.mu.RLock()
+	synced := ks.synced
+	ks.mu.RUnlock()
+
+	if !synced || !ks.isRelevant(obj) {
+		return
+	}
+
+	ks.reconcile(ctx, callback)
+}
+
+// isRelevant reports whether an object is one the source loads.
+func (ks *K8sSource) isRelevant(obj any) bool {
+	switch object := obj.(type) {
+	case *corev1.ConfigMap:
+		if len(ks.configMapNames) > 0 {
+			return slices.Contains(ks.configMapNames, object.Name)
 		}
 
-		// Reset retry count on successful watch
-		retryCount = 0
+		return ks.labelSelector.Matches(labels.Set(object.Labels)) && ks.fieldSelector.Matches(configMapFields(object))
+	case *corev1.Secret:
+		return slices.Contains(ks.secretNames, object.Name)
+	default:
+		return false
+	}
+}
+
+// reconcile reloads from the informer cache and notifies the callback when
+// the configuration differs from the last load. Resyncs therefore only
+// notify when something changed.
+func (ks *K8sSource) reconcile(ctx context.Context, callback func(map[string]any)) {
+	ks.reconcileMu.Lock()
+	defer ks.reconcileMu.Unlock()
 
-		// Process watch events
-		for event := range watcher.ResultChan() {
-			select {
-			case <-ctx.Done():
-				watcher.Stop()
+	ks.mu.RLock()
+	previous := ks.last
+	ks.mu.RUnlock()
 
-				return
-			case <-ks.watchStop:
-				watcher.Stop()
+	config, err := ks.Load(ctx)
+	if err != nil {
+		ks.handleWatchError(err)
 
-				return
-			default:
-			}
+		return
+	}
 
-			if event.Type == watch.Modified || event.Type == watch.Added || event.Type == watch.Deleted {
-				if ks.logger != nil {
-					ks.logger.Info("Kubernetes Secret change detected",
-						logger.String("namespace", ks.namespace),
-						logger.String("event_type", string(event.Type)),
-					)
-				}
+	if previous == nil || reflect.DeepEqual(previous, config) {
+		return
+	}
+
+	if ks.logger != nil {
+		ks.logger.Info("Kubernetes configuration change detected",
+			logger.String("namespace", ks.namespace),
+		)
+	}
 
-				// Reload all configuration and notify callback
-				if config, err := ks.Load(ctx); err == nil {
-					if callback != nil {
-						go func() {
-							defer func() {
-								if r := recover(); r != nil {
-									if ks.logger != nil {
-										ks.logger.Error("panic in Kubernetes watch callback",
-											logger.Any("panic", r),
-										)
-									}
-								}
-							}()
-
-							callback(config)
-						}()
+	if callback != nil {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					if ks.logger != nil {
+						ks.logger.Error("panic in Kubernetes watch callback",
+							logger.Any("panic", r),
+						)
 					}
 				}
-			}
-		}
+			}()
 
-		// Watch connection closed, retry
-		if ks.logger != nil {
-			ks.logger.Warn("Secret watch connection closed, retrying",
-				logger.String("namespace", ks.namespace),
-			)
-		}
+			callback(config)
+		}()
+	}
+}
+
+// configMapFields returns the fields ConfigMaps can be selected by.
+func configMapFields(configMap *corev1.ConfigMap) fields.Set {
+	return fields.Set{
+		"metadata.name":      configMap.Name,
+		"metadata.namespace": configMap.Namespace,
 	}
 }
 
@@ -730,7 +908,8 @@ func (ks *K8sSource) setNestedValue(config map[string]any, key string, value any
 	}
 }
 
-// handleWatchError handles errors during watching.
+// handleWatchError handles errors during watching. The informers keep
+// retrying, so watching continues.
 func (ks *K8sSource) handleWatchError(err error) {
 	if ks.logger != nil {
 		ks.logger.Error("Kubernetes watch error",
@@ -742,9 +921,6 @@ func (ks *K8sSource) handleWatchError(err error) {
 	if ks.errorHandler != nil {
 		_ = ks.errorHandler.HandleError(context.Background(), configcore.ErrConfigError("Kubernetes watch error for namespace "+ks.namespace, err))
 	}
-
-	// Stop watching on persistent errors
-	_ = ks.StopWatch()
 }
 
 // K8sSourceFactory creates Kubernetes configuration sources.
@@ -774,6 +950,11 @@ func (factory *K8sSourceFactory) CreateFromConfig(config K8sSourceConfig) (confi
 		InCluster:      config.InCluster,
 		LabelSelector:  config.LabelSelector,
 		FieldSelector:  config.FieldSelector,
+		ResyncPeriod:   config.ResyncPeriod,
+		ConfigMapPath:  config.ConfigMapPath,
+		SecretPath:     config.SecretPath,
+		ParseDocuments: config.ParseDocuments,
+		BinaryData:     config.BinaryData,
 		RetryCount:     config.RetryCount,
 		RetryDelay:     config.RetryDelay,
 		Logger:         factory.logger,
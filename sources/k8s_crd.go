@@ -0,0 +1,613 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	configcore "github.com/xraph/confy/internal"
+	errors "github.com/xraph/go-utils/errs"
+	logger "github.com/xraph/go-utils/log"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/jsonpath"
+	"k8s.io/client-go/util/retry"
+)
+
+// K8sCustomResourceSource loads configuration from a named custom resource
+// through the dynamic client. The configuration is taken from .spec or a
+// configured JSONPath, and the outcome is written back to .status as the
+// observed generation and a condition.
+type K8sCustomResourceSource struct {
+	name         string
+	client       dynamic.Interface
+	gvr          schema.GroupVersionResource
+	namespace    string
+	resourceName string
+	path         *jsonpath.JSONPath
+	priority     int
+	last         map[string]any
+	generation   int64
+	lastError    string
+	watching     bool
+	watchStop    chan struct{}
+	applyMu      sync.Mutex
+	logger       logger.Logger
+	errorHandler errors.ErrorHandler
+	options      K8sCustomResourceSourceOptions
+	mu           sync.RWMutex
+}
+
+// K8sCustomResourceSourceOptions contains options for custom resource
+// sources.
+type K8sCustomResourceSourceOptions struct {
+	Name     string
+	Priority int
+	// Group, Version and Resource identify the custom resource, for example
+	// platform.example.com, v1 and appconfigs.
+	Group    string
+	Version  string
+	Resource string
+	// Namespace is empty for cluster-scoped resources.
+	Namespace    string
+	ResourceName string
+	// Path is a JSONPath to the configuration object, such as
+	// "{.spec.config}" or ".spec.config". Defaults to ".spec".
+	Path string
+	// Validator checks the configuration before it is applied. A failure
+	// is reported as the condition and Load returns the error.
+	Validator func(config map[string]any) error
+	// DisableStatusUpdates stops the source from writing .status, for
+	// resources without a status subresource or without RBAC access to it.
+	DisableStatusUpdates bool
+	// ConditionType is the status condition written. Defaults to "Applied".
+	ConditionType string
+	// Client is used instead of building one from InCluster or KubeConfig.
+	Client       dynamic.Interface
+	InCluster    bool
+	KubeConfig   string
+	WatchEnabled bool
+	ResyncPeriod time.Duration
+	Logger       logger.Logger
+	ErrorHandler errors.ErrorHandler
+}
+
+// K8sCustomResourceSourceConfig contains configuration for creating custom
+// resource sources.
+type K8sCustomResourceSourceConfig struct {
+	Priority             int           `json:"priority"               yaml:"priority"`
+	Group                string        `json:"group"                  yaml:"group"`
+	Version              string        `json:"version"                yaml:"version"`
+	Resource             string        `json:"resource"               yaml:"resource"`
+	Namespace            string        `json:"namespace"              yaml:"namespace"`
+	ResourceName         string        `json:"resource_name"          yaml:"resource_name"`
+	Path                 string        `json:"path"                   yaml:"path"`
+	DisableStatusUpdates bool          `json:"disable_status_updates" yaml:"disable_status_updates"`
+	ConditionType        string        `json:"condition_type"         yaml:"condition_type"`
+	InCluster            bool          `json:"in_cluster"             yaml:"in_cluster"`
+	KubeConfig           string        `json:"kubeconfig"             yaml:"kubeconfig"`
+	WatchEnabled         bool          `json:"watch_enabled"          yaml:"watch_enabled"`
+	ResyncPeriod         time.Duration `json:"resync_period"          yaml:"resync_period"`
+}
+
+// Condition reasons written to the custom resource status.
+const (
+	crReasonApplied          = "Applied"
+	crReasonInvalidConfig    = "InvalidConfig"
+	crReasonValidationFailed = "ValidationFailed"
+)
+
+// NewK8sCustomResourceSource creates a new custom resource source.
+func NewK8sCustomResourceSource(options K8sCustomResourceSourceOptions) (configcore.ConfigSource, error) {
+	if options.Version == "" || options.Resource == "" {
+		return nil, configcore.ErrConfigError("custom resource version and resource are required", nil)
+	}
+
+	if options.ResourceName == "" {
+		return nil, configcore.ErrConfigError("custom resource name is required", nil)
+	}
+
+	if options.Path == "" {
+		options.Path = ".spec"
+	}
+
+	if options.ConditionType == "" {
+		options.ConditionType = "Applied"
+	}
+
+	template := options.Path
+	if !strings.HasPrefix(template, "{") {
+		template = "{" + template + "}"
+	}
+
+	path := jsonpath.New("config")
+	if err := path.Parse(template); err != nil {
+		return nil, configcore.ErrConfigError("invalid custom resource path "+options.Path, err)
+	}
+
+	client := options.Client
+	if client == nil {
+		config, err := newK8sRestConfig(options.InCluster, options.KubeConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		client, err = dynamic.NewForConfig(config)
+		if err != nil {
+			return nil, configcore.ErrConfigError(fmt.Sprintf("failed to create Kubernetes dynamic client: %v", err), err)
+		}
+	}
+
+	gvr := schema.GroupVersionResource{Group: options.Group, Version: options.Version, Resource: options.Resource}
+
+	name := options.Name
+	if name == "" {
+		name = "k8s-cr:" + gvr.Resource + "/" + options.ResourceName
+	}
+
+	return &K8sCustomResourceSource{
+		name:         name,
+		client:       client,
+		gvr:          gvr,
+		namespace:    options.Namespace,
+		resourceName: options.ResourceName,
+		path:         path,
+		priority:     options.Priority,
+		logger:       options.Logger,
+		errorHandler: options.ErrorHandler,
+		options:      options,
+	}, nil
+}
+
+// Name returns the source name.
+func (cs *K8sCustomResourceSource) Name() string {
+	return cs.name
+}
+
+// GetName returns the source name (alias for Name).
+func (cs *K8sCustomResourceSource) GetName() string {
+	return cs.name
+}
+
+// GetType returns the source type.
+func (cs *K8sCustomResourceSource) GetType() string {
+	return "kubernetes-custom-resource"
+}
+
+// IsAvailable checks if the custom resource exists.
+func (cs *K8sCustomResourceSource) IsAvailable(ctx context.Context) bool {
+	_, err := cs.resourceClient().Get(ctx, cs.resourceName, metav1.GetOptions{})
+
+	return err == nil
+}
+
+// Priority returns the source priority.
+func (cs *K8sCustomResourceSource) Priority() int {
+	return cs.priority
+}
+
+// Load reads the custom resource, validates its configuration and records
+// the outcome in its status.
+func (cs *K8sCustomResourceSource) Load(ctx context.Context) (map[string]any, error) {
+	object, err := cs.resourceClient().Get(ctx, cs.resourceName, metav1.GetOptions{})
+	if err != nil {
+		return nil, configcore.ErrConfigError(fmt.Sprintf("failed to get %s %s", cs.gvr.Resource, cs.resourceName), err)
+	}
+
+	config, err := cs.apply(ctx, object)
+	if err != nil {
+		return nil, err
+	}
+
+	return copyConfigMap(config), nil
+}
+
+// Watch starts watching the custom resource through an informer.
+func (cs *K8sCustomResourceSource) Watch(ctx context.Context, callback func(map[string]any)) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if cs.watching {
+		return configcore.ErrConfigError("already watching custom resource", nil)
+	}
+
+	if !cs.IsWatchable() {
+		return configcore.ErrConfigError("custom resource watching is not enabled", nil)
+	}
+
+	selector := fields.OneTermEqualSelector("metadata.name", cs.resourceName).String()
+	informer := dynamicinformer.NewFilteredDynamicInformer(cs.client, cs.gvr, cs.namespace, cs.options.ResyncPeriod, cache.Indexers{},
+		func(options *metav1.ListOptions) {
+			options.FieldSelector = selector
+		},
+	).Informer()
+
+	_ = informer.SetWatchErrorHandlerWithContext(func(ctx context.Context, r *cache.Reflector, err error) {
+		cache.DefaultWatchErrorHandler(ctx, r, err)
+		cs.handleWatchError(err)
+	})
+
+	handle := func(obj any) {
+		object, ok := obj.(*unstructured.Unstructured)
+		if !ok || object.GetName() != cs.resourceName {
+			return
+		}
+
+		cs.handleUpdate(ctx, object, callback)
+	}
+
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    handle,
+		UpdateFunc: func(_, obj any) { handle(obj) },
+		DeleteFunc: func(obj any) {
+			if cs.logger != nil {
+				cs.logger.Warn("custom resource deleted, keeping last configuration",
+					logger.String("resource", cs.gvr.Resource),
+					logger.String("name", cs.resourceName),
+				)
+			}
+		},
+	})
+	if err != nil {
+		return configcore.ErrConfigError("failed to register custom resource handler", err)
+	}
+
+	cs.watchStop = make(chan struct{})
+	cs.watching = true
+
+	go cs.runInformer(ctx, informer, cs.watchStop)
+
+	if cs.logger != nil {
+		cs.logger.Info("started watching custom resource",
+			logger.String("resource", cs.gvr.Resource),
+			logger.String("name", cs.resourceName),
+		)
+	}
+
+	return nil
+}
+
+// runInformer runs the informer until the context is done or watching stops.
+func (cs *K8sCustomResourceSource) runInformer(ctx context.Context, informer cache.SharedIndexInformer, stop chan struct{}) {
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		select {
+		case <-ctx.Done():
+		case <-stop:
+		}
+	}()
+
+	informer.Run(done)
+}
+
+// StopWatch stops watching the custom resource.
+func (cs *K8sCustomResourceSource) StopWatch() error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if !cs.watching {
+		return nil
+	}
+
+	if cs.watchStop != nil {
+		close(cs.watchStop)
+		cs.watchStop = nil
+	}
+
+	cs.watching = false
+
+	if cs.logger != nil {
+		cs.logger.Info("stopped watching custom resource",
+			logger.String("resource", cs.gvr.Resource),
+			logger.String("name", cs.resourceName),
+		)
+	}
+
+	return nil
+}
+
+// Reload forces a reload of the custom resource.
+func (cs *K8sCustomResourceSource) Reload(ctx context.Context) error {
+	_, err := cs.Load(ctx)
+
+	return err
+}
+
+// IsWatchable returns true if watching is enabled.
+func (cs *K8sCustomResourceSource) IsWatchable() bool {
+	return cs.options.WatchEnabled
+}
+
+// SupportsSecrets returns false.
+func (cs *K8sCustomResourceSource) SupportsSecrets() bool {
+	return false
+}
+
+// GetSecret is not supported by custom resource sources.
+func (cs *K8sCustomResourceSource) GetSecret(ctx context.Context, key string) (string, error) {
+	return "", configcore.ErrConfigError("custom resource source does not support secrets", nil)
+}
+
+// ReportMetadata reports the resource and the last applied generation.
+func (cs *K8sCustomResourceSource) ReportMetadata(metadata *configcore.SourceMetadata) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	if metadata.Properties == nil {
+		metadata.Properties = make(map[string]any)
+	}
+
+	metadata.Properties["resource"] = cs.gvr.String()
+	metadata.Properties["name"] = cs.resourceName
+	metadata.Properties["namespace"] = cs.namespace
+	metadata.Properties["generation"] = cs.generation
+
+	if cs.lastError != "" {
+		metadata.LastError = cs.lastError
+	}
+}
+
+// resourceClient returns the dynamic client for the resource's namespace.
+func (cs *K8sCustomResourceSource) resourceClient() dynamic.ResourceInterface {
+	if cs.namespace == "" {
+		return cs.client.Resource(cs.gvr)
+	}
+
+	return cs.client.Resource(cs.gvr).Namespace(cs.namespace)
+}
+
+// handleUpdate applies a watched object and notifies the callback when its
+// generation or configuration changed. Status-only updates, including the
+// source's own status writes, are ignored.
+func (cs *K8sCustomResourceSource) handleUpdate(ctx context.Context, object *unstructured.Unstructured, callback func(map[string]any)) {
+	cs.mu.RLock()
+	previous, generation := cs.last, cs.generation
+	cs.mu.RUnlock()
+
+	config, err := cs.apply(ctx, object)
+	if err != nil {
+		cs.handleWatchError(err)
+
+		return
+	}
+
+	if object.GetGeneration() == generation && reflect.DeepEqual(previous, config) {
+		return
+	}
+
+	if cs.logger != nil {
+		cs.logger.Info("custom resource changed, configuration reloaded",
+			logger.String("name", cs.resourceName),
+			logger.Int64("generation", object.GetGeneration()),
+		)
+	}
+
+	if callback != nil {
+		callback(copyConfigMap(config))
+	}
+}
+
+// apply extracts and validates the configuration of an object, records the
+// outcome in its status and remembers it as the last applied configuration.
+func (cs *K8sCustomResourceSource) apply(ctx context.Context, object *unstructured.Unstructured) (map[string]any, error) {
+	cs.applyMu.Lock()
+	defer cs.applyMu.Unlock()
+
+	config, reason, err := cs.extract(object)
+	if err == nil && cs.options.Validator != nil {
+		if verr := cs.options.Validator(config); verr != nil {
+			reason, err = crReasonValidationFailed, verr
+		}
+	}
+
+	if statusErr := cs.writeStatus(ctx, object, reason, err); statusErr != nil && cs.logger != nil {
+		cs.logger.Warn("failed to update custom resource status",
+			logger.String("name", cs.resourceName),
+			logger.Error(statusErr),
+		)
+	}
+
+	if err != nil {
+		cs.mu.Lock()
+		cs.lastError = err.Error()
+		cs.mu.Unlock()
+
+		return nil, configcore.ErrConfigError(fmt.Sprintf("invalid configuration in %s %s generation %d", cs.gvr.Resource, cs.resourceName, object.GetGeneration()), err)
+	}
+
+	cs.mu.Lock()
+	cs.last = config
+	cs.generation = object.GetGeneration()
+	cs.lastError = ""
+	cs.mu.Unlock()
+
+	return config, nil
+}
+
+// extract evaluates the configured path against an object.
+func (cs *K8sCustomResourceSource) extract(object *unstructured.Unstructured) (map[string]any, string, error) {
+	results, err := cs.path.FindResults(object.Object)
+	if err != nil {
+		return nil, crReasonInvalidConfig, fmt.Errorf("path %s: %w", cs.options.Path, err)
+	}
+
+	if len(results) == 0 || len(results[0]) == 0 {
+		return nil, crReasonInvalidConfig, fmt.Errorf("path %s matched nothing", cs.options.Path)
+	}
+
+	config, ok := results[0][0].Interface().(map[string]any)
+	if !ok {
+		return nil, crReasonInvalidConfig, fmt.Errorf("path %s is not an object", cs.options.Path)
+	}
+
+	return runtime.DeepCopyJSON(config), crReasonApplied, nil
+}
+
+// writeStatus records the observed generation and the condition, skipping
+// the update when the status already matches. Conflicts are retried
+// against the latest version of the resource.
+func (cs *K8sCustomResourceSource) writeStatus(ctx context.Context, object *unstructured.Unstructured, reason string, applyErr error) error {
+	if cs.options.DisableStatusUpdates {
+		return nil
+	}
+
+	condition := metav1.Condition{
+		Type:               cs.options.ConditionType,
+		Status:             metav1.ConditionTrue,
+		Reason:             reason,
+		Message:            "configuration applied by " + cs.name,
+		ObservedGeneration: object.GetGeneration(),
+	}
+
+	if applyErr != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Message = applyErr.Error()
+	}
+
+	current := object
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		updated, changed, err := withCondition(current, condition)
+		if err != nil || !changed {
+			return err
+		}
+
+		_, err = cs.resourceClient().UpdateStatus(ctx, updated, metav1.UpdateOptions{})
+		if err == nil || !apierrors.IsConflict(err) {
+			return err
+		}
+
+		latest, getErr := cs.resourceClient().Get(ctx, cs.resourceName, metav1.GetOptions{})
+		if getErr != nil {
+			return getErr
+		}
+
+		current = latest
+
+		return err
+	})
+}
+
+// withCondition returns a copy of object with the observed generation and
+// condition set, and whether anything changed.
+func withCondition(object *unstructured.Unstructured, condition metav1.Condition) (*unstructured.Unstructured, bool, error) {
+	updated := object.DeepCopy()
+
+	rawConditions, _, err := unstructured.NestedSlice(updated.Object, "status", "conditions")
+	if err != nil {
+		return nil, false, err
+	}
+
+	conditions := make([]metav1.Condition, 0, len(rawConditions)+1)
+
+	for _, raw := range rawConditions {
+		rawMap, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		var existing metav1.Condition
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(rawMap, &existing); err != nil {
+			return nil, false, err
+		}
+
+		conditions = append(conditions, existing)
+	}
+
+	observed, _, _ := unstructured.NestedInt64(updated.Object, "status", "observedGeneration")
+
+	changed := apimeta.SetStatusCondition(&conditions, condition)
+	if observed != condition.ObservedGeneration {
+		changed = true
+	}
+
+	if !changed {
+		return nil, false, nil
+	}
+
+	encoded := make([]any, 0, len(conditions))
+
+	for i := range conditions {
+		raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&conditions[i])
+		if err != nil {
+			return nil, false, err
+		}
+
+		encoded = append(encoded, raw)
+	}
+
+	if err := unstructured.SetNestedSlice(updated.Object, encoded, "status", "conditions"); err != nil {
+		return nil, false, err
+	}
+
+	if err := unstructured.SetNestedField(updated.Object, condition.ObservedGeneration, "status", "observedGeneration"); err != nil {
+		return nil, false, err
+	}
+
+	return updated, true, nil
+}
+
+// handleWatchError handles errors during watching. The informer keeps
+// retrying, so watching continues.
+func (cs *K8sCustomResourceSource) handleWatchError(err error) {
+	if cs.logger != nil {
+		cs.logger.Error("custom resource watch error",
+			logger.String("name", cs.resourceName),
+			logger.Error(err),
+		)
+	}
+
+	if cs.errorHandler != nil {
+		_ = cs.errorHandler.HandleError(context.Background(), configcore.ErrConfigError("custom resource watch error for "+cs.resourceName, err))
+	}
+}
+
+// K8sCustomResourceSourceFactory creates custom resource sources.
+type K8sCustomResourceSourceFactory struct {
+	logger       logger.Logger
+	errorHandler errors.ErrorHandler
+}
+
+// NewK8sCustomResourceSourceFactory creates a new custom resource source
+// factory.
+func NewK8sCustomResourceSourceFactory(logger logger.Logger, errorHandler errors.ErrorHandler) *K8sCustomResourceSourceFactory {
+	return &K8sCustomResourceSourceFactory{
+		logger:       logger,
+		errorHandler: errorHandler,
+	}
+}
+
+// CreateFromConfig creates a custom resource source from configuration.
+func (factory *K8sCustomResourceSourceFactory) CreateFromConfig(config K8sCustomResourceSourceConfig) (configcore.ConfigSource, error) {
+	return NewK8sCustomResourceSource(K8sCustomResourceSourceOptions{
+		Priority:             config.Priority,
+		Group:                config.Group,
+		Version:              config.Version,
+		Resource:             config.Resource,
+		Namespace:            config.Namespace,
+		ResourceName:         config.ResourceName,
+		Path:                 config.Path,
+		DisableStatusUpdates: config.DisableStatusUpdates,
+		ConditionType:        config.ConditionType,
+		InCluster:            config.InCluster,
+		KubeConfig:           config.KubeConfig,
+		WatchEnabled:         config.WatchEnabled,
+		ResyncPeriod:         config.ResyncPeriod,
+		Logger:               factory.logger,
+		ErrorHandler:         factory.errorHandler,
+	})
+}
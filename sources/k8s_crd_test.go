@@ -0,0 +1,217 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+var appConfigGVR = schema.GroupVersionResource{Group: "platform.example.com", Version: "v1", Resource: "appconfigs"}
+
+func newAppConfig(generation int64, spec map[string]any) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "platform.example.com/v1",
+		"kind":       "AppConfig",
+		"metadata": map[string]any{
+			"name":       "checkout",
+			"namespace":  "shop",
+			"generation": generation,
+		},
+		"spec": spec,
+	}}
+}
+
+func newFakeDynamicClient(objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{appConfigGVR: "AppConfigList"}, objects...)
+}
+
+func testCustomResourceOptions(client *dynamicfake.FakeDynamicClient) K8sCustomResourceSourceOptions {
+	return K8sCustomResourceSourceOptions{
+		Client:       client,
+		Group:        appConfigGVR.Group,
+		Version:      appConfigGVR.Version,
+		Resource:     appConfigGVR.Resource,
+		Namespace:    "shop",
+		ResourceName: "checkout",
+	}
+}
+
+// appConfigCondition reads the Applied condition from the stored resource.
+func appConfigCondition(t *testing.T, client *dynamicfake.FakeDynamicClient) (*metav1.Condition, int64) {
+	t.Helper()
+
+	object, err := client.Resource(appConfigGVR).Namespace("shop").Get(context.Background(), "checkout", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, _, _ := unstructured.NestedSlice(object.Object, "status", "conditions")
+
+	conditions := make([]metav1.Condition, 0, len(raw))
+
+	for _, item := range raw {
+		var condition metav1.Condition
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.(map[string]any), &condition); err != nil {
+			t.Fatal(err)
+		}
+
+		conditions = append(conditions, condition)
+	}
+
+	observed, _, _ := unstructured.NestedInt64(object.Object, "status", "observedGeneration")
+
+	return apimeta.FindStatusCondition(conditions, "Applied"), observed
+}
+
+func TestK8sCustomResourceSource_LoadWritesStatus(t *testing.T) {
+	client := newFakeDynamicClient(newAppConfig(3, map[string]any{
+		"replicas": int64(2),
+		"features": map[string]any{"beta": true},
+	}))
+
+	source, err := NewK8sCustomResourceSource(testCustomResourceOptions(client))
+	if err != nil {
+		t.Fatalf("NewK8sCustomResourceSource() error = %v", err)
+	}
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if data["replicas"] != int64(2) || data["features"].(map[string]any)["beta"] != true {
+		t.Errorf("Load() = %v, want .spec", data)
+	}
+
+	condition, observed := appConfigCondition(t, client)
+	if condition == nil || condition.Status != metav1.ConditionTrue || condition.Reason != "Applied" || observed != 3 {
+		t.Errorf("status condition = %+v, observedGeneration = %d", condition, observed)
+	}
+
+	// A second load with unchanged status must not write again
+	updates := 0
+
+	for _, action := range client.Actions() {
+		if action.GetSubresource() == "status" {
+			updates++
+		}
+	}
+
+	if _, err := source.Load(context.Background()); err != nil {
+		t.Fatalf("second Load() error = %v", err)
+	}
+
+	after := 0
+
+	for _, action := range client.Actions() {
+		if action.GetSubresource() == "status" {
+			after++
+		}
+	}
+
+	if after != updates {
+		t.Errorf("status written %d more times for an unchanged resource", after-updates)
+	}
+}
+
+func TestK8sCustomResourceSource_PathAndValidation(t *testing.T) {
+	client := newFakeDynamicClient(newAppConfig(1, map[string]any{
+		"config": map[string]any{"level": "verbose"},
+	}))
+
+	options := testCustomResourceOptions(client)
+	options.Path = "{.spec.config}"
+	options.Validator = func(config map[string]any) error {
+		if config["level"] != "info" && config["level"] != "debug" {
+			return fmt.Errorf("unsupported level %v", config["level"])
+		}
+
+		return nil
+	}
+
+	source, _ := NewK8sCustomResourceSource(options)
+
+	if _, err := source.Load(context.Background()); err == nil {
+		t.Fatal("Load() should fail validation")
+	}
+
+	condition, _ := appConfigCondition(t, client)
+	if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != "ValidationFailed" {
+		t.Errorf("status condition = %+v, want ValidationFailed", condition)
+	}
+
+	options.Path = ".spec.missing"
+	options.Validator = nil
+	source, _ = NewK8sCustomResourceSource(options)
+
+	if _, err := source.Load(context.Background()); err == nil {
+		t.Error("Load() should fail for a path that matches nothing")
+	}
+
+	condition, _ = appConfigCondition(t, client)
+	if condition == nil || condition.Reason != "InvalidConfig" {
+		t.Errorf("status condition = %+v, want InvalidConfig", condition)
+	}
+}
+
+func TestK8sCustomResourceSource_Watch(t *testing.T) {
+	client := newFakeDynamicClient(newAppConfig(1, map[string]any{"level": "info"}))
+
+	options := testCustomResourceOptions(client)
+	options.WatchEnabled = true
+
+	source, _ := NewK8sCustomResourceSource(options)
+	if _, err := source.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	updates := make(chan map[string]any, 16)
+	if err := source.Watch(context.Background(), func(data map[string]any) { updates <- data }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer func() { _ = source.StopWatch() }()
+
+	deadline := time.After(5 * time.Second)
+
+	// Retry the update: the fake client drops events sent before the
+	// informer's watch is registered
+	for generation := int64(2); ; generation++ {
+		current, err := client.Resource(appConfigGVR).Namespace("shop").Get(context.Background(), "checkout", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		current.SetGeneration(generation)
+		_ = unstructured.SetNestedField(current.Object, "debug", "spec", "level")
+
+		if _, err := client.Resource(appConfigGVR).Namespace("shop").Update(context.Background(), current, metav1.UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case data := <-updates:
+			if data["level"] != "debug" {
+				t.Fatalf("watched data = %v, want level=debug", data)
+			}
+
+			_, observed := appConfigCondition(t, client)
+			if observed < 2 {
+				t.Errorf("observedGeneration = %d, want the watched generation", observed)
+			}
+
+			return
+		case <-time.After(100 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("timed out waiting for custom resource update")
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package sources
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// k8sResyncCheckPeriod is how often shared informers check for handlers due
+// for a resync. It is the shortest effective K8sSourceOptions.ResyncPeriod.
+const k8sResyncCheckPeriod = 30 * time.Second
+
+// k8sInformerKey identifies informers shared by sources with the same client
+// and namespace.
+type k8sInformerKey struct {
+	client    kubernetes.Interface
+	namespace string
+}
+
+// k8sSharedInformers holds the ConfigMap and Secret informers of one
+// namespace. Informers list once, then watch from the last resourceVersion,
+// and relist automatically when the server answers 410 Gone.
+//
+// Secrets get one informer per name, filtered by a metadata.name field
+// selector, so only the named Secrets are cached and RBAC can be limited to
+// them through resourceNames.
+type k8sSharedInformers struct {
+	key        k8sInformerKey
+	factory    informers.SharedInformerFactory
+	stop       chan struct{}
+	sources    map[*K8sSource]struct{}
+	configMaps cache.SharedIndexInformer
+	secrets    map[string]informers.SharedInformerFactory
+}
+
+// k8sInformerRegistry tracks the shared informers in use. Informers are
+// started by the first watching source and shut down with the last.
+var k8sInformerRegistry = struct {
+	mu      sync.Mutex
+	entries map[k8sInformerKey]*k8sSharedInformers
+}{entries: make(map[k8sInformerKey]*k8sSharedInformers)}
+
+// acquireK8sInformers returns the started informers for the source's client
+// and namespace, creating them on first use. An informer is started for
+// each of secretNames that does not have one yet.
+func acquireK8sInformers(source *K8sSource, secretNames []string) *k8sSharedInformers {
+	k8sInformerRegistry.mu.Lock()
+	defer k8sInformerRegistry.mu.Unlock()
+
+	key := k8sInformerKey{client: source.client, namespace: source.namespace}
+
+	shared, ok := k8sInformerRegistry.entries[key]
+	if !ok {
+		shared = &k8sSharedInformers{
+			key:     key,
+			factory: informers.NewSharedInformerFactoryWithOptions(source.client, k8sResyncCheckPeriod, informers.WithNamespace(source.namespace)),
+			stop:    make(chan struct{}),
+			sources: make(map[*K8sSource]struct{}),
+			secrets: make(map[string]informers.SharedInformerFactory),
+		}
+		k8sInformerRegistry.entries[key] = shared
+	}
+
+	shared.sources[source] = struct{}{}
+
+	// Error handlers must be set before an informer starts
+	if shared.configMaps == nil {
+		shared.configMaps = shared.factory.Core().V1().ConfigMaps().Informer()
+		_ = shared.configMaps.SetWatchErrorHandlerWithContext(shared.handleWatchError)
+	}
+
+	for _, name := range secretNames {
+		if _, ok := shared.secrets[name]; ok {
+			continue
+		}
+
+		selector := fields.OneTermEqualSelector("metadata.name", name).String()
+		factory := informers.NewSharedInformerFactoryWithOptions(source.client, k8sResyncCheckPeriod,
+			informers.WithNamespace(source.namespace),
+			informers.WithTweakListOptions(func(options *metav1.ListOptions) { options.FieldSelector = selector }),
+		)
+		_ = factory.Core().V1().Secrets().Informer().SetWatchErrorHandlerWithContext(shared.handleWatchError)
+		factory.Start(shared.stop)
+
+		shared.secrets[name] = factory
+	}
+
+	// Start is a no-op for informers that are already running
+	shared.factory.Start(shared.stop)
+
+	return shared
+}
+
+// secretFactory returns the informer factory of the named Secret, or nil if
+// none was started.
+func (si *k8sSharedInformers) secretFactory(name string) informers.SharedInformerFactory {
+	k8sInformerRegistry.mu.Lock()
+	defer k8sInformerRegistry.mu.Unlock()
+
+	return si.secrets[name]
+}
+
+// release detaches a source and shuts the informers down when no source
+// uses them any more.
+func (si *k8sSharedInformers) release(source *K8sSource) {
+	k8sInformerRegistry.mu.Lock()
+
+	delete(si.sources, source)
+
+	if len(si.sources) > 0 {
+		k8sInformerRegistry.mu.Unlock()
+
+		return
+	}
+
+	delete(k8sInformerRegistry.entries, si.key)
+	close(si.stop)
+	k8sInformerRegistry.mu.Unlock()
+
+	si.factory.Shutdown()
+
+	for _, factory := range si.secrets {
+		factory.Shutdown()
+	}
+}
+
+// handleWatchError reports watch failures to every attached source. Expired
+// resource versions and closed connections are routine; the informer
+// relists or reconnects on its own.
+func (si *k8sSharedInformers) handleWatchError(ctx context.Context, r *cache.Reflector, err error) {
+	cache.DefaultWatchErrorHandler(ctx, r, err)
+
+	if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) || err == io.EOF || err == io.ErrUnexpectedEOF {
+		return
+	}
+
+	k8sInformerRegistry.mu.Lock()
+
+	sources := make([]*K8sSource, 0, len(si.sources))
+	for source := range si.sources {
+		sources = append(sources, source)
+	}
+
+	k8sInformerRegistry.mu.Unlock()
+
+	for _, source := range sources {
+		source.handleWatchError(err)
+	}
+}
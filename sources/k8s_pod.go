@@ -0,0 +1,704 @@
+package sources
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	configcore "github.com/xraph/confy/internal"
+	errors "github.com/xraph/go-utils/errs"
+	logger "github.com/xraph/go-utils/log"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// DefaultDownwardAPIPath is the conventional mount path of a Downward API
+	// volume.
+	DefaultDownwardAPIPath = "/etc/podinfo"
+
+	// serviceAccountNamespaceFile holds the pod namespace inside a cluster.
+	serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+)
+
+// PodMetadataSource exposes the metadata of the running pod, such as its
+// name, namespace, labels, annotations, node and resource limits, under a
+// prefix (pod.labels.app, pod.annotations.team, ...).
+//
+// Metadata is read from a Downward API volume, or from the API server when a
+// client is configured. Label and annotation keys are kept whole, so keys
+// containing dots are available from the labels and annotations maps.
+type PodMetadataSource struct {
+	name         string
+	client       kubernetes.Interface
+	podName      string
+	namespace    string
+	priority     int
+	last         map[string]any
+	watching     bool
+	watchStop    chan struct{}
+	logger       logger.Logger
+	errorHandler errors.ErrorHandler
+	options      PodMetadataSourceOptions
+	mu           sync.RWMutex
+}
+
+// PodMetadataSourceOptions contains options for pod metadata sources.
+type PodMetadataSourceOptions struct {
+	Name     string
+	Priority int
+	// Prefix is the key the metadata is mounted under. Defaults to "pod".
+	Prefix string
+	// DownwardAPIPath is the Downward API volume directory, used when no
+	// client is configured. The "labels" and "annotations" files are parsed
+	// as key="value" lines; other files become keys named after the file,
+	// split on dots (e.g. resources.app.limits.cpu).
+	DownwardAPIPath string
+	// Client reads the pod from the API server, for example the client of a
+	// K8sSource. InCluster or KubeConfig create one instead.
+	Client     kubernetes.Interface
+	InCluster  bool
+	KubeConfig string
+	// PodName defaults to $POD_NAME, then the hostname.
+	PodName string
+	// Namespace defaults to $POD_NAMESPACE, then the service account
+	// namespace, then "default".
+	Namespace    string
+	WatchEnabled bool
+	// WatchInterval is the Downward API polling interval. Defaults to 10s.
+	WatchInterval time.Duration
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+	Logger        logger.Logger
+	ErrorHandler  errors.ErrorHandler
+}
+
+// PodMetadataSourceConfig contains configuration for creating pod metadata
+// sources.
+type PodMetadataSourceConfig struct {
+	Priority        int           `json:"priority"          yaml:"priority"`
+	Prefix          string        `json:"prefix"            yaml:"prefix"`
+	DownwardAPIPath string        `json:"downward_api_path" yaml:"downward_api_path"`
+	InCluster       bool          `json:"in_cluster"        yaml:"in_cluster"`
+	KubeConfig      string        `json:"kubeconfig"        yaml:"kubeconfig"`
+	PodName         string        `json:"pod_name"          yaml:"pod_name"`
+	Namespace       string        `json:"namespace"         yaml:"namespace"`
+	WatchEnabled    bool          `json:"watch_enabled"     yaml:"watch_enabled"`
+	WatchInterval   time.Duration `json:"watch_interval"    yaml:"watch_interval"`
+}
+
+// NewPodMetadataSource creates a new pod metadata source.
+func NewPodMetadataSource(options PodMetadataSourceOptions) (configcore.ConfigSource, error) {
+	if options.Prefix == "" {
+		options.Prefix = "pod"
+	}
+
+	if options.DownwardAPIPath == "" {
+		options.DownwardAPIPath = DefaultDownwardAPIPath
+	}
+
+	if options.WatchInterval == 0 {
+		options.WatchInterval = 10 * time.Second
+	}
+
+	if options.RetryDelay == 0 {
+		options.RetryDelay = time.Second
+	}
+
+	if options.MaxRetryDelay == 0 {
+		options.MaxRetryDelay = 30 * time.Second
+	}
+
+	client := options.Client
+	if client == nil && (options.InCluster || options.KubeConfig != "") {
+		var err error
+
+		client, err = newK8sClientset(options.InCluster, options.KubeConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	podName := options.PodName
+	if podName == "" {
+		podName = os.Getenv("POD_NAME")
+	}
+
+	if podName == "" {
+		podName, _ = os.Hostname()
+	}
+
+	namespace := options.Namespace
+	if namespace == "" {
+		namespace = os.Getenv("POD_NAMESPACE")
+	}
+
+	if namespace == "" {
+		if data, err := os.ReadFile(serviceAccountNamespaceFile); err == nil {
+			namespace = strings.TrimSpace(string(data))
+		}
+	}
+
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	if client != nil && podName == "" {
+		return nil, configcore.ErrConfigError("pod name is required to read pod metadata from the API server", nil)
+	}
+
+	name := options.Name
+	if name == "" {
+		name = "pod-metadata"
+	}
+
+	return &PodMetadataSource{
+		name:         name,
+		client:       client,
+		podName:      podName,
+		namespace:    namespace,
+		priority:     options.Priority,
+		logger:       options.Logger,
+		errorHandler: options.ErrorHandler,
+		options:      options,
+	}, nil
+}
+
+// Name returns the source name.
+func (ps *PodMetadataSource) Name() string {
+	return ps.name
+}
+
+// GetName returns the source name (alias for Name).
+func (ps *PodMetadataSource) GetName() string {
+	return ps.name
+}
+
+// GetType returns the source type.
+func (ps *PodMetadataSource) GetType() string {
+	return "pod-metadata"
+}
+
+// IsAvailable returns true when a client is configured or the Downward API
+// volume exists.
+func (ps *PodMetadataSource) IsAvailable(ctx context.Context) bool {
+	if ps.client != nil {
+		return true
+	}
+
+	info, err := os.Stat(ps.options.DownwardAPIPath)
+
+	return err == nil && info.IsDir()
+}
+
+// Priority returns the source priority.
+func (ps *PodMetadataSource) Priority() int {
+	return ps.priority
+}
+
+// Load reads the pod metadata.
+func (ps *PodMetadataSource) Load(ctx context.Context) (map[string]any, error) {
+	var (
+		metadata map[string]any
+		err      error
+	)
+
+	if ps.client != nil {
+		metadata, err = ps.loadFromAPI(ctx)
+	} else {
+		metadata, err = ps.loadFromDownwardAPI()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	config := make(map[string]any)
+	setNestedPath(config, strings.Split(ps.options.Prefix, "."), metadata)
+
+	ps.mu.Lock()
+	ps.last = config
+	ps.mu.Unlock()
+
+	if ps.logger != nil {
+		ps.logger.Debug("pod metadata loaded",
+			logger.String("pod", ps.podName),
+			logger.String("namespace", ps.namespace),
+			logger.String("mode", ps.mode()),
+		)
+	}
+
+	return copyConfigMap(config), nil
+}
+
+// Watch starts watching the pod for label and annotation updates.
+func (ps *PodMetadataSource) Watch(ctx context.Context, callback func(map[string]any)) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if ps.watching {
+		return configcore.ErrConfigError("already watching pod metadata", nil)
+	}
+
+	if !ps.IsWatchable() {
+		return configcore.ErrConfigError("pod metadata watching is not enabled", nil)
+	}
+
+	ps.watchStop = make(chan struct{})
+	ps.watching = true
+
+	if ps.client != nil {
+		go ps.watchAPI(ctx, ps.watchStop, callback)
+	} else {
+		go ps.pollDownwardAPI(ctx, ps.watchStop, callback)
+	}
+
+	if ps.logger != nil {
+		ps.logger.Info("started watching pod metadata",
+			logger.String("pod", ps.podName),
+			logger.String("mode", ps.mode()),
+		)
+	}
+
+	return nil
+}
+
+// StopWatch stops watching the pod.
+func (ps *PodMetadataSource) StopWatch() error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if !ps.watching {
+		return nil
+	}
+
+	if ps.watchStop != nil {
+		close(ps.watchStop)
+		ps.watchStop = nil
+	}
+
+	ps.watching = false
+
+	if ps.logger != nil {
+		ps.logger.Info("stopped watching pod metadata",
+			logger.String("pod", ps.podName),
+		)
+	}
+
+	return nil
+}
+
+// Reload forces a reload of the pod metadata.
+func (ps *PodMetadataSource) Reload(ctx context.Context) error {
+	_, err := ps.Load(ctx)
+
+	return err
+}
+
+// IsWatchable returns true if watching is enabled.
+func (ps *PodMetadataSource) IsWatchable() bool {
+	return ps.options.WatchEnabled
+}
+
+// SupportsSecrets returns false.
+func (ps *PodMetadataSource) SupportsSecrets() bool {
+	return false
+}
+
+// GetSecret is not supported by pod metadata sources.
+func (ps *PodMetadataSource) GetSecret(ctx context.Context, key string) (string, error) {
+	return "", configcore.ErrConfigError("pod metadata source does not support secrets", nil)
+}
+
+// ReportMetadata reports the pod, namespace and how metadata is read.
+func (ps *PodMetadataSource) ReportMetadata(metadata *configcore.SourceMetadata) {
+	if metadata.Properties == nil {
+		metadata.Properties = make(map[string]any)
+	}
+
+	metadata.Properties["pod"] = ps.podName
+	metadata.Properties["namespace"] = ps.namespace
+	metadata.Properties["mode"] = ps.mode()
+
+	if ps.client == nil {
+		metadata.Properties["path"] = ps.options.DownwardAPIPath
+	}
+}
+
+// mode returns how metadata is read.
+func (ps *PodMetadataSource) mode() string {
+	if ps.client != nil {
+		return "api"
+	}
+
+	return "downward-api"
+}
+
+// loadFromAPI reads the pod from the API server.
+func (ps *PodMetadataSource) loadFromAPI(ctx context.Context) (map[string]any, error) {
+	pod, err := ps.client.CoreV1().Pods(ps.namespace).Get(ctx, ps.podName, metav1.GetOptions{})
+	if err != nil {
+		return nil, configcore.ErrConfigError(fmt.Sprintf("failed to get pod %s/%s", ps.namespace, ps.podName), err)
+	}
+
+	return podToConfig(pod), nil
+}
+
+// podToConfig converts a pod to metadata keys.
+func podToConfig(pod *corev1.Pod) map[string]any {
+	labels := make(map[string]any, len(pod.Labels))
+	for key, value := range pod.Labels {
+		labels[key] = value
+	}
+
+	annotations := make(map[string]any, len(pod.Annotations))
+	for key, value := range pod.Annotations {
+		annotations[key] = value
+	}
+
+	resources := make(map[string]any, len(pod.Spec.Containers))
+
+	for _, container := range pod.Spec.Containers {
+		containerResources := make(map[string]any)
+
+		if limits := resourceListToConfig(container.Resources.Limits); len(limits) > 0 {
+			containerResources["limits"] = limits
+		}
+
+		if requests := resourceListToConfig(container.Resources.Requests); len(requests) > 0 {
+			containerResources["requests"] = requests
+		}
+
+		resources[container.Name] = containerResources
+	}
+
+	return map[string]any{
+		"name":            pod.Name,
+		"namespace":       pod.Namespace,
+		"uid":             string(pod.UID),
+		"node_name":       pod.Spec.NodeName,
+		"service_account": pod.Spec.ServiceAccountName,
+		"ip":              pod.Status.PodIP,
+		"host_ip":         pod.Status.HostIP,
+		"phase":           string(pod.Status.Phase),
+		"labels":          labels,
+		"annotations":     annotations,
+		"resources":       resources,
+	}
+}
+
+// resourceListToConfig converts resource quantities to strings such as
+// "500m" and "256Mi".
+func resourceListToConfig(list corev1.ResourceList) map[string]any {
+	result := make(map[string]any, len(list))
+
+	for name, quantity := range list {
+		result[string(name)] = quantity.String()
+	}
+
+	return result
+}
+
+// loadFromDownwardAPI reads the files of a Downward API volume.
+func (ps *PodMetadataSource) loadFromDownwardAPI() (map[string]any, error) {
+	dir := ps.options.DownwardAPIPath
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, configcore.ErrConfigError("failed to read Downward API volume "+dir, err)
+	}
+
+	metadata := make(map[string]any)
+
+	for _, entry := range entries {
+		name := entry.Name()
+
+		// Skip the kubelet's ..data and timestamped bookkeeping entries
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, configcore.ErrConfigError("failed to read Downward API file "+path, err)
+		}
+
+		switch name {
+		case "labels", "annotations":
+			values, err := parseDownwardAPIMap(content)
+			if err != nil {
+				return nil, configcore.ErrConfigError("failed to parse Downward API file "+path, err)
+			}
+
+			metadata[name] = values
+		default:
+			value := strings.TrimSpace(string(content))
+
+			var parsed any = value
+			if number, err := strconv.ParseInt(value, 10, 64); err == nil {
+				parsed = number
+			}
+
+			setNestedPath(metadata, strings.Split(name, "."), parsed)
+		}
+	}
+
+	return metadata, nil
+}
+
+// parseDownwardAPIMap parses the key="value" lines the kubelet writes for
+// labels and annotations.
+func parseDownwardAPIMap(content []byte) (map[string]any, error) {
+	result := make(map[string]any)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		key, quoted, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid line %q", line)
+		}
+
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+
+		result[key] = value
+	}
+
+	return result, scanner.Err()
+}
+
+// pollDownwardAPI polls the Downward API volume, which the kubelet updates
+// when labels or annotations change.
+func (ps *PodMetadataSource) pollDownwardAPI(ctx context.Context, stop chan struct{}, callback func(map[string]any)) {
+	defer func() {
+		if r := recover(); r != nil {
+			if ps.logger != nil {
+				ps.logger.Error("panic in pod metadata watch loop",
+					logger.String("pod", ps.podName),
+					logger.Any("panic", r),
+				)
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(ps.options.WatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-ticker.C:
+			ps.reloadIfChanged(ctx, callback)
+		}
+	}
+}
+
+// watchAPI watches the pod on the API server, re-establishing the watch
+// with backoff when it fails or closes.
+func (ps *PodMetadataSource) watchAPI(ctx context.Context, stop chan struct{}, callback func(map[string]any)) {
+	defer func() {
+		if r := recover(); r != nil {
+			if ps.logger != nil {
+				ps.logger.Error("panic in pod metadata watch loop",
+					logger.String("pod", ps.podName),
+					logger.Any("panic", r),
+				)
+			}
+		}
+	}()
+
+	attempt := 0
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		default:
+		}
+
+		watcher, err := ps.client.CoreV1().Pods(ps.namespace).Watch(ctx, metav1.ListOptions{
+			FieldSelector: fields.OneTermEqualSelector("metadata.name", ps.podName).String(),
+		})
+		if err != nil {
+			attempt++
+			ps.handleWatchError(err)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-stop:
+				return
+			case <-time.After(backoffDelay(attempt, ps.options.RetryDelay, ps.options.MaxRetryDelay)):
+				continue
+			}
+		}
+
+		attempt = 0
+
+		// Catch up on changes made while no watch was established
+		ps.reloadIfChanged(ctx, callback)
+
+		if !ps.consumePodEvents(ctx, stop, watcher, callback) {
+			return
+		}
+
+		if ps.logger != nil {
+			ps.logger.Debug("pod watch closed, re-establishing",
+				logger.String("pod", ps.podName),
+			)
+		}
+	}
+}
+
+// consumePodEvents applies watch events until the watch closes. It returns
+// false when watching should stop.
+func (ps *PodMetadataSource) consumePodEvents(ctx context.Context, stop chan struct{}, watcher watch.Interface, callback func(map[string]any)) bool {
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-stop:
+			return false
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return true
+			}
+
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				pod, ok := event.Object.(*corev1.Pod)
+				if !ok || pod.Name != ps.podName {
+					continue
+				}
+
+				config := make(map[string]any)
+				setNestedPath(config, strings.Split(ps.options.Prefix, "."), podToConfig(pod))
+				ps.notifyIfChanged(config, callback)
+			case watch.Error:
+				ps.handleWatchError(fmt.Errorf("pod watch error: %v", event.Object))
+
+				return true
+			}
+		}
+	}
+}
+
+// reloadIfChanged loads the metadata and notifies the callback when it
+// differs from the last load.
+func (ps *PodMetadataSource) reloadIfChanged(ctx context.Context, callback func(map[string]any)) {
+	ps.mu.RLock()
+	previous := ps.last
+	ps.mu.RUnlock()
+
+	config, err := ps.Load(ctx)
+	if err != nil {
+		ps.handleWatchError(err)
+
+		return
+	}
+
+	if previous != nil && !reflect.DeepEqual(previous, config) && callback != nil {
+		callback(config)
+	}
+}
+
+// notifyIfChanged stores config and notifies the callback when it differs
+// from the last load.
+func (ps *PodMetadataSource) notifyIfChanged(config map[string]any, callback func(map[string]any)) {
+	ps.mu.Lock()
+	changed := !reflect.DeepEqual(ps.last, config)
+	ps.last = config
+	ps.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	if ps.logger != nil {
+		ps.logger.Info("pod metadata changed",
+			logger.String("pod", ps.podName),
+		)
+	}
+
+	if callback != nil {
+		callback(copyConfigMap(config))
+	}
+}
+
+// handleWatchError handles errors during watching.
+func (ps *PodMetadataSource) handleWatchError(err error) {
+	if ps.logger != nil {
+		ps.logger.Error("pod metadata watch error",
+			logger.String("pod", ps.podName),
+			logger.Error(err),
+		)
+	}
+
+	if ps.errorHandler != nil {
+		_ = ps.errorHandler.HandleError(context.Background(), configcore.ErrConfigError("pod metadata watch error for "+ps.podName, err))
+	}
+}
+
+// PodMetadataSourceFactory creates pod metadata sources.
+type PodMetadataSourceFactory struct {
+	logger       logger.Logger
+	errorHandler errors.ErrorHandler
+}
+
+// NewPodMetadataSourceFactory creates a new pod metadata source factory.
+func NewPodMetadataSourceFactory(logger logger.Logger, errorHandler errors.ErrorHandler) *PodMetadataSourceFactory {
+	return &PodMetadataSourceFactory{
+		logger:       logger,
+		errorHandler: errorHandler,
+	}
+}
+
+// CreateFromConfig creates a pod metadata source from configuration.
+func (factory *PodMetadataSourceFactory) CreateFromConfig(config PodMetadataSourceConfig) (configcore.ConfigSource, error) {
+	return NewPodMetadataSource(PodMetadataSourceOptions{
+		Priority:        config.Priority,
+		Prefix:          config.Prefix,
+		DownwardAPIPath: config.DownwardAPIPath,
+		InCluster:       config.InCluster,
+		KubeConfig:      config.KubeConfig,
+		PodName:         config.PodName,
+		Namespace:       config.Namespace,
+		WatchEnabled:    config.WatchEnabled,
+		WatchInterval:   config.WatchInterval,
+		Logger:          factory.logger,
+		ErrorHandler:    factory.errorHandler,
+	})
+}
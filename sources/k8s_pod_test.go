@@ -0,0 +1,196 @@
+package sources
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	configcore "github.com/xraph/confy/internal"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// writeDownwardAPIFile writes a file into a fake Downward API volume.
+func writeDownwardAPIFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPodMetadataSource_DownwardAPI(t *testing.T) {
+	dir := t.TempDir()
+
+	writeDownwardAPIFile(t, dir, "labels", "app=\"checkout\"\napp.kubernetes.io/version=\"1.4.0\"\n")
+	writeDownwardAPIFile(t, dir, "annotations", "features.example.com/beta=\"true\"\nnote=\"line\\none\"\n")
+	writeDownwardAPIFile(t, dir, "name", "checkout-7d9f")
+	writeDownwardAPIFile(t, dir, "node_name", "node-a\n")
+	writeDownwardAPIFile(t, dir, "resources.app.limits.memory", "268435456\n")
+	writeDownwardAPIFile(t, dir, "..data", "ignored")
+
+	source, err := NewPodMetadataSource(PodMetadataSourceOptions{DownwardAPIPath: dir, Namespace: "shop"})
+	if err != nil {
+		t.Fatalf("NewPodMetadataSource() error = %v", err)
+	}
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	pod, _ := data["pod"].(map[string]any)
+	labels, _ := pod["labels"].(map[string]any)
+	annotations, _ := pod["annotations"].(map[string]any)
+
+	if labels["app"] != "checkout" || labels["app.kubernetes.io/version"] != "1.4.0" {
+		t.Errorf("labels = %v", labels)
+	}
+
+	if annotations["features.example.com/beta"] != "true" || annotations["note"] != "line\none" {
+		t.Errorf("annotations = %v", annotations)
+	}
+
+	if pod["name"] != "checkout-7d9f" || pod["node_name"] != "node-a" {
+		t.Errorf("pod = %v", pod)
+	}
+
+	limits := pod["resources"].(map[string]any)["app"].(map[string]any)["limits"].(map[string]any)
+	if limits["memory"] != int64(268435456) {
+		t.Errorf("limits = %v, want numeric memory", limits)
+	}
+
+	if _, ok := pod[""]; ok {
+		t.Error("hidden kubelet entries should be skipped")
+	}
+}
+
+func TestPodMetadataSource_DownwardAPIWatch(t *testing.T) {
+	dir := t.TempDir()
+	writeDownwardAPIFile(t, dir, "annotations", "rollout=\"stable\"\n")
+
+	source, _ := NewPodMetadataSource(PodMetadataSourceOptions{
+		DownwardAPIPath: dir,
+		Prefix:          "runtime.pod",
+		WatchEnabled:    true,
+		WatchInterval:   20 * time.Millisecond,
+	})
+
+	if _, err := source.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	updates := make(chan map[string]any, 4)
+	if err := source.Watch(context.Background(), func(data map[string]any) { updates <- data }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer func() { _ = source.StopWatch() }()
+
+	writeDownwardAPIFile(t, dir, "annotations", "rollout=\"canary\"\n")
+
+	select {
+	case data := <-updates:
+		pod := data["runtime"].(map[string]any)["pod"].(map[string]any)
+		if pod["annotations"].(map[string]any)["rollout"] != "canary" {
+			t.Errorf("watched data = %v, want rollout=canary", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for annotation update")
+	}
+}
+
+func TestPodMetadataSource_API(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "checkout-7d9f",
+			Namespace:   "shop",
+			UID:         "1234",
+			Labels:      map[string]string{"app": "checkout"},
+			Annotations: map[string]string{"rollout": "stable"},
+		},
+		Spec: corev1.PodSpec{
+			NodeName: "node-a",
+			Containers: []corev1.Container{{
+				Name: "app",
+				Resources: corev1.ResourceRequirements{
+					Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+					Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")},
+				},
+			}},
+		},
+	}
+
+	client := fake.NewClientset(pod)
+	podWatch := watch.NewFake()
+	client.PrependWatchReactor("pods", k8stesting.DefaultWatchReactor(podWatch, nil))
+
+	source, err := NewPodMetadataSource(PodMetadataSourceOptions{
+		Client:       client,
+		PodName:      "checkout-7d9f",
+		Namespace:    "shop",
+		WatchEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("NewPodMetadataSource() error = %v", err)
+	}
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	loaded := data["pod"].(map[string]any)
+	resources := loaded["resources"].(map[string]any)["app"].(map[string]any)
+
+	if loaded["node_name"] != "node-a" || loaded["labels"].(map[string]any)["app"] != "checkout" {
+		t.Errorf("pod = %v", loaded)
+	}
+
+	if resources["limits"].(map[string]any)["cpu"] != "500m" || resources["requests"].(map[string]any)["memory"] != "256Mi" {
+		t.Errorf("resources = %v", resources)
+	}
+
+	metadata := &configcore.SourceMetadata{}
+	source.(configcore.SourceMetadataReporter).ReportMetadata(metadata)
+
+	if metadata.Properties["mode"] != "api" {
+		t.Errorf("metadata = %v, want api mode", metadata.Properties)
+	}
+
+	updates := make(chan map[string]any, 4)
+	if err := source.Watch(context.Background(), func(data map[string]any) { updates <- data }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer func() { _ = source.StopWatch() }()
+
+	// Updates that leave the exposed metadata unchanged must not notify
+	unchanged := pod.DeepCopy()
+	unchanged.ResourceVersion = "2"
+	podWatch.Modify(unchanged)
+
+	changed := pod.DeepCopy()
+	changed.Annotations["rollout"] = "canary"
+	podWatch.Modify(changed)
+
+	select {
+	case data := <-updates:
+		annotations := data["pod"].(map[string]any)["annotations"].(map[string]any)
+		if annotations["rollout"] != "canary" {
+			t.Errorf("watched annotations = %v, want rollout=canary", annotations)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for annotation update")
+	}
+
+	select {
+	case data := <-updates:
+		t.Errorf("unexpected extra update %v", data)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
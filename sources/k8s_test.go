@@ -0,0 +1,359 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func testConfigMap(name string, labels, data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "app", Labels: labels},
+		Data:       data,
+	}
+}
+
+// updateConfigMapUntil applies an update until the watch delivers a matching
+// configuration; the fake clientset drops events sent before an informer's
+// watch is registered.
+func updateConfigMapUntil(t *testing.T, client kubernetes.Interface, configMap *corev1.ConfigMap, updates <-chan map[string]any, match func(map[string]any) bool) {
+	t.Helper()
+
+	deadline := time.After(5 * time.Second)
+
+	for {
+		if _, err := client.CoreV1().ConfigMaps("app").Update(context.Background(), configMap, metav1.UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case data := <-updates:
+			if match(data) {
+				return
+			}
+		case <-time.After(100 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("timed out waiting for ConfigMap update")
+		}
+	}
+}
+
+func TestK8sSource_LoadWithInjectedClient(t *testing.T) {
+	client := fake.NewClientset(
+		testConfigMap("app-config", nil, map[string]string{"server.port": "8080", "features": `{"beta": true}`}),
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "app"},
+			Data:       map[string][]byte{"password": []byte("hunter2")},
+		},
+	)
+
+	source, err := NewK8sSource(K8sSourceOptions{
+		Client:         client,
+		Namespace:      "app",
+		ConfigMapNames: []string{"app-config", "missing"},
+		SecretNames:    []string{"db"},
+	})
+	if err != nil {
+		t.Fatalf("NewK8sSource() error = %v", err)
+	}
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	appConfig, _ := data["app-config"].(map[string]any)
+	server, _ := appConfig["server"].(map[string]any)
+	features, _ := appConfig["features"].(map[string]any)
+
+	if server["port"] != "8080" || features["beta"] != true {
+		t.Errorf("app-config = %v", appConfig)
+	}
+
+	secrets, _ := data["secrets"].(map[string]any)
+	if secrets["db"].(map[string]any)["password"] != "hunter2" {
+		t.Errorf("secrets = %v", secrets)
+	}
+
+	if source.(*K8sSource).Client() != client {
+		t.Error("Client() should return the injected client")
+	}
+
+	if _, err := NewK8sSource(K8sSourceOptions{Client: client, LabelSelector: "app in ("}); err == nil {
+		t.Error("NewK8sSource() should reject invalid label selectors")
+	}
+}
+
+func TestK8sSource_InformerWatch(t *testing.T) {
+	configMap := testConfigMap("app-config", map[string]string{"team": "payments"}, map[string]string{"level": "info"})
+	client := fake.NewClientset(configMap, testConfigMap("other", nil, map[string]string{"level": "x"}))
+
+	source, _ := NewK8sSource(K8sSourceOptions{
+		Client:        client,
+		Namespace:     "app",
+		LabelSelector: "team=payments",
+		WatchEnabled:  true,
+		ResyncPeriod:  time.Hour,
+	})
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if _, ok := data["other"]; ok {
+		t.Errorf("Load() = %v, want label selector applied", data)
+	}
+
+	updates := make(chan map[string]any, 16)
+	if err := source.Watch(context.Background(), func(data map[string]any) { updates <- data }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer func() { _ = source.StopWatch() }()
+
+	updated := configMap.DeepCopy()
+	updated.Data["level"] = "debug"
+
+	updateConfigMapUntil(t, client, updated, updates, func(data map[string]any) bool {
+		return data["app-config"].(map[string]any)["level"] == "debug"
+	})
+
+	if !source.(*K8sSource).isRelevant(configMap) || source.(*K8sSource).isRelevant(testConfigMap("other", nil, nil)) {
+		t.Error("isRelevant() should follow the label selector")
+	}
+}
+
+func TestK8sSource_SharedInformers(t *testing.T) {
+	configMap := testConfigMap("app-config", nil, map[string]string{"level": "info"})
+	client := fake.NewClientset(configMap)
+
+	newSource := func(name string) *K8sSource {
+		source, err := NewK8sSource(K8sSourceOptions{
+			Name:           name,
+			Client:         client,
+			Namespace:      "app",
+			ConfigMapNames: []string{"app-config"},
+			WatchEnabled:   true,
+		})
+		if err != nil {
+			t.Fatalf("NewK8sSource() error = %v", err)
+		}
+
+		if _, err := source.Load(context.Background()); err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+
+		return source.(*K8sSource)
+	}
+
+	first, second := newSource("first"), newSource("second")
+
+	firstUpdates := make(chan map[string]any, 16)
+	secondUpdates := make(chan map[string]any, 16)
+
+	_ = first.Watch(context.Background(), func(data map[string]any) { firstUpdates <- data })
+	_ = second.Watch(context.Background(), func(data map[string]any) { secondUpdates <- data })
+
+	if first.informers == nil || first.informers != second.informers {
+		t.Fatal("sources with the same client and namespace should share informers")
+	}
+
+	_ = first.StopWatch()
+
+	updated := configMap.DeepCopy()
+	updated.Data["level"] = "warn"
+
+	updateConfigMapUntil(t, client, updated, secondUpdates, func(data map[string]any) bool {
+		return data["app-config"].(map[string]any)["level"] == "warn"
+	})
+
+	select {
+	case data := <-firstUpdates:
+		t.Errorf("stopped source received %v", data)
+	default:
+	}
+
+	_ = second.StopWatch()
+
+	k8sInformerRegistry.mu.Lock()
+	remaining := len(k8sInformerRegistry.entries)
+	k8sInformerRegistry.mu.Unlock()
+
+	if remaining != 0 {
+		t.Errorf("registry has %d entries after the last source stopped", remaining)
+	}
+}
+
+func TestK8sSource_SecretInformerPerName(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "app"},
+		Data:       map[string][]byte{"password": []byte("hunter2")},
+	}
+	client := fake.NewClientset(secret, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "app"}})
+
+	source, _ := NewK8sSource(K8sSourceOptions{
+		Client:       client,
+		Namespace:    "app",
+		SecretNames:  []string{"db"},
+		WatchEnabled: true,
+	})
+
+	if _, err := source.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	updates := make(chan map[string]any, 16)
+	if err := source.Watch(context.Background(), func(data map[string]any) { updates <- data }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer func() { _ = source.StopWatch() }()
+
+	deadline := time.After(5 * time.Second)
+
+	for {
+		updated := secret.DeepCopy()
+		updated.Data["password"] = []byte("rotated")
+
+		if _, err := client.CoreV1().Secrets("app").Update(context.Background(), updated, metav1.UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case data := <-updates:
+			if data["secrets"].(map[string]any)["db"].(map[string]any)["password"] != "rotated" {
+				continue
+			}
+		case <-time.After(100 * time.Millisecond):
+			continue
+		case <-deadline:
+			t.Fatal("timed out waiting for Secret update")
+		}
+
+		break
+	}
+
+	// Secrets are only ever listed and watched by name
+	listed := 0
+
+	for _, action := range client.Actions() {
+		if action.GetResource().Resource != "secrets" {
+			continue
+		}
+
+		var fieldSelector string
+
+		switch action := action.(type) {
+		case k8stesting.ListAction:
+			fieldSelector = action.GetListRestrictions().Fields.String()
+		case k8stesting.WatchAction:
+			fieldSelector = action.GetWatchRestrictions().Fields.String()
+		default:
+			continue
+		}
+
+		if fieldSelector != "metadata.name=db" {
+			t.Errorf("%s secrets with field selector %q, want metadata.name=db", action.GetVerb(), fieldSelector)
+		}
+
+		listed++
+	}
+
+	if listed == 0 {
+		t.Error("the Secret informer never listed or watched")
+	}
+}
+
+func TestK8sSource_DocumentLayout(t *testing.T) {
+	base := testConfigMap("base", nil, map[string]string{
+		"application.yaml": "server:\n  port: 8080\n  host: localhost\nlevel: info\n",
+		"level":            "warn",
+	})
+	base.BinaryData = map[string][]byte{"extra.json": []byte(`{"cache": {"ttl": 60}}`)}
+
+	override := testConfigMap("override", nil, map[string]string{
+		"application.toml": "[server]\nport = 9090\n",
+	})
+
+	client := fake.NewClientset(base, override, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "app"},
+		Data:       map[string][]byte{"credentials.yaml": []byte("user: app\npassword: hunter2\n")},
+	})
+
+	source, err := NewK8sSource(K8sSourceOptions{
+		Client:         client,
+		Namespace:      "app",
+		ConfigMapNames: []string{"base", "override"},
+		SecretNames:    []string{"db"},
+		ConfigMapPath:  ".",
+		SecretPath:     "database",
+		ParseDocuments: true,
+		BinaryData:     true,
+	})
+	if err != nil {
+		t.Fatalf("NewK8sSource() error = %v", err)
+	}
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	server, _ := data["server"].(map[string]any)
+	if fmt.Sprint(server["port"]) != "9090" || server["host"] != "localhost" {
+		t.Errorf("server = %v, want later ConfigMap merged over earlier", server)
+	}
+
+	if data["level"] != "warn" {
+		t.Errorf("level = %v, want plain key applied over documents", data["level"])
+	}
+
+	cache, _ := data["cache"].(map[string]any)
+	if cache["ttl"] == nil {
+		t.Errorf("data = %v, want binaryData document merged", data)
+	}
+
+	database, _ := data["database"].(map[string]any)
+	if database["user"] != "app" || database["password"] != "hunter2" {
+		t.Errorf("database = %v, want Secret document under secret path", database)
+	}
+
+	if _, ok := data["base"]; ok {
+		t.Error("ConfigMaps should not be nested by name at the root path")
+	}
+}
+
+func TestK8sSource_DefaultLayout(t *testing.T) {
+	client := fake.NewClientset(
+		testConfigMap("app.config", nil, map[string]string{"application.yaml": "level: info\n"}),
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "app"},
+			Data:       map[string][]byte{"password": []byte("hunter2")},
+		},
+	)
+
+	source, _ := NewK8sSource(K8sSourceOptions{Client: client, Namespace: "app", SecretNames: []string{"db"}})
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	appConfig, _ := data["app.config"].(map[string]any)
+	application, _ := appConfig["application"].(map[string]any)
+
+	if application["yaml"] != "level: info\n" {
+		t.Errorf("app.config = %v, want keys nested by name without document parsing", appConfig)
+	}
+
+	secrets, _ := data["secrets"].(map[string]any)
+	if secrets["db"].(map[string]any)["password"] != "hunter2" {
+		t.Errorf("secrets = %v, want secrets.<name>", secrets)
+	}
+}
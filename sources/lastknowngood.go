@@ -0,0 +1,589 @@
+package sources
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	configcore "github.com/xraph/confy/internal"
+	errors "github.com/xraph/go-utils/errs"
+	logger "github.com/xraph/go-utils/log"
+)
+
+// Encryptor encrypts and decrypts persisted configuration.
+// *confy.SecretEncryptor satisfies this interface.
+type Encryptor interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(ciphertext string) (string, error)
+}
+
+// LastKnownGoodSource decorates a source with a local cache of its last
+// successful Load. When the upstream fails, the cached data is served and the
+// source is reported as degraded until the upstream recovers.
+type LastKnownGoodSource struct {
+	source           configcore.ConfigSource
+	path             string
+	encryptor        Encryptor
+	degraded         bool
+	degradedSince    time.Time
+	lastError        error
+	cachedAt         time.Time
+	recoveryInterval time.Duration
+	watching         bool
+	watchPending     bool
+	watchStop        chan struct{}
+	logger           logger.Logger
+	errorHandler     errors.ErrorHandler
+	mu               sync.RWMutex
+	watchMu          sync.Mutex
+}
+
+// LastKnownGoodOptions contains options for last-known-good caching.
+type LastKnownGoodOptions struct {
+	// Path is the cache file. Defaults to a file named after the source in
+	// the user cache directory.
+	Path string
+	// Encryptor optionally encrypts the cache file at rest.
+	Encryptor Encryptor
+	// RecoveryInterval is how often a degraded, watched source retries the
+	// upstream. Defaults to 30 seconds.
+	RecoveryInterval time.Duration
+	Logger           logger.Logger
+	ErrorHandler     errors.ErrorHandler
+}
+
+// lastKnownGoodEnvelope is the on-disk cache format.
+type lastKnownGoodEnvelope struct {
+	Source  string         `json:"source"`
+	SavedAt time.Time      `json:"saved_at"`
+	Data    map[string]any `json:"data"`
+}
+
+// NewLastKnownGoodSource wraps source with a last-known-good cache. The
+// wrapper keeps the name, priority and type of the wrapped source.
+func NewLastKnownGoodSource(source configcore.ConfigSource, options LastKnownGoodOptions) (configcore.ConfigSource, error) {
+	if source == nil {
+		return nil, configcore.ErrConfigError("last-known-good source requires a source", nil)
+	}
+
+	path := options.Path
+	if path == "" {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, configcore.ErrConfigError("failed to determine cache directory for "+source.Name(), err)
+		}
+
+		path = filepath.Join(cacheDir, "confy", sanitizeCacheName(source.Name())+".json")
+	}
+
+	if options.RecoveryInterval == 0 {
+		options.RecoveryInterval = 30 * time.Second
+	}
+
+	return &LastKnownGoodSource{
+		source:           source,
+		path:             path,
+		encryptor:        options.Encryptor,
+		recoveryInterval: options.RecoveryInterval,
+		logger:           options.Logger,
+		errorHandler:     options.ErrorHandler,
+	}, nil
+}
+
+// Name returns the wrapped source name.
+func (lk *LastKnownGoodSource) Name() string {
+	return lk.source.Name()
+}
+
+// GetName returns the source name (alias for Name).
+func (lk *LastKnownGoodSource) GetName() string {
+	return lk.source.Name()
+}
+
+// GetType returns the wrapped source type.
+func (lk *LastKnownGoodSource) GetType() string {
+	return lk.source.GetType()
+}
+
+// IsAvailable reports whether the upstream or the cache can serve data.
+func (lk *LastKnownGoodSource) IsAvailable(ctx context.Context) bool {
+	if lk.source.IsAvailable(ctx) {
+		return true
+	}
+
+	_, err := os.Stat(lk.path)
+
+	return err == nil
+}
+
+// Priority returns the wrapped source priority.
+func (lk *LastKnownGoodSource) Priority() int {
+	return lk.source.Priority()
+}
+
+// Load loads from the upstream, persisting the result. If the upstream
+// fails and a cached copy exists, the cached copy is returned instead.
+func (lk *LastKnownGoodSource) Load(ctx context.Context) (map[string]any, error) {
+	data, err := lk.source.Load(ctx)
+	if err == nil {
+		lk.recordSuccess(data)
+
+		return data, nil
+	}
+
+	cached, cacheErr := lk.readCache()
+	if cacheErr != nil {
+		if lk.logger != nil && !os.IsNotExist(cacheErr) {
+			lk.logger.Warn("failed to read last-known-good cache",
+				logger.String("source", lk.Name()),
+				logger.String("path", lk.path),
+				logger.Error(cacheErr),
+			)
+		}
+
+		lk.mu.Lock()
+		lk.lastError = err
+		lk.mu.Unlock()
+
+		return nil, err
+	}
+
+	lk.mu.Lock()
+	if !lk.degraded {
+		lk.degradedSince = time.Now()
+	}
+
+	lk.degraded = true
+	lk.lastError = err
+	lk.cachedAt = cached.SavedAt
+	lk.mu.Unlock()
+
+	if lk.logger != nil {
+		lk.logger.Warn("source unavailable, serving last-known-good configuration",
+			logger.String("source", lk.Name()),
+			logger.String("path", lk.path),
+			logger.Time("saved_at", cached.SavedAt),
+			logger.Error(err),
+		)
+	}
+
+	if lk.errorHandler != nil {
+		_ = lk.errorHandler.HandleError(ctx, configcore.ErrSourceError(lk.Name(), "load", err))
+	}
+
+	return cached.Data, nil
+}
+
+// Watch watches the upstream and, while degraded, periodically retries it
+// so that recovery is picked up without a manual reload. If the upstream
+// watch cannot start while degraded, it is restarted once the upstream
+// recovers.
+func (lk *LastKnownGoodSource) Watch(ctx context.Context, callback func(map[string]any)) error {
+	lk.mu.Lock()
+	if lk.watching {
+		lk.mu.Unlock()
+
+		return configcore.ErrConfigError("already watching "+lk.Name(), nil)
+	}
+
+	stop := make(chan struct{})
+	lk.watchStop = stop
+	lk.watching = true
+	degraded := lk.degraded
+	lk.mu.Unlock()
+
+	if lk.source.IsWatchable() {
+		lk.watchMu.Lock()
+		err := lk.watchUpstream(ctx, callback)
+		lk.watchMu.Unlock()
+
+		if err != nil {
+			if !degraded {
+				lk.mu.Lock()
+				lk.watching = false
+				lk.watchStop = nil
+				lk.mu.Unlock()
+
+				return err
+			}
+
+			// A degraded upstream may refuse to start watching; the recovery
+			// loop restarts the watch once it is reachable again.
+			lk.mu.Lock()
+			lk.watchPending = true
+			lk.lastError = err
+			lk.mu.Unlock()
+		}
+	}
+
+	go lk.recoveryLoop(ctx, stop, callback)
+
+	return nil
+}
+
+// StopWatch stops watching the upstream.
+func (lk *LastKnownGoodSource) StopWatch() error {
+	lk.mu.Lock()
+	if !lk.watching {
+		lk.mu.Unlock()
+
+		return nil
+	}
+
+	if lk.watchStop != nil {
+		close(lk.watchStop)
+		lk.watchStop = nil
+	}
+
+	lk.watching = false
+	lk.watchPending = false
+	lk.mu.Unlock()
+
+	lk.watchMu.Lock()
+	defer lk.watchMu.Unlock()
+
+	return lk.source.StopWatch()
+}
+
+// Reload forces a reload, falling back to the cache on failure.
+func (lk *LastKnownGoodSource) Reload(ctx context.Context) error {
+	_, err := lk.Load(ctx)
+
+	return err
+}
+
+// IsWatchable returns true if the wrapped source is watchable.
+func (lk *LastKnownGoodSource) IsWatchable() bool {
+	return lk.source.IsWatchable()
+}
+
+// SupportsSecrets returns true if the wrapped source supports secrets.
+func (lk *LastKnownGoodSource) SupportsSecrets() bool {
+	return lk.source.SupportsSecrets()
+}
+
+// GetSecret retrieves a secret from the wrapped source.
+func (lk *LastKnownGoodSource) GetSecret(ctx context.Context, key string) (string, error) {
+	return lk.source.GetSecret(ctx, key)
+}
+
+// IsWritable returns true if the wrapped source accepts writes.
+func (lk *LastKnownGoodSource) IsWritable() bool {
+	_, ok := writableInner(lk.source)
+
+	return ok
+}
+
+// Persist forwards the write to the wrapped source. The cache is refreshed by
+// the next successful Load or watch update.
+func (lk *LastKnownGoodSource) Persist(ctx context.Context, key string, value any) error {
+	writable, ok := writableInner(lk.source)
+	if !ok {
+		return configcore.ErrConfigError("source "+lk.source.Name()+" is not writable", nil)
+	}
+
+	return writable.Persist(ctx, key, value)
+}
+
+// IsDegraded returns true while cached data is being served.
+func (lk *LastKnownGoodSource) IsDegraded() bool {
+	lk.mu.RLock()
+	defer lk.mu.RUnlock()
+
+	return lk.degraded
+}
+
+// GetCachePath returns the cache file path.
+func (lk *LastKnownGoodSource) GetCachePath() string {
+	return lk.path
+}
+
+// Unwrap returns the wrapped source.
+func (lk *LastKnownGoodSource) Unwrap() configcore.ConfigSource {
+	return lk.source
+}
+
+// ReportMetadata reports degraded state in the source metadata.
+func (lk *LastKnownGoodSource) ReportMetadata(metadata *configcore.SourceMetadata) {
+	if reporter, ok := lk.source.(configcore.SourceMetadataReporter); ok {
+		reporter.ReportMetadata(metadata)
+	}
+
+	lk.mu.RLock()
+	defer lk.mu.RUnlock()
+
+	if metadata.Properties == nil {
+		metadata.Properties = make(map[string]any)
+	}
+
+	metadata.Properties["degraded"] = lk.degraded
+	metadata.Properties["cache_path"] = lk.path
+
+	if lk.degraded {
+		metadata.Properties["degraded_since"] = lk.degradedSince
+		metadata.Properties["cached_at"] = lk.cachedAt
+	}
+
+	if lk.lastError != nil {
+		metadata.LastError = lk.lastError.Error()
+	}
+}
+
+// recordSuccess persists data and clears the degraded state.
+func (lk *LastKnownGoodSource) recordSuccess(data map[string]any) {
+	lk.mu.Lock()
+	wasDegraded := lk.degraded
+	lk.degraded = false
+	lk.lastError = nil
+	lk.mu.Unlock()
+
+	if wasDegraded && lk.logger != nil {
+		lk.logger.Info("source recovered, leaving degraded mode",
+			logger.String("source", lk.Name()),
+		)
+	}
+
+	if err := lk.writeCache(data); err != nil {
+		if lk.logger != nil {
+			lk.logger.Warn("failed to write last-known-good cache",
+				logger.String("source", lk.Name()),
+				logger.String("path", lk.path),
+				logger.Error(err),
+			)
+		}
+
+		return
+	}
+
+	lk.mu.Lock()
+	lk.cachedAt = time.Now()
+	lk.mu.Unlock()
+}
+
+// watchUpstream starts watching the wrapped source, recording every update
+// before passing it on. Callers hold watchMu.
+func (lk *LastKnownGoodSource) watchUpstream(ctx context.Context, callback func(map[string]any)) error {
+	return lk.source.Watch(ctx, func(data map[string]any) {
+		lk.recordSuccess(data)
+
+		if callback != nil {
+			callback(data)
+		}
+	})
+}
+
+// recoveryLoop retries the upstream while degraded, then restarts the
+// upstream watch if it could not be started, until it succeeds.
+func (lk *LastKnownGoodSource) recoveryLoop(ctx context.Context, stop chan struct{}, callback func(map[string]any)) {
+	ticker := time.NewTicker(lk.recoveryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		if lk.IsDegraded() {
+			data, err := lk.source.Load(ctx)
+			if err != nil {
+				lk.mu.Lock()
+				lk.lastError = err
+				lk.mu.Unlock()
+
+				continue
+			}
+
+			lk.recordSuccess(data)
+
+			if callback != nil {
+				callback(data)
+			}
+		}
+
+		lk.restartWatch(ctx, stop, callback)
+	}
+}
+
+// restartWatch starts the pending upstream watch, unless watching stopped.
+func (lk *LastKnownGoodSource) restartWatch(ctx context.Context, stop chan struct{}, callback func(map[string]any)) {
+	lk.watchMu.Lock()
+	defer lk.watchMu.Unlock()
+
+	lk.mu.RLock()
+	pending := lk.watchPending && lk.watchStop == stop
+	lk.mu.RUnlock()
+
+	if !pending {
+		return
+	}
+
+	if err := lk.watchUpstream(ctx, callback); err != nil {
+		if lk.logger != nil {
+			lk.logger.Warn("failed to restart upstream watch",
+				logger.String("source", lk.Name()),
+				logger.Error(err),
+			)
+		}
+
+		return
+	}
+
+	lk.mu.Lock()
+	lk.watchPending = false
+	lk.mu.Unlock()
+
+	if lk.logger != nil {
+		lk.logger.Info("restarted upstream watch",
+			logger.String("source", lk.Name()),
+		)
+	}
+}
+
+// writeCache atomically writes the cache file.
+func (lk *LastKnownGoodSource) writeCache(data map[string]any) error {
+	content, err := json.Marshal(lastKnownGoodEnvelope{
+		Source:  lk.Name(),
+		SavedAt: time.Now(),
+		Data:    data,
+	})
+	if err != nil {
+		return err
+	}
+
+	if lk.encryptor != nil {
+		encrypted, err := lk.encryptor.Encrypt(string(content))
+		if err != nil {
+			return configcore.ErrEncryptionError("encrypt", err)
+		}
+
+		content = []byte(encrypted)
+	}
+
+	dir := filepath.Dir(lk.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(lk.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := tmp.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
+
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, lk.path)
+}
+
+// readCache reads and decodes the cache file.
+func (lk *LastKnownGoodSource) readCache() (*lastKnownGoodEnvelope, error) {
+	content, err := os.ReadFile(lk.path)
+	if err != nil {
+		return nil, err
+	}
+
+	if lk.encryptor != nil {
+		decrypted, err := lk.encryptor.Decrypt(string(content))
+		if err != nil {
+			return nil, configcore.ErrEncryptionError("decrypt", err)
+		}
+
+		content = []byte(decrypted)
+	}
+
+	// Decode numbers as json.Number so integers come back as ints, the way a
+	// live load from YAML or TOML returns them, rather than as float64.
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+
+	var envelope lastKnownGoodEnvelope
+	if err := decoder.Decode(&envelope); err != nil {
+		return nil, configcore.ErrConfigError("corrupt last-known-good cache "+lk.path, err)
+	}
+
+	if envelope.Data == nil {
+		envelope.Data = make(map[string]any)
+	}
+
+	envelope.Data, _ = restoreCachedNumbers(envelope.Data).(map[string]any)
+
+	return &envelope, nil
+}
+
+// writableInner returns the source wrapped by a decorator as a
+// WritableSource if it accepts writes.
+func writableInner(source configcore.ConfigSource) (configcore.WritableSource, bool) {
+	writable, ok := source.(configcore.WritableSource)
+	if !ok || !writable.IsWritable() {
+		return nil, false
+	}
+
+	return writable, true
+}
+
+// restoreCachedNumbers replaces json.Number values with int, int64 or
+// float64, whichever represents them exactly.
+func restoreCachedNumbers(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = restoreCachedNumbers(item)
+		}
+
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = restoreCachedNumbers(item)
+		}
+
+		return v
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			if i == int64(int(i)) {
+				return int(i)
+			}
+
+			return i
+		}
+
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+
+		return v.String()
+	default:
+		return value
+	}
+}
+
+// sanitizeCacheName converts a source name into a safe file name.
+func sanitizeCacheName(name string) string {
+	result := []rune(name)
+	for i, r := range result {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			result[i] = '_'
+		}
+	}
+
+	return string(result)
+}
@@ -0,0 +1,413 @@
+package sources
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	configcore "github.com/xraph/confy/internal"
+)
+
+// stubSource is a controllable in-memory source used by decorator tests.
+type stubSource struct {
+	name      string
+	priority  int
+	data      map[string]any
+	loadErr   error
+	watchable bool
+	secrets   map[string]string
+	callback  func(map[string]any)
+	watching  bool
+	mu        sync.Mutex
+}
+
+func newStubSource(name string, data map[string]any) *stubSource {
+	return &stubSource{name: name, priority: 100, data: data, watchable: true}
+}
+
+func (s *stubSource) Name() string    { return s.name }
+func (s *stubSource) GetName() string { return s.name }
+func (s *stubSource) GetType() string { return "stub" }
+func (s *stubSource) Priority() int   { return s.priority }
+
+func (s *stubSource) IsAvailable(ctx context.Context) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.loadErr == nil
+}
+
+func (s *stubSource) Load(ctx context.Context) (map[string]any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.loadErr != nil {
+		return nil, s.loadErr
+	}
+
+	return copyConfigMap(s.data), nil
+}
+
+func (s *stubSource) Watch(ctx context.Context, callback func(map[string]any)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.loadErr != nil {
+		return s.loadErr
+	}
+
+	s.callback = callback
+	s.watching = true
+
+	return nil
+}
+
+func (s *stubSource) StopWatch() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.watching = false
+
+	return nil
+}
+
+func (s *stubSource) Reload(ctx context.Context) error {
+	_, err := s.Load(ctx)
+
+	return err
+}
+
+func (s *stubSource) IsWatchable() bool     { return s.watchable }
+func (s *stubSource) SupportsSecrets() bool { return s.secrets != nil }
+
+func (s *stubSource) GetSecret(ctx context.Context, key string) (string, error) {
+	if value, ok := s.secrets[key]; ok {
+		return value, nil
+	}
+
+	return "", errors.New("secret not found: " + key)
+}
+
+func (s *stubSource) set(data map[string]any, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data = data
+	s.loadErr = err
+}
+
+func (s *stubSource) isWatching() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.watching
+}
+
+func (s *stubSource) emit(data map[string]any) {
+	s.mu.Lock()
+	callback := s.callback
+	s.mu.Unlock()
+
+	if callback != nil {
+		callback(data)
+	}
+}
+
+// reverseEncryptor is a reversible test encryptor.
+type reverseEncryptor struct{}
+
+func (reverseEncryptor) Encrypt(plaintext string) (string, error) {
+	return base64.StdEncoding.EncodeToString([]byte(plaintext)), nil
+}
+
+func (reverseEncryptor) Decrypt(ciphertext string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(ciphertext)
+
+	return string(decoded), err
+}
+
+func TestLastKnownGoodSource_ServesCacheWhenUpstreamFails(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "cache", "consul.json")
+	upstream := newStubSource("consul:app", map[string]any{"feature": map[string]any{"enabled": true}})
+
+	source, err := NewLastKnownGoodSource(upstream, LastKnownGoodOptions{Path: cachePath})
+	if err != nil {
+		t.Fatalf("NewLastKnownGoodSource() error = %v", err)
+	}
+
+	if source.Name() != "consul:app" || source.GetType() != "stub" || source.Priority() != 100 {
+		t.Errorf("decorator identity = %s/%s/%d, want wrapped identity", source.Name(), source.GetType(), source.Priority())
+	}
+
+	if _, err := source.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if info, err := os.Stat(cachePath); err != nil {
+		t.Fatalf("cache file not written: %v", err)
+	} else if info.Mode().Perm() != 0600 {
+		t.Errorf("cache file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	upstream.set(nil, errors.New("connection refused"))
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() with failing upstream error = %v", err)
+	}
+
+	if feature, ok := data["feature"].(map[string]any); !ok || feature["enabled"] != true {
+		t.Errorf("cached data = %v, want feature.enabled=true", data)
+	}
+
+	lkg := source.(*LastKnownGoodSource)
+	if !lkg.IsDegraded() {
+		t.Error("IsDegraded() = false, want true")
+	}
+
+	metadata := &configcore.SourceMetadata{}
+	lkg.ReportMetadata(metadata)
+
+	if metadata.Properties["degraded"] != true {
+		t.Errorf("metadata degraded = %v, want true", metadata.Properties["degraded"])
+	}
+
+	if !strings.Contains(metadata.LastError, "connection refused") {
+		t.Errorf("metadata LastError = %q, want upstream error", metadata.LastError)
+	}
+
+	upstream.set(map[string]any{"feature": map[string]any{"enabled": false}}, nil)
+
+	data, err = source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() after recovery error = %v", err)
+	}
+
+	if data["feature"].(map[string]any)["enabled"] != false {
+		t.Errorf("recovered data = %v, want upstream data", data)
+	}
+
+	if lkg.IsDegraded() {
+		t.Error("IsDegraded() = true after recovery, want false")
+	}
+}
+
+func TestLastKnownGoodSource_NoCache(t *testing.T) {
+	upstream := newStubSource("consul:app", nil)
+	upstream.set(nil, errors.New("unreachable"))
+
+	source, _ := NewLastKnownGoodSource(upstream, LastKnownGoodOptions{Path: filepath.Join(t.TempDir(), "missing.json")})
+
+	if _, err := source.Load(context.Background()); err == nil {
+		t.Error("Load() without cache should return the upstream error")
+	}
+
+	if source.(*LastKnownGoodSource).IsDegraded() {
+		t.Error("IsDegraded() should be false without cached data")
+	}
+}
+
+func TestLastKnownGoodSource_Encrypted(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "cache.json")
+	upstream := newStubSource("k8s:default", map[string]any{"password": "hunter2"})
+
+	source, _ := NewLastKnownGoodSource(upstream, LastKnownGoodOptions{Path: cachePath, Encryptor: reverseEncryptor{}})
+
+	if _, err := source.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	content, _ := os.ReadFile(cachePath)
+	if strings.Contains(string(content), "hunter2") {
+		t.Error("cache file contains plaintext data")
+	}
+
+	upstream.set(nil, errors.New("down"))
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if data["password"] != "hunter2" {
+		t.Errorf("decrypted data = %v, want password", data)
+	}
+
+	// A cache written with a different key cannot be used.
+	other, _ := NewLastKnownGoodSource(upstream, LastKnownGoodOptions{Path: cachePath})
+	if _, err := other.Load(context.Background()); err == nil {
+		t.Error("Load() with undecodable cache should fail")
+	}
+}
+
+func TestLastKnownGoodSource_WatchRecovery(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "cache.json")
+	upstream := newStubSource("consul:app", map[string]any{"version": "1"})
+
+	source, _ := NewLastKnownGoodSource(upstream, LastKnownGoodOptions{
+		Path:             cachePath,
+		RecoveryInterval: 10 * time.Millisecond,
+	})
+
+	_, _ = source.Load(context.Background())
+
+	upstream.set(nil, errors.New("down"))
+	_, _ = source.Load(context.Background())
+
+	updates := make(chan map[string]any, 4)
+	if err := source.Watch(context.Background(), func(data map[string]any) { updates <- data }); err != nil {
+		t.Fatalf("Watch() while degraded error = %v", err)
+	}
+	defer func() { _ = source.StopWatch() }()
+
+	upstream.set(map[string]any{"version": "2"}, nil)
+
+	select {
+	case data := <-updates:
+		if data["version"] != "2" {
+			t.Errorf("recovered data = %v, want version 2", data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for recovery")
+	}
+
+	if source.(*LastKnownGoodSource).IsDegraded() {
+		t.Error("IsDegraded() = true after recovery")
+	}
+
+	// The upstream watch that failed to start while degraded is restarted,
+	// so later upstream changes still arrive.
+	deadline := time.Now().Add(2 * time.Second)
+	for !upstream.isWatching() {
+		if time.Now().After(deadline) {
+			t.Fatal("upstream watch was not restarted after recovery")
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	upstream.emit(map[string]any{"version": "3"})
+
+	select {
+	case data := <-updates:
+		if data["version"] != "3" {
+			t.Errorf("watched data = %v, want version 3", data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for an upstream change after recovery")
+	}
+}
+
+func TestLastKnownGoodSource_WatchPersistsUpdates(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "cache.json")
+	upstream := newStubSource("consul:app", map[string]any{"version": "1"})
+
+	source, _ := NewLastKnownGoodSource(upstream, LastKnownGoodOptions{Path: cachePath})
+
+	updates := make(chan map[string]any, 1)
+	if err := source.Watch(context.Background(), func(data map[string]any) { updates <- data }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	upstream.emit(map[string]any{"version": "3"})
+	<-updates
+
+	_ = source.StopWatch()
+
+	upstream.set(nil, errors.New("down"))
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if data["version"] != "3" {
+		t.Errorf("cached data = %v, want watched update", data)
+	}
+}
+
+// writableStubSource is a stubSource that records persisted values.
+type writableStubSource struct {
+	*stubSource
+
+	writable  bool
+	persisted map[string]any
+}
+
+func newWritableStubSource(name string, data map[string]any) *writableStubSource {
+	return &writableStubSource{stubSource: newStubSource(name, data), writable: true, persisted: make(map[string]any)}
+}
+
+func (s *writableStubSource) IsWritable() bool { return s.writable }
+
+func (s *writableStubSource) Persist(ctx context.Context, key string, value any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.persisted[key] = value
+
+	return nil
+}
+
+func TestLastKnownGoodSource_RestoresIntegers(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "cache.json")
+	upstream := newStubSource("consul:app", map[string]any{
+		"server": map[string]any{"port": 8080, "ratio": 0.5, "ports": []any{80, 443}},
+	})
+
+	source, _ := NewLastKnownGoodSource(upstream, LastKnownGoodOptions{Path: cachePath})
+	if _, err := source.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// A new decorator, as after a restart, with the upstream down.
+	upstream.set(nil, errors.New("connection refused"))
+	restored, _ := NewLastKnownGoodSource(upstream, LastKnownGoodOptions{Path: cachePath})
+
+	data, err := restored.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() from cache error = %v", err)
+	}
+
+	server := data["server"].(map[string]any)
+	if port, ok := server["port"].(int); !ok || port != 8080 {
+		t.Errorf("port = %#v, want int 8080", server["port"])
+	}
+
+	if ratio, ok := server["ratio"].(float64); !ok || ratio != 0.5 {
+		t.Errorf("ratio = %#v, want float64 0.5", server["ratio"])
+	}
+
+	if ports := server["ports"].([]any); ports[1] != 443 {
+		t.Errorf("ports = %#v, want ints", ports)
+	}
+}
+
+func TestLastKnownGoodSource_Persist(t *testing.T) {
+	upstream := newWritableStubSource("consul:app", map[string]any{})
+	source, _ := NewLastKnownGoodSource(upstream, LastKnownGoodOptions{Path: filepath.Join(t.TempDir(), "cache.json")})
+
+	writable, ok := source.(configcore.WritableSource)
+	if !ok || !writable.IsWritable() {
+		t.Fatal("decorator of a writable source should be writable")
+	}
+
+	if err := writable.Persist(context.Background(), "limits.rps", 10); err != nil {
+		t.Fatalf("Persist() error = %v", err)
+	}
+
+	if upstream.persisted["limits.rps"] != 10 {
+		t.Errorf("persisted = %v, want limits.rps forwarded", upstream.persisted)
+	}
+
+	upstream.writable = false
+	if writable.IsWritable() || writable.Persist(context.Background(), "a", 1) == nil {
+		t.Error("decorator of a read-only source should not be writable")
+	}
+}
@@ -0,0 +1,191 @@
+package sources
+
+import (
+	"context"
+	"strings"
+
+	configcore "github.com/xraph/confy/internal"
+)
+
+// MountSource places the entire content of a source under a key prefix, for
+// example a vendor file under "integrations.stripe".
+type MountSource struct {
+	source configcore.ConfigSource
+	prefix string
+	keys   []string
+}
+
+// Mount returns a source that nests everything loaded from source under the
+// dot-separated prefix. Name, priority, watching, secrets and metadata are
+// passed through to the wrapped source.
+func Mount(prefix string, source configcore.ConfigSource) configcore.ConfigSource {
+	prefix = strings.Trim(prefix, ".")
+
+	var keys []string
+	if prefix != "" {
+		keys = strings.Split(prefix, ".")
+	}
+
+	return &MountSource{
+		source: source,
+		prefix: prefix,
+		keys:   keys,
+	}
+}
+
+// Name returns the wrapped source name.
+func (ms *MountSource) Name() string {
+	return ms.source.Name()
+}
+
+// GetName returns the source name (alias for Name).
+func (ms *MountSource) GetName() string {
+	return ms.source.Name()
+}
+
+// GetType returns the wrapped source type.
+func (ms *MountSource) GetType() string {
+	return ms.source.GetType()
+}
+
+// IsAvailable checks if the wrapped source is available.
+func (ms *MountSource) IsAvailable(ctx context.Context) bool {
+	return ms.source.IsAvailable(ctx)
+}
+
+// Priority returns the wrapped source priority.
+func (ms *MountSource) Priority() int {
+	return ms.source.Priority()
+}
+
+// Load loads the wrapped source and nests it under the prefix.
+func (ms *MountSource) Load(ctx context.Context) (map[string]any, error) {
+	data, err := ms.source.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return ms.mount(data), nil
+}
+
+// Watch watches the wrapped source, mounting every update.
+func (ms *MountSource) Watch(ctx context.Context, callback func(map[string]any)) error {
+	return ms.source.Watch(ctx, func(data map[string]any) {
+		if callback != nil {
+			callback(ms.mount(data))
+		}
+	})
+}
+
+// StopWatch stops watching the wrapped source.
+func (ms *MountSource) StopWatch() error {
+	return ms.source.StopWatch()
+}
+
+// Reload reloads the wrapped source.
+func (ms *MountSource) Reload(ctx context.Context) error {
+	return ms.source.Reload(ctx)
+}
+
+// IsWatchable returns true if the wrapped source is watchable.
+func (ms *MountSource) IsWatchable() bool {
+	return ms.source.IsWatchable()
+}
+
+// SupportsSecrets returns true if the wrapped source supports secrets.
+func (ms *MountSource) SupportsSecrets() bool {
+	return ms.source.SupportsSecrets()
+}
+
+// GetSecret retrieves a secret from the wrapped source. Keys addressed by
+// their mounted path have the prefix removed first.
+func (ms *MountSource) GetSecret(ctx context.Context, key string) (string, error) {
+	if ms.prefix != "" {
+		key = strings.TrimPrefix(key, ms.prefix+".")
+	}
+
+	return ms.source.GetSecret(ctx, key)
+}
+
+// IsWritable returns true if the wrapped source accepts writes.
+func (ms *MountSource) IsWritable() bool {
+	_, ok := writableInner(ms.source)
+
+	return ok
+}
+
+// Persist forwards the write to the wrapped source with the mount prefix
+// removed. Keys outside the mount are rejected.
+func (ms *MountSource) Persist(ctx context.Context, key string, value any) error {
+	writable, ok := writableInner(ms.source)
+	if !ok {
+		return configcore.ErrConfigError("source "+ms.source.Name()+" is not writable", nil)
+	}
+
+	if ms.prefix != "" {
+		if !strings.HasPrefix(key, ms.prefix+".") {
+			return configcore.ErrConfigError("key "+key+" is outside mount "+ms.prefix, nil)
+		}
+
+		key = strings.TrimPrefix(key, ms.prefix+".")
+	}
+
+	return writable.Persist(ctx, key, value)
+}
+
+// GetPrefix returns the mount prefix.
+func (ms *MountSource) GetPrefix() string {
+	return ms.prefix
+}
+
+// Unwrap returns the wrapped source.
+func (ms *MountSource) Unwrap() configcore.ConfigSource {
+	return ms.source
+}
+
+// ReportMetadata reports the wrapped source metadata and the mount prefix.
+func (ms *MountSource) ReportMetadata(metadata *configcore.SourceMetadata) {
+	if reporter, ok := ms.source.(configcore.SourceMetadataReporter); ok {
+		reporter.ReportMetadata(metadata)
+	}
+
+	if metadata.Properties == nil {
+		metadata.Properties = make(map[string]any)
+	}
+
+	metadata.Properties["mount"] = ms.prefix
+}
+
+// mount nests data under the prefix.
+func (ms *MountSource) mount(data map[string]any) map[string]any {
+	if len(ms.keys) == 0 {
+		return data
+	}
+
+	result := make(map[string]any)
+	setNestedPath(result, ms.keys, data)
+
+	return result
+}
+
+// setNestedPath sets value at the nested path described by keys, creating
+// intermediate maps and replacing non-map values in the way.
+func setNestedPath(config map[string]any, keys []string, value any) {
+	current := config
+
+	for i, k := range keys {
+		if i == len(keys)-1 {
+			current[k] = value
+
+			return
+		}
+
+		next, ok := current[k].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			current[k] = next
+		}
+
+		current = next
+	}
+}
@@ -0,0 +1,195 @@
+package sources
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	configcore "github.com/xraph/confy/internal"
+)
+
+func TestMount_Load(t *testing.T) {
+	inner := newStubSource("file:stripe.yaml", map[string]any{"api_key": "sk_test", "retries": 3})
+
+	source := Mount("integrations.stripe", inner)
+
+	if source.Name() != "file:stripe.yaml" || source.Priority() != 100 {
+		t.Errorf("identity = %s/%d, want wrapped identity", source.Name(), source.Priority())
+	}
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := map[string]any{
+		"integrations": map[string]any{
+			"stripe": map[string]any{"api_key": "sk_test", "retries": 3},
+		},
+	}
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("Load() = %v, want %v", data, want)
+	}
+}
+
+func TestMount_WatchSecretsAndMetadata(t *testing.T) {
+	inner := newStubSource("vault", map[string]any{})
+	inner.secrets = map[string]string{"token": "s3cr3t"}
+
+	source := Mount("vendor", inner)
+
+	updates := make(chan map[string]any, 1)
+	if err := source.Watch(context.Background(), func(data map[string]any) { updates <- data }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	inner.emit(map[string]any{"version": "2"})
+
+	if data := <-updates; data["vendor"].(map[string]any)["version"] != "2" {
+		t.Errorf("watched data = %v, want mounted update", data)
+	}
+
+	for _, key := range []string{"token", "vendor.token"} {
+		if value, err := source.GetSecret(context.Background(), key); err != nil || value != "s3cr3t" {
+			t.Errorf("GetSecret(%q) = %q, %v", key, value, err)
+		}
+	}
+
+	metadata := &configcore.SourceMetadata{}
+	source.(configcore.SourceMetadataReporter).ReportMetadata(metadata)
+
+	if metadata.Properties["mount"] != "vendor" {
+		t.Errorf("metadata mount = %v, want vendor", metadata.Properties["mount"])
+	}
+}
+
+func TestFilter_IncludeExcludeRename(t *testing.T) {
+	inner := newStubSource("consul:shared", map[string]any{
+		"db": map[string]any{
+			"host":     "localhost",
+			"password": "secret",
+			"pool":     map[string]any{"max": 10},
+		},
+		"cache": map[string]any{"ttl": "1m"},
+		"other": "dropped",
+	})
+
+	source := Filter(inner, FilterOptions{
+		Include: []string{"db", "cache.*"},
+		Exclude: []string{"**.password"},
+		Rename:  map[string]string{"db": "database", "db.pool": "pool"},
+	})
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := map[string]any{
+		"database": map[string]any{"host": "localhost"},
+		"pool":     map[string]any{"max": 10},
+		"cache":    map[string]any{"ttl": "1m"},
+	}
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("Load() = %v, want %v", data, want)
+	}
+}
+
+func TestFilter_Watch(t *testing.T) {
+	inner := newStubSource("consul:shared", nil)
+	source := Filter(inner, FilterOptions{Include: []string{"feature_*"}})
+
+	updates := make(chan map[string]any, 1)
+	if err := source.Watch(context.Background(), func(data map[string]any) { updates <- data }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	inner.emit(map[string]any{"feature_x": true, "internal": 1})
+
+	data := <-updates
+	if !reflect.DeepEqual(data, map[string]any{"feature_x": true}) {
+		t.Errorf("watched data = %v, want only feature_x", data)
+	}
+}
+
+func TestMount_Persist(t *testing.T) {
+	inner := newWritableStubSource("consul:stripe", map[string]any{})
+	source := Mount("integrations.stripe", inner).(configcore.WritableSource)
+
+	if !source.IsWritable() {
+		t.Fatal("mount of a writable source should be writable")
+	}
+
+	if err := source.Persist(context.Background(), "integrations.stripe.retries", 5); err != nil {
+		t.Fatalf("Persist() error = %v", err)
+	}
+
+	if inner.persisted["retries"] != 5 {
+		t.Errorf("persisted = %v, want retries with the mount prefix removed", inner.persisted)
+	}
+
+	if err := source.Persist(context.Background(), "integrations.paypal.retries", 5); err == nil {
+		t.Error("Persist() outside the mount should fail")
+	}
+}
+
+func TestFilter_PersistAndSecrets(t *testing.T) {
+	inner := newWritableStubSource("consul:shared", map[string]any{})
+	inner.secrets = map[string]string{"db.password": "hunter2", "db.user": "app"}
+
+	source := Filter(inner, FilterOptions{
+		Include: []string{"db", "cache.*"},
+		Exclude: []string{"**.password"},
+		Rename:  map[string]string{"db": "database"},
+	})
+	writable := source.(configcore.WritableSource)
+
+	if err := writable.Persist(context.Background(), "database.host", "db.internal"); err != nil {
+		t.Fatalf("Persist() error = %v", err)
+	}
+
+	if inner.persisted["db.host"] != "db.internal" {
+		t.Errorf("persisted = %v, want database.host written back as db.host", inner.persisted)
+	}
+
+	for _, key := range []string{"database.password", "db.host", "other", "cache"} {
+		if err := writable.Persist(context.Background(), key, "x"); err == nil {
+			t.Errorf("Persist(%q) should fail for a key the filter does not expose", key)
+		}
+	}
+
+	if err := writable.Persist(context.Background(), "database", map[string]any{"password": "x"}); err == nil {
+		t.Error("Persist() of a section containing excluded keys should fail")
+	}
+
+	if value, err := source.GetSecret(context.Background(), "database.user"); err != nil || value != "app" {
+		t.Errorf("GetSecret(database.user) = %q, %v", value, err)
+	}
+
+	if _, err := source.GetSecret(context.Background(), "database.password"); err == nil {
+		t.Error("GetSecret() should not expose an excluded key")
+	}
+}
+
+func TestMatchKeySegments(t *testing.T) {
+	tests := []struct {
+		pattern string
+		key     string
+		want    bool
+	}{
+		{"db", "db.host", true},
+		{"db.host", "db.host", true},
+		{"db.*", "db.pool.max", true},
+		{"*.host", "db.host", true},
+		{"*.host", "db.pool.host", false},
+		{"**.host", "db.pool.host", true},
+		{"db_*", "db_main.host", true},
+		{"cache", "db.cache", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchesAnyKeyPattern([]string{tt.pattern}, tt.key); got != tt.want {
+			t.Errorf("match(%q, %q) = %v, want %v", tt.pattern, tt.key, got, tt.want)
+		}
+	}
+}
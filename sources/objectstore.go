@@ -0,0 +1,651 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/xraph/confy/formats"
+	configcore "github.com/xraph/confy/internal"
+	errors "github.com/xraph/go-utils/errs"
+	logger "github.com/xraph/go-utils/log"
+)
+
+// keyTemplateVar matches {name} placeholders in object key templates.
+var keyTemplateVar = regexp.MustCompile(`\{([A-Za-z0-9_]+)\}`)
+
+// ObjectStoreSource represents a configuration object in an S3-compatible
+// bucket.
+type ObjectStoreSource struct {
+	name         string
+	client       *minio.Client
+	bucket       string
+	key          string
+	priority     int
+	etag         string
+	lastModified time.Time
+	versionID    string
+	lastError    error
+	watching     bool
+	watchStop    chan struct{}
+	logger       logger.Logger
+	errorHandler errors.ErrorHandler
+	options      ObjectStoreSourceOptions
+	mu           sync.RWMutex
+}
+
+// ObjectStoreSourceOptions contains options for object store configuration
+// sources.
+type ObjectStoreSourceOptions struct {
+	Name string
+	// Endpoint is the S3 endpoint, with or without scheme. Defaults to
+	// s3.amazonaws.com.
+	Endpoint string
+	Region   string
+	Bucket   string
+	// Key is the object key. It may contain {env} and other {name}
+	// placeholders, filled from Vars; {env} falls back to Env and then to the
+	// APP_ENV environment variable.
+	Key  string
+	Env  string
+	Vars map[string]string
+	// Credentials default to the AWS/MinIO environment variables, the shared
+	// credentials file and the instance metadata service.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// PathStyle addresses buckets as endpoint/bucket instead of bucket.endpoint,
+	// as required by most self-hosted S3 implementations.
+	PathStyle bool
+	// Insecure uses plain HTTP when Endpoint has no scheme.
+	Insecure bool
+	// Format forces a format instead of detecting it from the key extension.
+	Format string
+	// Optional treats a missing object as empty configuration.
+	Optional      bool
+	Priority      int
+	ExpandEnvVars bool
+	WatchEnabled  bool
+	WatchInterval time.Duration
+	Timeout       time.Duration
+	Transport     http.RoundTripper
+	Logger        logger.Logger
+	ErrorHandler  errors.ErrorHandler
+}
+
+// ObjectStoreSourceConfig contains configuration for creating object store
+// sources.
+type ObjectStoreSourceConfig struct {
+	Endpoint        string            `json:"endpoint"          yaml:"endpoint"`
+	Region          string            `json:"region"            yaml:"region"`
+	Bucket          string            `json:"bucket"            yaml:"bucket"`
+	Key             string            `json:"key"               yaml:"key"`
+	Env             string            `json:"env"               yaml:"env"`
+	Vars            map[string]string `json:"vars"              yaml:"vars"`
+	AccessKeyID     string            `json:"access_key_id"     yaml:"access_key_id"`
+	SecretAccessKey string            `json:"secret_access_key" yaml:"secret_access_key"`
+	SessionToken    string            `json:"session_token"     yaml:"session_token"`
+	PathStyle       bool              `json:"path_style"        yaml:"path_style"`
+	Insecure        bool              `json:"insecure"          yaml:"insecure"`
+	Format          string            `json:"format"            yaml:"format"`
+	Optional        bool              `json:"optional"          yaml:"optional"`
+	Priority        int               `json:"priority"          yaml:"priority"`
+	ExpandEnvVars   bool              `json:"expand_env_vars"   yaml:"expand_env_vars"`
+	WatchEnabled    bool              `json:"watch_enabled"     yaml:"watch_enabled"`
+	WatchInterval   time.Duration     `json:"watch_interval"    yaml:"watch_interval"`
+	Timeout         time.Duration     `json:"timeout"           yaml:"timeout"`
+}
+
+// NewObjectStoreSource creates a new S3-compatible object store
+// configuration source. Requests are signed with SigV4.
+func NewObjectStoreSource(options ObjectStoreSourceOptions) (configcore.ConfigSource, error) {
+	if options.Bucket == "" {
+		return nil, configcore.ErrConfigError("object store bucket is required", nil)
+	}
+
+	if options.Key == "" {
+		return nil, configcore.ErrConfigError("object store key is required", nil)
+	}
+
+	key, err := expandKeyTemplate(options.Key, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Format != "" {
+		if _, err := getFormatProcessor(options.Format); err != nil {
+			return nil, err
+		}
+	} else if _, ok := processorForExtension(strings.ToLower(path.Ext(key))); !ok {
+		return nil, configcore.ErrConfigError("cannot detect format of object "+key, nil)
+	}
+
+	if options.Endpoint == "" {
+		options.Endpoint = "s3.amazonaws.com"
+	}
+
+	if options.WatchInterval == 0 {
+		options.WatchInterval = time.Minute
+	}
+
+	if options.Timeout == 0 {
+		options.Timeout = 30 * time.Second
+	}
+
+	host, secure, err := parseObjectStoreEndpoint(options.Endpoint, options.Insecure)
+	if err != nil {
+		return nil, err
+	}
+
+	lookup := minio.BucketLookupAuto
+	if options.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+
+	client, err := minio.New(host, &minio.Options{
+		Creds:        objectStoreCredentials(options),
+		Secure:       secure,
+		Region:       options.Region,
+		BucketLookup: lookup,
+		Transport:    options.Transport,
+	})
+	if err != nil {
+		return nil, configcore.ErrConfigError(fmt.Sprintf("failed to create object store client: %v", err), err)
+	}
+
+	name := options.Name
+	if name == "" {
+		name = "s3://" + options.Bucket + "/" + key
+	}
+
+	return &ObjectStoreSource{
+		name:         name,
+		client:       client,
+		bucket:       options.Bucket,
+		key:          key,
+		priority:     options.Priority,
+		logger:       options.Logger,
+		errorHandler: options.ErrorHandler,
+		options:      options,
+	}, nil
+}
+
+// Name returns the source name.
+func (oss *ObjectStoreSource) Name() string {
+	return oss.name
+}
+
+// GetName returns the source name (alias for Name).
+func (oss *ObjectStoreSource) GetName() string {
+	return oss.name
+}
+
+// GetType returns the source type.
+func (oss *ObjectStoreSource) GetType() string {
+	return "s3"
+}
+
+// IsAvailable checks if the object can be stat'ed.
+func (oss *ObjectStoreSource) IsAvailable(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, oss.options.Timeout)
+	defer cancel()
+
+	_, err := oss.client.StatObject(ctx, oss.bucket, oss.key, minio.StatObjectOptions{})
+
+	return err == nil || (oss.options.Optional && isObjectNotFound(err))
+}
+
+// Priority returns the source priority.
+func (oss *ObjectStoreSource) Priority() int {
+	return oss.priority
+}
+
+// Load fetches and parses the object.
+func (oss *ObjectStoreSource) Load(ctx context.Context) (map[string]any, error) {
+	if oss.logger != nil {
+		oss.logger.Debug("loading configuration from object store",
+			logger.String("bucket", oss.bucket),
+			logger.String("key", oss.key),
+		)
+	}
+
+	config, err := oss.fetch(ctx)
+	if err != nil {
+		oss.mu.Lock()
+		oss.lastError = err
+		oss.mu.Unlock()
+
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// Watch starts polling the object's ETag.
+func (oss *ObjectStoreSource) Watch(ctx context.Context, callback func(map[string]any)) error {
+	oss.mu.Lock()
+	defer oss.mu.Unlock()
+
+	if oss.watching {
+		return configcore.ErrConfigError("already watching object store", nil)
+	}
+
+	if !oss.IsWatchable() {
+		return configcore.ErrConfigError("object store watching is not enabled", nil)
+	}
+
+	oss.watchStop = make(chan struct{})
+	oss.watching = true
+
+	go oss.watchLoop(ctx, oss.watchStop, callback)
+
+	if oss.logger != nil {
+		oss.logger.Info("started watching object store",
+			logger.String("bucket", oss.bucket),
+			logger.String("key", oss.key),
+			logger.Duration("interval", oss.options.WatchInterval),
+		)
+	}
+
+	return nil
+}
+
+// StopWatch stops watching the object.
+func (oss *ObjectStoreSource) StopWatch() error {
+	oss.mu.Lock()
+	defer oss.mu.Unlock()
+
+	if !oss.watching {
+		return nil
+	}
+
+	if oss.watchStop != nil {
+		close(oss.watchStop)
+		oss.watchStop = nil
+	}
+
+	oss.watching = false
+
+	if oss.logger != nil {
+		oss.logger.Info("stopped watching object store",
+			logger.String("bucket", oss.bucket),
+			logger.String("key", oss.key),
+		)
+	}
+
+	return nil
+}
+
+// Reload forces a reload of the object.
+func (oss *ObjectStoreSource) Reload(ctx context.Context) error {
+	_, err := oss.Load(ctx)
+
+	return err
+}
+
+// IsWatchable returns true if object store watching is enabled.
+func (oss *ObjectStoreSource) IsWatchable() bool {
+	return oss.options.WatchEnabled
+}
+
+// SupportsSecrets returns false.
+func (oss *ObjectStoreSource) SupportsSecrets() bool {
+	return false
+}
+
+// GetSecret is not supported by object store sources.
+func (oss *ObjectStoreSource) GetSecret(ctx context.Context, key string) (string, error) {
+	return "", configcore.ErrConfigError("object store source does not support secrets", nil)
+}
+
+// GetKey returns the object key after template expansion.
+func (oss *ObjectStoreSource) GetKey() string {
+	return oss.key
+}
+
+// GetETag returns the ETag of the last loaded object.
+func (oss *ObjectStoreSource) GetETag() string {
+	oss.mu.RLock()
+	defer oss.mu.RUnlock()
+
+	return oss.etag
+}
+
+// ReportMetadata reports the object location and version.
+func (oss *ObjectStoreSource) ReportMetadata(metadata *configcore.SourceMetadata) {
+	oss.mu.RLock()
+	defer oss.mu.RUnlock()
+
+	if metadata.Properties == nil {
+		metadata.Properties = make(map[string]any)
+	}
+
+	metadata.Properties["bucket"] = oss.bucket
+	metadata.Properties["key"] = oss.key
+
+	if oss.etag != "" {
+		metadata.Properties["etag"] = oss.etag
+	}
+
+	if !oss.lastModified.IsZero() {
+		metadata.Properties["last_modified"] = oss.lastModified
+	}
+
+	if oss.versionID != "" {
+		metadata.Properties["version_id"] = oss.versionID
+	}
+
+	if oss.lastError != nil {
+		metadata.LastError = oss.lastError.Error()
+	}
+}
+
+// fetch downloads and parses the object, recording its ETag.
+func (oss *ObjectStoreSource) fetch(ctx context.Context) (map[string]any, error) {
+	ctx, cancel := context.WithTimeout(ctx, oss.options.Timeout)
+	defer cancel()
+
+	object, err := oss.client.GetObject(ctx, oss.bucket, oss.key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, configcore.ErrConfigError(fmt.Sprintf("failed to get object %s: %v", oss.name, err), err)
+	}
+	defer object.Close()
+
+	// GetObject is lazy; Stat issues the request and surfaces missing objects
+	info, err := object.Stat()
+	if err != nil {
+		if oss.options.Optional && isObjectNotFound(err) {
+			oss.record(minio.ObjectInfo{})
+
+			return make(map[string]any), nil
+		}
+
+		return nil, configcore.ErrConfigError(fmt.Sprintf("failed to get object %s: %v", oss.name, err), err)
+	}
+
+	content, err := io.ReadAll(object)
+	if err != nil {
+		return nil, configcore.ErrConfigError(fmt.Sprintf("failed to read object %s: %v", oss.name, err), err)
+	}
+
+	config, err := oss.parse(content)
+	if err != nil {
+		return nil, err
+	}
+
+	oss.record(info)
+
+	if oss.logger != nil {
+		oss.logger.Info("configuration loaded from object store",
+			logger.String("bucket", oss.bucket),
+			logger.String("key", oss.key),
+			logger.String("etag", info.ETag),
+		)
+	}
+
+	return config, nil
+}
+
+// record stores the version information of the loaded object.
+func (oss *ObjectStoreSource) record(info minio.ObjectInfo) {
+	oss.mu.Lock()
+	defer oss.mu.Unlock()
+
+	oss.etag = info.ETag
+	oss.lastModified = info.LastModified
+	oss.versionID = info.VersionID
+	oss.lastError = nil
+}
+
+// parse parses the object content.
+func (oss *ObjectStoreSource) parse(content []byte) (map[string]any, error) {
+	processor, err := oss.processor()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := processor.Parse(content)
+	if err != nil {
+		return nil, configcore.ErrConfigError("failed to parse object "+oss.name, err)
+	}
+
+	if data == nil {
+		data = make(map[string]any)
+	}
+
+	if err := processor.Validate(data); err != nil {
+		return nil, configcore.ErrConfigError("validation failed for object "+oss.name, err)
+	}
+
+	if oss.options.ExpandEnvVars {
+		data = expandEnvInMap(data)
+	}
+
+	return data, nil
+}
+
+// processor returns the processor for the object, honouring the forced format.
+func (oss *ObjectStoreSource) processor() (formats.FormatProcessor, error) {
+	if oss.options.Format != "" {
+		return getFormatProcessor(oss.options.Format)
+	}
+
+	if processor, ok := processorForExtension(strings.ToLower(path.Ext(oss.key))); ok {
+		return processor, nil
+	}
+
+	return nil, configcore.ErrConfigError("cannot detect format of object "+oss.key, nil)
+}
+
+// watchLoop polls the object's ETag with HEAD requests and reloads when it
+// changes.
+func (oss *ObjectStoreSource) watchLoop(ctx context.Context, stop chan struct{}, callback func(map[string]any)) {
+	defer func() {
+		if r := recover(); r != nil {
+			if oss.logger != nil {
+				oss.logger.Error("panic in object store watch loop",
+					logger.String("key", oss.key),
+					logger.Any("panic", r),
+				)
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(oss.options.WatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-ticker.C:
+			oss.checkForChanges(ctx, callback)
+		}
+	}
+}
+
+// checkForChanges compares the current ETag with the loaded one.
+func (oss *ObjectStoreSource) checkForChanges(ctx context.Context, callback func(map[string]any)) {
+	statCtx, cancel := context.WithTimeout(ctx, oss.options.Timeout)
+	info, err := oss.client.StatObject(statCtx, oss.bucket, oss.key, minio.StatObjectOptions{})
+
+	cancel()
+
+	if err != nil && !(oss.options.Optional && isObjectNotFound(err)) {
+		oss.handleWatchError(err)
+
+		return
+	}
+
+	if info.ETag == oss.GetETag() {
+		return
+	}
+
+	config, err := oss.Load(ctx)
+	if err != nil {
+		oss.handleWatchError(err)
+
+		return
+	}
+
+	if oss.logger != nil {
+		oss.logger.Info("object store changes detected",
+			logger.String("bucket", oss.bucket),
+			logger.String("key", oss.key),
+			logger.String("etag", info.ETag),
+		)
+	}
+
+	if callback != nil {
+		callback(config)
+	}
+}
+
+// handleWatchError handles errors during watching.
+func (oss *ObjectStoreSource) handleWatchError(err error) {
+	oss.mu.Lock()
+	oss.lastError = err
+	oss.mu.Unlock()
+
+	if oss.logger != nil {
+		oss.logger.Error("object store watch error",
+			logger.String("bucket", oss.bucket),
+			logger.String("key", oss.key),
+			logger.Error(err),
+		)
+	}
+
+	if oss.errorHandler != nil {
+		_ = oss.errorHandler.HandleError(context.Background(), configcore.ErrConfigError("object store watch error for "+oss.name, err))
+	}
+}
+
+// expandKeyTemplate fills {name} placeholders in an object key.
+func expandKeyTemplate(template string, options ObjectStoreSourceOptions) (string, error) {
+	var missing []string
+
+	key := keyTemplateVar.ReplaceAllStringFunc(template, func(match string) string {
+		name := match[1 : len(match)-1]
+
+		if value, ok := options.Vars[name]; ok {
+			return value
+		}
+
+		if name == "env" {
+			if options.Env != "" {
+				return options.Env
+			}
+
+			if env := os.Getenv("APP_ENV"); env != "" {
+				return env
+			}
+		}
+
+		missing = append(missing, name)
+
+		return match
+	})
+
+	if len(missing) > 0 {
+		return "", configcore.ErrConfigError("object key template has unset variables: "+strings.Join(missing, ", "), nil)
+	}
+
+	return key, nil
+}
+
+// parseObjectStoreEndpoint splits an endpoint into host and TLS setting.
+func parseObjectStoreEndpoint(endpoint string, insecure bool) (string, bool, error) {
+	if !strings.Contains(endpoint, "://") {
+		return strings.TrimSuffix(endpoint, "/"), !insecure, nil
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", false, configcore.ErrConfigError("invalid object store endpoint "+endpoint, err)
+	}
+
+	switch u.Scheme {
+	case "https":
+		return u.Host, true, nil
+	case "http":
+		return u.Host, false, nil
+	default:
+		return "", false, configcore.ErrConfigError("unsupported object store endpoint scheme "+u.Scheme, nil)
+	}
+}
+
+// objectStoreCredentials returns static credentials when configured and the
+// standard provider chain otherwise.
+func objectStoreCredentials(options ObjectStoreSourceOptions) *credentials.Credentials {
+	if options.AccessKeyID != "" {
+		return credentials.NewStaticV4(options.AccessKeyID, options.SecretAccessKey, options.SessionToken)
+	}
+
+	return credentials.NewChainCredentials([]credentials.Provider{
+		&credentials.EnvAWS{},
+		&credentials.EnvMinio{},
+		&credentials.FileAWSCredentials{},
+		&credentials.IAM{Client: &http.Client{Transport: http.DefaultTransport}},
+	})
+}
+
+// isObjectNotFound reports whether err is a missing bucket or key response.
+func isObjectNotFound(err error) bool {
+	resp := minio.ToErrorResponse(err)
+
+	return resp.StatusCode == http.StatusNotFound || resp.Code == "NoSuchKey"
+}
+
+// ObjectStoreSourceFactory creates object store configuration sources.
+type ObjectStoreSourceFactory struct {
+	logger       logger.Logger
+	errorHandler errors.ErrorHandler
+}
+
+// NewObjectStoreSourceFactory creates a new object store source factory.
+func NewObjectStoreSourceFactory(logger logger.Logger, errorHandler errors.ErrorHandler) *ObjectStoreSourceFactory {
+	return &ObjectStoreSourceFactory{
+		logger:       logger,
+		errorHandler: errorHandler,
+	}
+}
+
+// CreateFromConfig creates an object store source from configuration.
+func (factory *ObjectStoreSourceFactory) CreateFromConfig(config ObjectStoreSourceConfig) (configcore.ConfigSource, error) {
+	options := ObjectStoreSourceOptions{
+		Endpoint:        config.Endpoint,
+		Region:          config.Region,
+		Bucket:          config.Bucket,
+		Key:             config.Key,
+		Env:             config.Env,
+		Vars:            config.Vars,
+		AccessKeyID:     config.AccessKeyID,
+		SecretAccessKey: config.SecretAccessKey,
+		SessionToken:    config.SessionToken,
+		PathStyle:       config.PathStyle,
+		Insecure:        config.Insecure,
+		Format:          config.Format,
+		Optional:        config.Optional,
+		Priority:        config.Priority,
+		ExpandEnvVars:   config.ExpandEnvVars,
+		WatchEnabled:    config.WatchEnabled,
+		WatchInterval:   config.WatchInterval,
+		Timeout:         config.Timeout,
+		Logger:          factory.logger,
+		ErrorHandler:    factory.errorHandler,
+	}
+
+	return NewObjectStoreSource(options)
+}
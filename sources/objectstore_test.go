@@ -0,0 +1,221 @@
+package sources
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	configcore "github.com/xraph/confy/internal"
+)
+
+// fakeS3 emulates the path-style S3 GET and HEAD object API.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]string
+	auth    []string
+	heads   int
+}
+
+func (f *fakeS3) put(path, content string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.objects[path] = content
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.auth = append(f.auth, r.Header.Get("Authorization"))
+
+	if r.Method == http.MethodHead {
+		f.heads++
+	}
+
+	content, ok := f.objects[r.URL.Path]
+	if !ok {
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusNotFound)
+
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`))
+		}
+
+		return
+	}
+
+	sum := md5.Sum([]byte(content))
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+	w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+
+	if r.Method == http.MethodGet {
+		_, _ = w.Write([]byte(content))
+	}
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	t.Helper()
+
+	fake := &fakeS3{objects: make(map[string]string)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	return fake, server
+}
+
+func testObjectStoreOptions(endpoint string) ObjectStoreSourceOptions {
+	return ObjectStoreSourceOptions{
+		Endpoint:        endpoint,
+		Region:          "eu-west-1",
+		Bucket:          "configs",
+		Key:             "config/{env}/app.yaml",
+		Env:             "prod",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+		PathStyle:       true,
+	}
+}
+
+func TestObjectStoreSource_Load(t *testing.T) {
+	fake, server := newFakeS3(t)
+	fake.put("/configs/config/prod/app.yaml", "server:\n  port: 8080\n")
+
+	source, err := NewObjectStoreSource(testObjectStoreOptions(server.URL))
+	if err != nil {
+		t.Fatalf("NewObjectStoreSource() error = %v", err)
+	}
+
+	if source.Name() != "s3://configs/config/prod/app.yaml" {
+		t.Errorf("Name() = %s", source.Name())
+	}
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	serverConfig, _ := data["server"].(map[string]any)
+	if serverConfig["port"] != 8080 {
+		t.Errorf("Load() = %v, want server.port=8080", data)
+	}
+
+	fake.mu.Lock()
+	auth := fake.auth[len(fake.auth)-1]
+	fake.mu.Unlock()
+
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") || !strings.Contains(auth, "/eu-west-1/s3/aws4_request") {
+		t.Errorf("Authorization = %q, want SigV4 for eu-west-1", auth)
+	}
+
+	metadata := &configcore.SourceMetadata{}
+	source.(configcore.SourceMetadataReporter).ReportMetadata(metadata)
+
+	if metadata.Properties["etag"] == "" || metadata.Properties["key"] != "config/prod/app.yaml" {
+		t.Errorf("metadata = %v, want etag and expanded key", metadata.Properties)
+	}
+}
+
+func TestObjectStoreSource_MissingObject(t *testing.T) {
+	_, server := newFakeS3(t)
+
+	source, _ := NewObjectStoreSource(testObjectStoreOptions(server.URL))
+	if _, err := source.Load(context.Background()); err == nil {
+		t.Error("Load() should fail for a missing object")
+	}
+
+	options := testObjectStoreOptions(server.URL)
+	options.Optional = true
+
+	source, _ = NewObjectStoreSource(options)
+
+	data, err := source.Load(context.Background())
+	if err != nil || len(data) != 0 {
+		t.Errorf("Load() = %v, %v, want empty config for optional object", data, err)
+	}
+}
+
+func TestObjectStoreSource_KeyTemplate(t *testing.T) {
+	t.Setenv("APP_ENV", "staging")
+
+	options := testObjectStoreOptions("http://127.0.0.1:1")
+	options.Env = ""
+	options.Key = "{team}/{env}/settings.json"
+	options.Vars = map[string]string{"team": "payments"}
+
+	source, err := NewObjectStoreSource(options)
+	if err != nil {
+		t.Fatalf("NewObjectStoreSource() error = %v", err)
+	}
+
+	if key := source.(*ObjectStoreSource).GetKey(); key != "payments/staging/settings.json" {
+		t.Errorf("GetKey() = %s, want payments/staging/settings.json", key)
+	}
+
+	options.Vars = nil
+	if _, err := NewObjectStoreSource(options); err == nil {
+		t.Error("NewObjectStoreSource() should fail for unset template variables")
+	}
+
+	options.Key = "config/app"
+	if _, err := NewObjectStoreSource(options); err == nil {
+		t.Error("NewObjectStoreSource() should fail without a detectable format")
+	}
+}
+
+func TestObjectStoreSource_WatchETag(t *testing.T) {
+	fake, server := newFakeS3(t)
+	fake.put("/configs/config/prod/app.yaml", "level: info\n")
+
+	options := testObjectStoreOptions(server.URL)
+	options.WatchEnabled = true
+	options.WatchInterval = 20 * time.Millisecond
+
+	source, _ := NewObjectStoreSource(options)
+	if _, err := source.Load(context.Background()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	updates := make(chan map[string]any, 4)
+	if err := source.Watch(context.Background(), func(data map[string]any) { updates <- data }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer func() { _ = source.StopWatch() }()
+
+	// Unchanged polls must not trigger reloads
+	time.Sleep(80 * time.Millisecond)
+
+	select {
+	case data := <-updates:
+		t.Fatalf("unexpected update %v for unchanged ETag", data)
+	default:
+	}
+
+	fake.put("/configs/config/prod/app.yaml", "level: debug\n")
+
+	select {
+	case data := <-updates:
+		if data["level"] != "debug" {
+			t.Errorf("watched data = %v, want level=debug", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for ETag change")
+	}
+
+	fake.mu.Lock()
+	heads := fake.heads
+	fake.mu.Unlock()
+
+	if heads == 0 {
+		t.Error("watch should poll with HEAD requests")
+	}
+}
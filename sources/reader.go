@@ -0,0 +1,229 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	configcore "github.com/xraph/confy/internal"
+	errors "github.com/xraph/go-utils/errs"
+	logger "github.com/xraph/go-utils/log"
+)
+
+// ReaderSource parses configuration from an io.Reader, such as standard
+// input. The reader is consumed on the first Load; later loads return the
+// parsed result.
+type ReaderSource struct {
+	name         string
+	reader       io.Reader
+	format       string
+	priority     int
+	data         map[string]any
+	loadErr      error
+	loaded       bool
+	size         int
+	logger       logger.Logger
+	errorHandler errors.ErrorHandler
+	options      ReaderSourceOptions
+	mu           sync.Mutex
+}
+
+// ReaderSourceOptions contains options for reader sources.
+type ReaderSourceOptions struct {
+	Name          string
+	Priority      int
+	ExpandEnvVars bool
+	// MaxSize limits the bytes read. Defaults to 16 MiB.
+	MaxSize      int64
+	Logger       logger.Logger
+	ErrorHandler errors.ErrorHandler
+}
+
+// NewReaderSource creates a source that parses r once in the given format
+// (yaml, json or toml).
+func NewReaderSource(r io.Reader, format string, options ReaderSourceOptions) (configcore.ConfigSource, error) {
+	if r == nil {
+		return nil, configcore.ErrConfigError("reader source requires a reader", nil)
+	}
+
+	format = strings.ToLower(strings.TrimPrefix(format, "."))
+	if format == "" {
+		return nil, configcore.ErrConfigError("reader source requires a format", nil)
+	}
+
+	if _, err := getFormatProcessor(format); err != nil {
+		return nil, configcore.ErrConfigError("invalid reader source format", err)
+	}
+
+	if options.MaxSize == 0 {
+		options.MaxSize = 16 << 20
+	}
+
+	name := options.Name
+	if name == "" {
+		name = "reader:" + format
+	}
+
+	return &ReaderSource{
+		name:         name,
+		reader:       r,
+		format:       format,
+		priority:     options.Priority,
+		logger:       options.Logger,
+		errorHandler: options.ErrorHandler,
+		options:      options,
+	}, nil
+}
+
+// NewStdinSource creates a reader source over standard input, for command
+// lines such as `kubectl get cm app -o yaml | tool --config -`.
+func NewStdinSource(format string, options ReaderSourceOptions) (configcore.ConfigSource, error) {
+	if options.Name == "" {
+		options.Name = "stdin"
+	}
+
+	return NewReaderSource(os.Stdin, format, options)
+}
+
+// Name returns the source name.
+func (rs *ReaderSource) Name() string {
+	return rs.name
+}
+
+// GetName returns the source name (alias for Name).
+func (rs *ReaderSource) GetName() string {
+	return rs.name
+}
+
+// GetType returns the source type.
+func (rs *ReaderSource) GetType() string {
+	return "reader"
+}
+
+// IsAvailable always returns true.
+func (rs *ReaderSource) IsAvailable(ctx context.Context) bool {
+	return true
+}
+
+// Priority returns the source priority.
+func (rs *ReaderSource) Priority() int {
+	return rs.priority
+}
+
+// Load parses the reader on the first call and returns a copy of the result.
+// A failed read or parse is returned again on later calls, since the reader
+// cannot be rewound.
+func (rs *ReaderSource) Load(ctx context.Context) (map[string]any, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if !rs.loaded {
+		rs.data, rs.loadErr = rs.parse()
+		rs.loaded = true
+		rs.reader = nil
+	}
+
+	if rs.loadErr != nil {
+		return nil, rs.loadErr
+	}
+
+	return copyConfigMap(rs.data), nil
+}
+
+// parse reads and parses the whole reader.
+func (rs *ReaderSource) parse() (map[string]any, error) {
+	content, err := io.ReadAll(io.LimitReader(rs.reader, rs.options.MaxSize+1))
+	if err != nil {
+		return nil, configcore.ErrConfigError("failed to read "+rs.name, err)
+	}
+
+	if int64(len(content)) > rs.options.MaxSize {
+		return nil, configcore.ErrConfigError(fmt.Sprintf("%s exceeds %d bytes", rs.name, rs.options.MaxSize), nil)
+	}
+
+	rs.size = len(content)
+
+	processor, err := getFormatProcessor(rs.format)
+	if err != nil {
+		return nil, configcore.ErrConfigError("invalid reader source format", err)
+	}
+
+	data, err := processor.Parse(content)
+	if err != nil {
+		return nil, configcore.ErrConfigError("failed to parse "+rs.name, err)
+	}
+
+	if data == nil {
+		data = make(map[string]any)
+	}
+
+	if err := processor.Validate(data); err != nil {
+		return nil, configcore.ErrConfigError("validation failed for "+rs.name, err)
+	}
+
+	if rs.options.ExpandEnvVars {
+		data = expandEnvInMap(data)
+	}
+
+	if rs.logger != nil {
+		rs.logger.Debug("configuration loaded from reader",
+			logger.String("name", rs.name),
+			logger.String("format", rs.format),
+			logger.Int("bytes", rs.size),
+		)
+	}
+
+	return data, nil
+}
+
+// Watch is not supported by reader sources.
+func (rs *ReaderSource) Watch(ctx context.Context, callback func(map[string]any)) error {
+	return configcore.ErrConfigError("reader source does not support watching", nil)
+}
+
+// StopWatch is a no-op for reader sources.
+func (rs *ReaderSource) StopWatch() error {
+	return nil
+}
+
+// Reload returns the result of the first load; the reader is not re-read.
+func (rs *ReaderSource) Reload(ctx context.Context) error {
+	_, err := rs.Load(ctx)
+
+	return err
+}
+
+// IsWatchable returns false.
+func (rs *ReaderSource) IsWatchable() bool {
+	return false
+}
+
+// SupportsSecrets returns false.
+func (rs *ReaderSource) SupportsSecrets() bool {
+	return false
+}
+
+// GetSecret is not supported by reader sources.
+func (rs *ReaderSource) GetSecret(ctx context.Context, key string) (string, error) {
+	return "", configcore.ErrConfigError("reader source does not support secrets", nil)
+}
+
+// ReportMetadata reports the format and the number of bytes read.
+func (rs *ReaderSource) ReportMetadata(metadata *configcore.SourceMetadata) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if metadata.Properties == nil {
+		metadata.Properties = make(map[string]any)
+	}
+
+	metadata.Properties["format"] = rs.format
+	metadata.Properties["bytes"] = rs.size
+
+	if rs.loadErr != nil {
+		metadata.LastError = rs.loadErr.Error()
+	}
+}
@@ -0,0 +1,100 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	configcore "github.com/xraph/confy/internal"
+)
+
+// countingReader counts Read calls to observe that the input is consumed once.
+type countingReader struct {
+	r     *strings.Reader
+	reads int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	c.reads++
+
+	return c.r.Read(p)
+}
+
+func TestReaderSource_ParsesOnce(t *testing.T) {
+	reader := &countingReader{r: strings.NewReader("apiVersion: v1\ndata:\n  level: debug\n")}
+
+	source, err := NewReaderSource(reader, "YAML", ReaderSourceOptions{Priority: 500})
+	if err != nil {
+		t.Fatalf("NewReaderSource() error = %v", err)
+	}
+
+	if source.Name() != "reader:yaml" || source.Priority() != 500 || source.IsWatchable() {
+		t.Errorf("source = %s/%d/%v", source.Name(), source.Priority(), source.IsWatchable())
+	}
+
+	first, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	reads := reader.reads
+
+	// Callers must not be able to mutate the cached result
+	first["data"].(map[string]any)["level"] = "changed"
+
+	second, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("second Load() error = %v", err)
+	}
+
+	if second["data"].(map[string]any)["level"] != "debug" {
+		t.Errorf("second Load() = %v, want cached level=debug", second)
+	}
+
+	if reader.reads != reads {
+		t.Errorf("reader read %d more times after the first Load", reader.reads-reads)
+	}
+
+	metadata := &configcore.SourceMetadata{}
+	source.(configcore.SourceMetadataReporter).ReportMetadata(metadata)
+
+	if metadata.Properties["format"] != "yaml" || metadata.Properties["bytes"] == 0 {
+		t.Errorf("metadata = %v", metadata.Properties)
+	}
+}
+
+func TestReaderSource_Errors(t *testing.T) {
+	if _, err := NewReaderSource(strings.NewReader("{}"), "", ReaderSourceOptions{}); err == nil {
+		t.Error("NewReaderSource() should require a format")
+	}
+
+	if _, err := NewReaderSource(strings.NewReader("{}"), "xml", ReaderSourceOptions{}); err == nil {
+		t.Error("NewReaderSource() should reject unsupported formats")
+	}
+
+	source, _ := NewReaderSource(strings.NewReader(`{"a": `), ".json", ReaderSourceOptions{})
+
+	_, first := source.Load(context.Background())
+	_, second := source.Load(context.Background())
+
+	if first == nil || !errors.Is(second, first) {
+		t.Errorf("Load() errors = %v, %v, want the parse error repeated", first, second)
+	}
+
+	source, _ = NewReaderSource(strings.NewReader(`{"key": "0123456789"}`), "json", ReaderSourceOptions{MaxSize: 8})
+	if _, err := source.Load(context.Background()); err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Errorf("Load() error = %v, want size limit", err)
+	}
+}
+
+func TestNewStdinSource(t *testing.T) {
+	source, err := NewStdinSource("toml", ReaderSourceOptions{})
+	if err != nil {
+		t.Fatalf("NewStdinSource() error = %v", err)
+	}
+
+	if source.Name() != "stdin" {
+		t.Errorf("Name() = %s, want stdin", source.Name())
+	}
+}
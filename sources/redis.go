@@ -0,0 +1,819 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	configcore "github.com/xraph/confy/internal"
+	errors "github.com/xraph/go-utils/errs"
+	logger "github.com/xraph/go-utils/log"
+)
+
+// Redis watch modes.
+const (
+	// RedisWatchKeyspace subscribes to keyspace notifications for the loaded keys.
+	RedisWatchKeyspace = "keyspace"
+	// RedisWatchPubSub subscribes to an application channel that is published
+	// to whenever settings change.
+	RedisWatchPubSub = "pubsub"
+)
+
+// RedisSource represents a Redis configuration source reading either a
+// single hash or all keys under a prefix.
+type RedisSource struct {
+	name         string
+	client       redis.UniversalClient
+	ownsClient   bool
+	hash         string
+	prefix       string
+	priority     int
+	keys         []string
+	lastConfig   map[string]any
+	lastLoad     time.Time
+	lastError    error
+	subscribed   bool
+	watching     bool
+	watchStop    chan struct{}
+	logger       logger.Logger
+	errorHandler errors.ErrorHandler
+	options      RedisSourceOptions
+	mu           sync.RWMutex
+}
+
+// RedisSourceOptions contains options for Redis configuration sources.
+type RedisSourceOptions struct {
+	Name     string
+	Addr     string
+	Username string
+	Password string
+	DB       int
+	Priority int
+	// Hash loads the fields of a single hash instead of keys under a prefix.
+	Hash string
+	// Separator splits keys (or hash fields) into nested sections. Defaults to ":".
+	Separator    string
+	WatchEnabled bool
+	// WatchMode is RedisWatchKeyspace (default) or RedisWatchPubSub.
+	WatchMode string
+	// Channel is the pub/sub channel used with RedisWatchPubSub.
+	Channel string
+	// ConfigureNotifications enables the keyspace notification flags needed
+	// for watching with CONFIG SET. Managed Redis services often disallow
+	// CONFIG, in which case the flags must be set on the server.
+	ConfigureNotifications bool
+	DialTimeout            time.Duration
+	Timeout                time.Duration
+	RetryDelay             time.Duration
+	MaxRetryDelay          time.Duration
+	TLS                    *RedisTLSConfig
+	// Client reuses an existing client instead of dialing Addr. The caller
+	// keeps ownership of it.
+	Client       redis.UniversalClient
+	Logger       logger.Logger
+	ErrorHandler errors.ErrorHandler
+}
+
+// RedisSourceConfig contains configuration for creating Redis sources.
+type RedisSourceConfig struct {
+	Addr                   string          `json:"addr"                    yaml:"addr"`
+	Username               string          `json:"username"                yaml:"username"`
+	Password               string          `json:"password"                yaml:"password"`
+	DB                     int             `json:"db"                      yaml:"db"`
+	Prefix                 string          `json:"prefix"                  yaml:"prefix"`
+	Hash                   string          `json:"hash"                    yaml:"hash"`
+	Separator              string          `json:"separator"               yaml:"separator"`
+	Priority               int             `json:"priority"                yaml:"priority"`
+	WatchEnabled           bool            `json:"watch_enabled"           yaml:"watch_enabled"`
+	WatchMode              string          `json:"watch_mode"              yaml:"watch_mode"`
+	Channel                string          `json:"channel"                 yaml:"channel"`
+	ConfigureNotifications bool            `json:"configure_notifications" yaml:"configure_notifications"`
+	DialTimeout            time.Duration   `json:"dial_timeout"            yaml:"dial_timeout"`
+	Timeout                time.Duration   `json:"timeout"                 yaml:"timeout"`
+	RetryDelay             time.Duration   `json:"retry_delay"             yaml:"retry_delay"`
+	MaxRetryDelay          time.Duration   `json:"max_retry_delay"         yaml:"max_retry_delay"`
+	TLS                    *RedisTLSConfig `json:"tls"                     yaml:"tls"`
+}
+
+// RedisTLSConfig contains TLS configuration for Redis.
+type RedisTLSConfig struct {
+	Enabled            bool   `json:"enabled"              yaml:"enabled"`
+	CertFile           string `json:"cert_file"            yaml:"cert_file"`
+	KeyFile            string `json:"key_file"             yaml:"key_file"`
+	CAFile             string `json:"ca_file"              yaml:"ca_file"`
+	ServerName         string `json:"server_name"          yaml:"server_name"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify" yaml:"insecure_skip_verify"`
+}
+
+// NewRedisSource creates a new Redis configuration source for the keys under
+// prefix, or for the hash named in options.Hash. String values are parsed as
+// JSON or YAML where possible; hash keys under a prefix become sections.
+func NewRedisSource(prefix string, options RedisSourceOptions) (configcore.ConfigSource, error) {
+	if options.Addr == "" {
+		options.Addr = "localhost:6379"
+	}
+
+	if options.Separator == "" {
+		options.Separator = ":"
+	}
+
+	if options.WatchMode == "" {
+		options.WatchMode = RedisWatchKeyspace
+	}
+
+	if options.WatchMode != RedisWatchKeyspace && options.WatchMode != RedisWatchPubSub {
+		return nil, configcore.ErrConfigError("unsupported redis watch mode: "+options.WatchMode, nil)
+	}
+
+	if options.WatchMode == RedisWatchPubSub && options.Channel == "" {
+		return nil, configcore.ErrConfigError("redis pubsub watching requires a channel", nil)
+	}
+
+	if options.DialTimeout == 0 {
+		options.DialTimeout = 5 * time.Second
+	}
+
+	if options.Timeout == 0 {
+		options.Timeout = 5 * time.Second
+	}
+
+	if options.RetryDelay == 0 {
+		options.RetryDelay = time.Second
+	}
+
+	if options.MaxRetryDelay == 0 {
+		options.MaxRetryDelay = time.Minute
+	}
+
+	name := options.Name
+	if name == "" {
+		if options.Hash != "" {
+			name = "redis:" + options.Hash
+		} else {
+			name = "redis:" + prefix
+		}
+	}
+
+	client := options.Client
+	ownsClient := false
+
+	if client == nil {
+		redisOptions := &redis.Options{
+			Addr:        options.Addr,
+			Username:    options.Username,
+			Password:    options.Password,
+			DB:          options.DB,
+			DialTimeout: options.DialTimeout,
+		}
+
+		if options.TLS != nil && options.TLS.Enabled {
+			tlsConfig, err := buildHTTPTLSConfig(&HTTPTLSConfig{
+				CertFile:           options.TLS.CertFile,
+				KeyFile:            options.TLS.KeyFile,
+				CAFile:             options.TLS.CAFile,
+				ServerName:         options.TLS.ServerName,
+				InsecureSkipVerify: options.TLS.InsecureSkipVerify,
+			})
+			if err != nil {
+				return nil, configcore.ErrConfigError("failed to configure TLS for redis", err)
+			}
+
+			redisOptions.TLSConfig = tlsConfig
+		}
+
+		client = redis.NewClient(redisOptions)
+		ownsClient = true
+	}
+
+	return &RedisSource{
+		name:         name,
+		client:       client,
+		ownsClient:   ownsClient,
+		hash:         options.Hash,
+		prefix:       prefix,
+		priority:     options.Priority,
+		logger:       options.Logger,
+		errorHandler: options.ErrorHandler,
+		options:      options,
+	}, nil
+}
+
+// Name returns the source name.
+func (rs *RedisSource) Name() string {
+	return rs.name
+}
+
+// GetName returns the source name (alias for Name).
+func (rs *RedisSource) GetName() string {
+	return rs.name
+}
+
+// GetType returns the source type.
+func (rs *RedisSource) GetType() string {
+	return "redis"
+}
+
+// IsAvailable checks if Redis answers a PING.
+func (rs *RedisSource) IsAvailable(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, rs.options.Timeout)
+	defer cancel()
+
+	return rs.client.Ping(ctx).Err() == nil
+}
+
+// Priority returns the source priority.
+func (rs *RedisSource) Priority() int {
+	return rs.priority
+}
+
+// Load loads configuration from Redis.
+func (rs *RedisSource) Load(ctx context.Context) (map[string]any, error) {
+	if rs.logger != nil {
+		rs.logger.Debug("loading configuration from redis",
+			logger.String("addr", rs.options.Addr),
+			logger.String("prefix", rs.prefix),
+			logger.String("hash", rs.hash),
+		)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, rs.options.Timeout)
+	defer cancel()
+
+	var (
+		values map[string]any
+		err    error
+	)
+
+	if rs.hash != "" {
+		values, err = rs.loadHash(ctx)
+	} else {
+		values, err = rs.loadPrefix(ctx)
+	}
+
+	if err != nil {
+		rs.mu.Lock()
+		rs.lastError = err
+		rs.mu.Unlock()
+
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	// Parents before children so that nested keys win over scalar parents
+	sort.Strings(keys)
+
+	config := make(map[string]any)
+
+	for _, key := range keys {
+		setNestedPath(config, strings.Split(key, rs.options.Separator), values[key])
+	}
+
+	rs.mu.Lock()
+	rs.keys = rs.redisKeys(keys)
+	rs.lastConfig = config
+	rs.lastLoad = time.Now()
+	rs.lastError = nil
+	rs.mu.Unlock()
+
+	if rs.logger != nil {
+		rs.logger.Info("configuration loaded from redis",
+			logger.String("prefix", rs.prefix),
+			logger.String("hash", rs.hash),
+			logger.Int("keys", len(keys)),
+		)
+	}
+
+	return copyConfigMap(config), nil
+}
+
+// Watch starts watching Redis for changes.
+func (rs *RedisSource) Watch(ctx context.Context, callback func(map[string]any)) error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if rs.watching {
+		return configcore.ErrConfigError("already watching redis", nil)
+	}
+
+	if !rs.IsWatchable() {
+		return configcore.ErrConfigError("redis watching is not enabled", nil)
+	}
+
+	rs.watchStop = make(chan struct{})
+	rs.watching = true
+
+	go rs.watchLoop(ctx, rs.watchStop, callback)
+
+	if rs.logger != nil {
+		rs.logger.Info("started watching redis",
+			logger.String("prefix", rs.prefix),
+			logger.String("hash", rs.hash),
+			logger.String("mode", rs.options.WatchMode),
+		)
+	}
+
+	return nil
+}
+
+// StopWatch stops watching Redis.
+func (rs *RedisSource) StopWatch() error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if !rs.watching {
+		return nil
+	}
+
+	if rs.watchStop != nil {
+		close(rs.watchStop)
+		rs.watchStop = nil
+	}
+
+	rs.watching = false
+
+	if rs.logger != nil {
+		rs.logger.Info("stopped watching redis",
+			logger.String("prefix", rs.prefix),
+			logger.String("hash", rs.hash),
+		)
+	}
+
+	return nil
+}
+
+// Reload forces a reload of Redis configuration.
+func (rs *RedisSource) Reload(ctx context.Context) error {
+	_, err := rs.Load(ctx)
+
+	return err
+}
+
+// IsWatchable returns true if Redis watching is enabled.
+func (rs *RedisSource) IsWatchable() bool {
+	return rs.options.WatchEnabled
+}
+
+// SupportsSecrets returns true (Redis can store secrets).
+func (rs *RedisSource) SupportsSecrets() bool {
+	return true
+}
+
+// GetSecret retrieves a secret from Redis. Keys are hash fields in hash mode
+// and keys relative to the prefix otherwise.
+func (rs *RedisSource) GetSecret(ctx context.Context, key string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, rs.options.Timeout)
+	defer cancel()
+
+	var cmd *redis.StringCmd
+
+	if rs.hash != "" {
+		cmd = rs.client.HGet(ctx, rs.hash, key)
+	} else {
+		if !strings.HasPrefix(key, rs.keyPrefix()) {
+			key = rs.keyPrefix() + key
+		}
+
+		cmd = rs.client.Get(ctx, key)
+	}
+
+	value, err := cmd.Result()
+	if err == redis.Nil {
+		return "", configcore.ErrConfigError("secret not found in redis: "+key, nil)
+	}
+
+	if err != nil {
+		return "", configcore.ErrConfigError(fmt.Sprintf("failed to get secret from redis: %v", err), err)
+	}
+
+	return value, nil
+}
+
+// Close closes the Redis client if the source created it.
+func (rs *RedisSource) Close() error {
+	if rs.ownsClient && rs.client != nil {
+		return rs.client.Close()
+	}
+
+	return nil
+}
+
+// ReportMetadata reports the loaded keys and watch state.
+func (rs *RedisSource) ReportMetadata(metadata *configcore.SourceMetadata) {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	if metadata.Properties == nil {
+		metadata.Properties = make(map[string]any)
+	}
+
+	metadata.Properties["keys"] = append([]string(nil), rs.keys...)
+	metadata.Properties["watch_mode"] = rs.options.WatchMode
+	metadata.Properties["subscribed"] = rs.subscribed
+
+	if !rs.lastLoad.IsZero() {
+		metadata.Properties["last_load"] = rs.lastLoad
+	}
+
+	if rs.lastError != nil {
+		metadata.LastError = rs.lastError.Error()
+	}
+}
+
+// loadHash reads the fields of the configured hash.
+func (rs *RedisSource) loadHash(ctx context.Context) (map[string]any, error) {
+	fields, err := rs.client.HGetAll(ctx, rs.hash).Result()
+	if err != nil {
+		return nil, configcore.ErrConfigError(fmt.Sprintf("failed to read redis hash %s: %v", rs.hash, err), err)
+	}
+
+	values := make(map[string]any, len(fields))
+	for field, value := range fields {
+		values[field] = parseKVValue([]byte(value))
+	}
+
+	return values, nil
+}
+
+// loadPrefix reads all string and hash keys under the prefix, keyed by the
+// remainder after the prefix.
+func (rs *RedisSource) loadPrefix(ctx context.Context) (map[string]any, error) {
+	var keys []string
+
+	iter := rs.client.Scan(ctx, 0, escapeRedisPattern(rs.keyPrefix())+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, configcore.ErrConfigError(fmt.Sprintf("failed to scan redis keys: %v", err), err)
+	}
+
+	values := make(map[string]any, len(keys))
+	if len(keys) == 0 {
+		return values, nil
+	}
+
+	types := make([]*redis.StatusCmd, len(keys))
+
+	pipe := rs.client.Pipeline()
+	for i, key := range keys {
+		types[i] = pipe.Type(ctx, key)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, configcore.ErrConfigError(fmt.Sprintf("failed to read redis key types: %v", err), err)
+	}
+
+	strs := make(map[string]*redis.StringCmd)
+	hashes := make(map[string]*redis.MapStringStringCmd)
+
+	pipe = rs.client.Pipeline()
+
+	for i, key := range keys {
+		switch types[i].Val() {
+		case "string":
+			strs[key] = pipe.Get(ctx, key)
+		case "hash":
+			hashes[key] = pipe.HGetAll(ctx, key)
+		default:
+			if rs.logger != nil {
+				rs.logger.Debug("skipping unsupported redis key type",
+					logger.String("key", key),
+					logger.String("type", types[i].Val()),
+				)
+			}
+		}
+	}
+
+	// Keys deleted between SCAN and GET report redis.Nil and are skipped
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, configcore.ErrConfigError(fmt.Sprintf("failed to read redis keys: %v", err), err)
+	}
+
+	for key, cmd := range strs {
+		value, err := cmd.Result()
+		if err != nil {
+			continue
+		}
+
+		values[rs.relativeKey(key)] = parseKVValue([]byte(value))
+	}
+
+	for key, cmd := range hashes {
+		fields := make(map[string]any, len(cmd.Val()))
+		for field, value := range cmd.Val() {
+			fields[field] = parseKVValue([]byte(value))
+		}
+
+		values[rs.relativeKey(key)] = fields
+	}
+
+	return values, nil
+}
+
+// keyPrefix returns the prefix ending with the separator, so that "app"
+// does not match "app2".
+func (rs *RedisSource) keyPrefix() string {
+	if rs.prefix == "" || strings.HasSuffix(rs.prefix, rs.options.Separator) {
+		return rs.prefix
+	}
+
+	return rs.prefix + rs.options.Separator
+}
+
+// relativeKey strips the prefix from a key.
+func (rs *RedisSource) relativeKey(key string) string {
+	return strings.TrimPrefix(key, rs.keyPrefix())
+}
+
+// redisKeys maps relative keys back to the Redis keys (or hash fields) they
+// were loaded from.
+func (rs *RedisSource) redisKeys(relative []string) []string {
+	if rs.hash != "" {
+		return append([]string(nil), relative...)
+	}
+
+	keys := make([]string, len(relative))
+	for i, key := range relative {
+		keys[i] = rs.keyPrefix() + key
+	}
+
+	return keys
+}
+
+// channel returns the channel pattern to subscribe to.
+func (rs *RedisSource) channel() string {
+	if rs.options.WatchMode == RedisWatchPubSub {
+		return rs.options.Channel
+	}
+
+	space := "__keyspace@" + strconv.Itoa(rs.options.DB) + "__:"
+
+	if rs.hash != "" {
+		return space + escapeRedisPattern(rs.hash)
+	}
+
+	return space + escapeRedisPattern(rs.keyPrefix()) + "*"
+}
+
+// enableNotifications adds the keyspace flags needed for watching to the
+// server's notify-keyspace-events setting.
+func (rs *RedisSource) enableNotifications(ctx context.Context) error {
+	current, err := rs.client.ConfigGet(ctx, "notify-keyspace-events").Result()
+	if err != nil {
+		return err
+	}
+
+	flags := current["notify-keyspace-events"]
+	updated := flags
+
+	for _, flag := range "Kg$hx" {
+		if strings.ContainsRune(updated, flag) || (flag != 'K' && strings.ContainsRune(updated, 'A')) {
+			continue
+		}
+
+		updated += string(flag)
+	}
+
+	if updated == flags {
+		return nil
+	}
+
+	return rs.client.ConfigSet(ctx, "notify-keyspace-events", updated).Err()
+}
+
+// watchLoop keeps a subscription open, resubscribing with backoff after
+// errors and reloading after each reconnect to catch missed changes.
+func (rs *RedisSource) watchLoop(ctx context.Context, stop chan struct{}, callback func(map[string]any)) {
+	defer func() {
+		if r := recover(); r != nil {
+			if rs.logger != nil {
+				rs.logger.Error("panic in redis watch loop",
+					logger.String("prefix", rs.prefix),
+					logger.Any("panic", r),
+				)
+			}
+		}
+	}()
+
+	if rs.options.WatchMode == RedisWatchKeyspace && rs.options.ConfigureNotifications {
+		if err := rs.enableNotifications(ctx); err != nil && rs.logger != nil {
+			rs.logger.Warn("failed to enable redis keyspace notifications",
+				logger.Error(err),
+			)
+		}
+	}
+
+	failures := 0
+	resync := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		default:
+		}
+
+		subscribed, err := rs.subscribeOnce(ctx, stop, callback, resync)
+		if subscribed {
+			failures = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		default:
+		}
+
+		if err == nil {
+			continue
+		}
+
+		failures++
+		resync = true
+		delay := backoffDelay(failures, rs.options.RetryDelay, rs.options.MaxRetryDelay)
+
+		rs.handleWatchError(err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// subscribeOnce subscribes and applies notifications until the subscription
+// fails or the watch stops. It reports whether the subscription was
+// established.
+func (rs *RedisSource) subscribeOnce(ctx context.Context, stop chan struct{}, callback func(map[string]any), resync bool) (bool, error) {
+	var pubsub *redis.PubSub
+	if rs.options.WatchMode == RedisWatchPubSub {
+		pubsub = rs.client.Subscribe(ctx, rs.channel())
+	} else {
+		pubsub = rs.client.PSubscribe(ctx, rs.channel())
+	}
+
+	defer pubsub.Close()
+
+	// Closing the subscription unblocks Receive when the watch stops
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-stop:
+		case <-done:
+			return
+		}
+
+		_ = pubsub.Close()
+	}()
+
+	// Wait for the subscription confirmation so that errors surface here
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return false, err
+	}
+
+	rs.setSubscribed(true)
+	defer rs.setSubscribed(false)
+
+	if resync {
+		rs.reloadIfChanged(ctx, callback)
+	}
+
+	for {
+		msg, err := pubsub.Receive(ctx)
+		if err != nil {
+			return true, err
+		}
+
+		if _, ok := msg.(*redis.Message); ok {
+			rs.reloadIfChanged(ctx, callback)
+		}
+	}
+}
+
+// reloadIfChanged reloads and invokes the callback when the configuration
+// differs from the last load.
+func (rs *RedisSource) reloadIfChanged(ctx context.Context, callback func(map[string]any)) {
+	rs.mu.RLock()
+	previous := rs.lastConfig
+	rs.mu.RUnlock()
+
+	config, err := rs.Load(ctx)
+	if err != nil {
+		rs.handleWatchError(err)
+
+		return
+	}
+
+	if reflect.DeepEqual(previous, config) {
+		return
+	}
+
+	if rs.logger != nil {
+		rs.logger.Info("redis changes detected",
+			logger.String("prefix", rs.prefix),
+			logger.String("hash", rs.hash),
+		)
+	}
+
+	if callback != nil {
+		callback(config)
+	}
+}
+
+func (rs *RedisSource) setSubscribed(subscribed bool) {
+	rs.mu.Lock()
+	rs.subscribed = subscribed
+	rs.mu.Unlock()
+}
+
+// handleWatchError handles errors during watching.
+func (rs *RedisSource) handleWatchError(err error) {
+	if rs.logger != nil {
+		rs.logger.Warn("redis watch error, resubscribing",
+			logger.String("prefix", rs.prefix),
+			logger.String("hash", rs.hash),
+			logger.Error(err),
+		)
+	}
+
+	if rs.errorHandler != nil {
+		_ = rs.errorHandler.HandleError(context.Background(), configcore.ErrConfigError("redis watch error for "+rs.name, err))
+	}
+}
+
+// escapeRedisPattern escapes glob characters for SCAN MATCH and PSUBSCRIBE.
+func escapeRedisPattern(s string) string {
+	var b strings.Builder
+
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// RedisSourceFactory creates Redis configuration sources.
+type RedisSourceFactory struct {
+	logger       logger.Logger
+	errorHandler errors.ErrorHandler
+}
+
+// NewRedisSourceFactory creates a new Redis source factory.
+func NewRedisSourceFactory(logger logger.Logger, errorHandler errors.ErrorHandler) *RedisSourceFactory {
+	return &RedisSourceFactory{
+		logger:       logger,
+		errorHandler: errorHandler,
+	}
+}
+
+// CreateFromConfig creates a Redis source from configuration.
+func (factory *RedisSourceFactory) CreateFromConfig(config RedisSourceConfig) (configcore.ConfigSource, error) {
+	options := RedisSourceOptions{
+		Addr:                   config.Addr,
+		Username:               config.Username,
+		Password:               config.Password,
+		DB:                     config.DB,
+		Hash:                   config.Hash,
+		Separator:              config.Separator,
+		Priority:               config.Priority,
+		WatchEnabled:           config.WatchEnabled,
+		WatchMode:              config.WatchMode,
+		Channel:                config.Channel,
+		ConfigureNotifications: config.ConfigureNotifications,
+		DialTimeout:            config.DialTimeout,
+		Timeout:                config.Timeout,
+		RetryDelay:             config.RetryDelay,
+		MaxRetryDelay:          config.MaxRetryDelay,
+		TLS:                    config.TLS,
+		Logger:                 factory.logger,
+		ErrorHandler:           factory.errorHandler,
+	}
+
+	return NewRedisSource(config.Prefix, options)
+}
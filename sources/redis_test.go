@@ -0,0 +1,205 @@
+package sources
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	configcore "github.com/xraph/confy/internal"
+)
+
+func TestRedisSource_LoadPrefix(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	mr.Set("app:database:host", "db.internal")
+	mr.Set("app:database:port", "5432")
+	mr.Set("app:features", `{"search": true}`)
+	mr.HSet("app:limits", "rps", "100", "burst", "20")
+	mr.Lpush("app:queue", "ignored")
+	mr.Set("app2:other", "ignored")
+
+	source, err := NewRedisSource("app", RedisSourceOptions{Addr: mr.Addr()})
+	if err != nil {
+		t.Fatalf("NewRedisSource() error = %v", err)
+	}
+	defer source.(*RedisSource).Close()
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := map[string]any{
+		"database": map[string]any{"host": "db.internal", "port": float64(5432)},
+		"features": map[string]any{"search": true},
+		"limits":   map[string]any{"rps": float64(100), "burst": float64(20)},
+	}
+
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("Load() = %#v, want %#v", data, want)
+	}
+
+	metadata := &configcore.SourceMetadata{}
+	source.(configcore.SourceMetadataReporter).ReportMetadata(metadata)
+
+	wantKeys := []string{"app:database:host", "app:database:port", "app:features", "app:limits"}
+	if !reflect.DeepEqual(metadata.Properties["keys"], wantKeys) {
+		t.Errorf("metadata keys = %v, want %v", metadata.Properties["keys"], wantKeys)
+	}
+
+	secret, err := source.GetSecret(context.Background(), "database:host")
+	if err != nil || secret != "db.internal" {
+		t.Errorf("GetSecret() = %q, %v", secret, err)
+	}
+}
+
+func TestRedisSource_LoadHash(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	mr.HSet("settings", "log.level", "debug", "cache.ttl", "30")
+
+	source, _ := NewRedisSource("", RedisSourceOptions{
+		Addr:      mr.Addr(),
+		Hash:      "settings",
+		Separator: ".",
+	})
+	defer source.(*RedisSource).Close()
+
+	if source.Name() != "redis:settings" {
+		t.Errorf("Name() = %s, want redis:settings", source.Name())
+	}
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := map[string]any{
+		"log":   map[string]any{"level": "debug"},
+		"cache": map[string]any{"ttl": float64(30)},
+	}
+
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("Load() = %#v, want %#v", data, want)
+	}
+}
+
+func TestRedisSource_WatchKeyspace(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mr.Set("app:level", "info")
+
+	source, _ := NewRedisSource("app", RedisSourceOptions{
+		Addr:         mr.Addr(),
+		WatchEnabled: true,
+	})
+	defer source.(*RedisSource).Close()
+
+	_, _ = source.Load(context.Background())
+
+	updates := make(chan map[string]any, 4)
+	if err := source.Watch(context.Background(), func(data map[string]any) { updates <- data }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer func() { _ = source.StopWatch() }()
+
+	waitForRedisSubscribers(t, mr, "__keyspace@0__:app:*")
+
+	// miniredis does not emit keyspace events; publish what Redis would
+	mr.Set("app:level", "debug")
+	mr.Publish("__keyspace@0__:app:level", "set")
+
+	select {
+	case data := <-updates:
+		if data["level"] != "debug" {
+			t.Errorf("watched data = %v, want level=debug", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for keyspace update")
+	}
+}
+
+func TestRedisSource_WatchPubSubReconnects(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mr.Set("app:level", "info")
+
+	source, err := NewRedisSource("app", RedisSourceOptions{
+		Addr:          mr.Addr(),
+		WatchEnabled:  true,
+		WatchMode:     RedisWatchPubSub,
+		Channel:       "config-changed",
+		RetryDelay:    10 * time.Millisecond,
+		MaxRetryDelay: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewRedisSource() error = %v", err)
+	}
+	defer source.(*RedisSource).Close()
+
+	_, _ = source.Load(context.Background())
+
+	updates := make(chan map[string]any, 4)
+	_ = source.Watch(context.Background(), func(data map[string]any) { updates <- data })
+	defer func() { _ = source.StopWatch() }()
+
+	waitForRedisSubscribers(t, mr, "config-changed")
+
+	// Changes made while disconnected are picked up after resubscribing
+	mr.Close()
+	mr.Set("app:level", "warn")
+
+	if err := mr.Restart(); err != nil {
+		t.Fatalf("Restart() error = %v", err)
+	}
+
+	select {
+	case data := <-updates:
+		if data["level"] != "warn" {
+			t.Errorf("resynced data = %v, want level=warn", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for resync after reconnect")
+	}
+
+	waitForRedisSubscribers(t, mr, "config-changed")
+
+	mr.Set("app:level", "error")
+	mr.Publish("config-changed", "app")
+
+	select {
+	case data := <-updates:
+		if data["level"] != "error" {
+			t.Errorf("watched data = %v, want level=error", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for pubsub update")
+	}
+}
+
+func TestNewRedisSource_InvalidWatchMode(t *testing.T) {
+	if _, err := NewRedisSource("app", RedisSourceOptions{WatchMode: "stream"}); err == nil {
+		t.Error("NewRedisSource() should reject unknown watch modes")
+	}
+
+	if _, err := NewRedisSource("app", RedisSourceOptions{WatchMode: RedisWatchPubSub}); err == nil {
+		t.Error("NewRedisSource() should require a channel for pubsub")
+	}
+}
+
+// waitForRedisSubscribers waits until a channel or pattern has a subscriber.
+func waitForRedisSubscribers(t *testing.T, mr *miniredis.Miniredis, channel string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for time.Now().Before(deadline) {
+		if mr.PubSubNumSub(channel)[channel] > 0 || mr.PubSubNumPat() > 0 {
+			return
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	t.Fatalf("no subscriber for %s", channel)
+}
@@ -0,0 +1,767 @@
+package sources
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	configcore "github.com/xraph/confy/internal"
+	errors "github.com/xraph/go-utils/errs"
+	logger "github.com/xraph/go-utils/log"
+)
+
+// sqlIdentifier matches table and column names that are safe to interpolate.
+var sqlIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// SQLSource represents a configuration source backed by key/value rows of a
+// database table.
+type SQLSource struct {
+	name         string
+	db           *sql.DB
+	ownsDB       bool
+	priority     int
+	lastMarker   string
+	lastRows     int
+	watching     bool
+	watchStop    chan struct{}
+	logger       logger.Logger
+	errorHandler errors.ErrorHandler
+	options      SQLSourceOptions
+	mu           sync.RWMutex
+}
+
+// SQLSourceOptions contains options for SQL sources.
+type SQLSourceOptions struct {
+	Name     string
+	Priority int
+	// Table defaults to "config"; KeyColumn and ValueColumn default to "key"
+	// and "value".
+	Table       string
+	KeyColumn   string
+	ValueColumn string
+	// FormatColumn optionally holds the format of each value: "string",
+	// "json" or a registered document format such as "yaml".
+	FormatColumn string
+	// UpdatedAtColumn or VersionColumn enables cheap change detection by
+	// polling their maximum and the row count. Versions must increase
+	// table-wide (Persist assigns max+1); timestamps only detect changes
+	// at their precision. Without either, watching compares full reads.
+	UpdatedAtColumn string
+	VersionColumn   string
+	// KeyPrefix restricts loading to keys under this prefix, which is
+	// removed. It is matched on a KeySeparator boundary.
+	KeyPrefix string
+	// KeySeparator splits keys into nested sections. Defaults to ".".
+	KeySeparator string
+	// Dialect selects placeholder and quoting syntax: "postgres" uses $1,
+	// others use ?; "mysql" quotes identifiers with backticks, others with
+	// double quotes.
+	Dialect       string
+	WatchEnabled  bool
+	WatchInterval time.Duration
+	// WriteEnabled allows Persist, and with it PersistableConfy.Persist and PersistOnSet.
+	WriteEnabled bool
+	Timeout      time.Duration
+	Logger       logger.Logger
+	ErrorHandler errors.ErrorHandler
+}
+
+// SQLSourceConfig contains configuration for creating SQL sources.
+type SQLSourceConfig struct {
+	Driver          string        `json:"driver"            yaml:"driver"`
+	DSN             string        `json:"dsn"               yaml:"dsn"`
+	Priority        int           `json:"priority"          yaml:"priority"`
+	Table           string        `json:"table"             yaml:"table"`
+	KeyColumn       string        `json:"key_column"        yaml:"key_column"`
+	ValueColumn     string        `json:"value_column"      yaml:"value_column"`
+	FormatColumn    string        `json:"format_column"     yaml:"format_column"`
+	UpdatedAtColumn string        `json:"updated_at_column" yaml:"updated_at_column"`
+	VersionColumn   string        `json:"version_column"    yaml:"version_column"`
+	KeyPrefix       string        `json:"key_prefix"        yaml:"key_prefix"`
+	KeySeparator    string        `json:"key_separator"     yaml:"key_separator"`
+	Dialect         string        `json:"dialect"           yaml:"dialect"`
+	WatchEnabled    bool          `json:"watch_enabled"     yaml:"watch_enabled"`
+	WatchInterval   time.Duration `json:"watch_interval"    yaml:"watch_interval"`
+	WriteEnabled    bool          `json:"write_enabled"     yaml:"write_enabled"`
+	Timeout         time.Duration `json:"timeout"           yaml:"timeout"`
+}
+
+// NewSQLSource creates a new SQL configuration source over db. The caller
+// keeps ownership of db; Close leaves it open.
+func NewSQLSource(db *sql.DB, options SQLSourceOptions) (configcore.ConfigSource, error) {
+	if db == nil {
+		return nil, configcore.ErrConfigError("SQL source database cannot be nil", nil)
+	}
+
+	if options.Table == "" {
+		options.Table = "config"
+	}
+
+	if options.KeyColumn == "" {
+		options.KeyColumn = "key"
+	}
+
+	if options.ValueColumn == "" {
+		options.ValueColumn = "value"
+	}
+
+	if options.KeySeparator == "" {
+		options.KeySeparator = "."
+	}
+
+	if options.WatchInterval == 0 {
+		options.WatchInterval = 30 * time.Second
+	}
+
+	if options.Timeout == 0 {
+		options.Timeout = 10 * time.Second
+	}
+
+	for _, identifier := range []string{
+		options.Table, options.KeyColumn, options.ValueColumn,
+		options.FormatColumn, options.UpdatedAtColumn, options.VersionColumn,
+	} {
+		if identifier != "" && !sqlIdentifier.MatchString(identifier) {
+			return nil, configcore.ErrConfigError("invalid SQL identifier: "+identifier, nil)
+		}
+	}
+
+	name := options.Name
+	if name == "" {
+		name = "sql:" + options.Table
+	}
+
+	return &SQLSource{
+		name:         name,
+		db:           db,
+		priority:     options.Priority,
+		logger:       options.Logger,
+		errorHandler: options.ErrorHandler,
+		options:      options,
+	}, nil
+}
+
+// Name returns the source name.
+func (ss *SQLSource) Name() string {
+	return ss.name
+}
+
+// GetName returns the source name (alias for Name).
+func (ss *SQLSource) GetName() string {
+	return ss.name
+}
+
+// GetType returns the source type.
+func (ss *SQLSource) GetType() string {
+	return "sql"
+}
+
+// IsAvailable checks if the database is reachable.
+func (ss *SQLSource) IsAvailable(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, ss.options.Timeout)
+	defer cancel()
+
+	return ss.db.PingContext(ctx) == nil
+}
+
+// Priority returns the source priority.
+func (ss *SQLSource) Priority() int {
+	return ss.priority
+}
+
+// Load reads all rows and builds nested configuration.
+func (ss *SQLSource) Load(ctx context.Context) (map[string]any, error) {
+	ctx, cancel := context.WithTimeout(ctx, ss.options.Timeout)
+	defer cancel()
+
+	if ss.logger != nil {
+		ss.logger.Debug("loading configuration from SQL",
+			logger.String("table", ss.options.Table),
+		)
+	}
+
+	columns := ss.quote(ss.options.KeyColumn) + ", " + ss.quote(ss.options.ValueColumn)
+	if ss.options.FormatColumn != "" {
+		columns += ", " + ss.quote(ss.options.FormatColumn)
+	}
+
+	query := "SELECT " + columns + " FROM " + ss.quote(ss.options.Table) + //nolint:gosec // G202: identifiers are validated
+		" ORDER BY " + ss.quote(ss.options.KeyColumn)
+
+	rows, err := ss.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, configcore.ErrConfigError("failed to query SQL table "+ss.options.Table, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	prefix := ss.keyPrefix()
+	config := make(map[string]any)
+	count := 0
+
+	for rows.Next() {
+		var (
+			key    string
+			value  sql.NullString
+			format sql.NullString
+		)
+
+		dest := []any{&key, &value}
+		if ss.options.FormatColumn != "" {
+			dest = append(dest, &format)
+		}
+
+		if err := rows.Scan(dest...); err != nil {
+			return nil, configcore.ErrConfigError("failed to scan SQL row", err)
+		}
+
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		relativeKey := strings.Trim(strings.TrimPrefix(key, prefix), ss.options.KeySeparator)
+		if relativeKey == "" {
+			continue
+		}
+
+		parsed, err := ss.parseValue(value.String, format.String)
+		if err != nil {
+			return nil, configcore.ErrConfigError("failed to parse SQL value for key "+key, err)
+		}
+
+		ss.setNestedValue(config, relativeKey, parsed)
+
+		count++
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, configcore.ErrConfigError("failed to read SQL rows", err)
+	}
+
+	if ss.logger != nil {
+		ss.logger.Debug("loaded configuration from SQL",
+			logger.String("table", ss.options.Table),
+			logger.Int("rows", count),
+		)
+	}
+
+	ss.mu.Lock()
+	ss.lastRows = count
+	ss.mu.Unlock()
+
+	return config, nil
+}
+
+// Watch starts polling the table for changes.
+func (ss *SQLSource) Watch(ctx context.Context, callback func(map[string]any)) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if ss.watching {
+		return configcore.ErrConfigError("already watching SQL source", nil)
+	}
+
+	if !ss.IsWatchable() {
+		return configcore.ErrConfigError("SQL watching is not enabled", nil)
+	}
+
+	ss.watchStop = make(chan struct{})
+	ss.watching = true
+
+	go ss.watchLoop(ctx, ss.watchStop, callback)
+
+	if ss.logger != nil {
+		ss.logger.Info("started watching SQL source",
+			logger.String("table", ss.options.Table),
+		)
+	}
+
+	return nil
+}
+
+// StopWatch stops polling the table.
+func (ss *SQLSource) StopWatch() error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if !ss.watching {
+		return nil
+	}
+
+	if ss.watchStop != nil {
+		close(ss.watchStop)
+		ss.watchStop = nil
+	}
+
+	ss.watching = false
+
+	if ss.logger != nil {
+		ss.logger.Info("stopped watching SQL source",
+			logger.String("table", ss.options.Table),
+		)
+	}
+
+	return nil
+}
+
+// Reload reloads the configuration.
+func (ss *SQLSource) Reload(ctx context.Context) error {
+	_, err := ss.Load(ctx)
+
+	return err
+}
+
+// IsWatchable returns true if SQL watching is enabled.
+func (ss *SQLSource) IsWatchable() bool {
+	return ss.options.WatchEnabled
+}
+
+// SupportsSecrets returns false.
+func (ss *SQLSource) SupportsSecrets() bool {
+	return false
+}
+
+// GetSecret is not supported by SQL sources.
+func (ss *SQLSource) GetSecret(ctx context.Context, key string) (string, error) {
+	return "", configcore.ErrConfigError("SQL source does not support secrets", nil)
+}
+
+// IsWritable returns true if writes are enabled.
+func (ss *SQLSource) IsWritable() bool {
+	return ss.options.WriteEnabled
+}
+
+// Persist writes value at the dot-separated key. Maps are written as one
+// row per leaf. Strings are stored as is and other values as JSON.
+func (ss *SQLSource) Persist(ctx context.Context, key string, value any) error {
+	if !ss.options.WriteEnabled {
+		return configcore.ErrConfigError("SQL source is not writable", nil)
+	}
+
+	leaves := make(map[string]any)
+	if nested, ok := value.(map[string]any); ok && len(nested) > 0 {
+		flattenConfig(nested, key, leaves)
+	} else {
+		leaves[key] = value
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ss.options.Timeout)
+	defer cancel()
+
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		return configcore.ErrConfigError("failed to begin SQL transaction", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var version int64
+	if ss.options.VersionColumn != "" {
+		if version, err = ss.nextVersion(ctx, tx); err != nil {
+			return err
+		}
+	}
+
+	for leafKey, leafValue := range leaves {
+		if err := ss.writeRow(ctx, tx, leafKey, leafValue, version); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return configcore.ErrConfigError("failed to commit SQL transaction", err)
+	}
+
+	if ss.logger != nil {
+		ss.logger.Info("persisted configuration to SQL",
+			logger.String("table", ss.options.Table),
+			logger.String("key", key),
+			logger.Int("rows", len(leaves)),
+		)
+	}
+
+	return nil
+}
+
+// Close closes the database if the source opened it.
+func (ss *SQLSource) Close() error {
+	if ss.ownsDB {
+		return ss.db.Close()
+	}
+
+	return nil
+}
+
+// GetTable returns the table name.
+func (ss *SQLSource) GetTable() string {
+	return ss.options.Table
+}
+
+// ReportMetadata reports the table, row count and change marker.
+func (ss *SQLSource) ReportMetadata(metadata *configcore.SourceMetadata) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	if metadata.Properties == nil {
+		metadata.Properties = make(map[string]any)
+	}
+
+	metadata.Properties["table"] = ss.options.Table
+	metadata.Properties["rows"] = ss.lastRows
+	metadata.Properties["writable"] = ss.options.WriteEnabled
+
+	if ss.lastMarker != "" {
+		metadata.Properties["marker"] = ss.lastMarker
+	}
+}
+
+// writeRow updates or inserts a single row. version is only used when a
+// version column is configured.
+func (ss *SQLSource) writeRow(ctx context.Context, tx *sql.Tx, key string, value any, version int64) error {
+	dbKey := ss.keyPrefix() + strings.ReplaceAll(key, ".", ss.options.KeySeparator)
+
+	encoded, format, err := encodeSQLValue(value)
+	if err != nil {
+		return configcore.ErrConfigError("failed to encode value for key "+key, err)
+	}
+
+	setColumns := []string{ss.options.ValueColumn}
+	args := []any{encoded}
+
+	if ss.options.FormatColumn != "" {
+		setColumns = append(setColumns, ss.options.FormatColumn)
+		args = append(args, format)
+	}
+
+	if ss.options.VersionColumn != "" {
+		setColumns = append(setColumns, ss.options.VersionColumn)
+		args = append(args, version)
+	}
+
+	assignments := make([]string, 0, len(setColumns)+1)
+	for i, column := range setColumns {
+		assignments = append(assignments, ss.quote(column)+" = "+ss.placeholder(i+1))
+	}
+
+	if ss.options.UpdatedAtColumn != "" {
+		assignments = append(assignments, ss.quote(ss.options.UpdatedAtColumn)+" = CURRENT_TIMESTAMP")
+	}
+
+	update := "UPDATE " + ss.quote(ss.options.Table) + " SET " + strings.Join(assignments, ", ") + //nolint:gosec // G202: identifiers are validated
+		" WHERE " + ss.quote(ss.options.KeyColumn) + " = " + ss.placeholder(len(args)+1)
+
+	result, err := tx.ExecContext(ctx, update, append(args, dbKey)...)
+	if err != nil {
+		return configcore.ErrConfigError("failed to update SQL key "+dbKey, err)
+	}
+
+	if affected, err := result.RowsAffected(); err == nil && affected > 0 {
+		return nil
+	}
+
+	columns := append([]string{ss.options.KeyColumn}, setColumns...)
+	insertArgs := append([]any{dbKey}, args...)
+
+	placeholders := make([]string, 0, len(columns)+1)
+	for i := range columns {
+		placeholders = append(placeholders, ss.placeholder(i+1))
+	}
+
+	if ss.options.UpdatedAtColumn != "" {
+		columns = append(columns, ss.options.UpdatedAtColumn)
+		placeholders = append(placeholders, "CURRENT_TIMESTAMP")
+	}
+
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = ss.quote(column)
+	}
+
+	insert := "INSERT INTO " + ss.quote(ss.options.Table) + " (" + strings.Join(quoted, ", ") + //nolint:gosec // G202: identifiers are validated
+		") VALUES (" + strings.Join(placeholders, ", ") + ")"
+
+	if _, err := tx.ExecContext(ctx, insert, insertArgs...); err != nil {
+		return configcore.ErrConfigError("failed to insert SQL key "+dbKey, err)
+	}
+
+	return nil
+}
+
+// nextVersion reads the next table-wide version inside tx. It is a separate
+// query because MySQL rejects a subquery on the table being modified.
+func (ss *SQLSource) nextVersion(ctx context.Context, tx *sql.Tx) (int64, error) {
+	query := "SELECT COALESCE(MAX(" + ss.quote(ss.options.VersionColumn) + "), 0) + 1 FROM " + ss.quote(ss.options.Table) //nolint:gosec // G202: identifiers are validated
+
+	var version int64
+	if err := tx.QueryRowContext(ctx, query).Scan(&version); err != nil {
+		return 0, configcore.ErrConfigError("failed to read next SQL version", err)
+	}
+
+	return version, nil
+}
+
+// keyPrefix returns KeyPrefix ending in the key separator, or "" when no
+// prefix is set.
+func (ss *SQLSource) keyPrefix() string {
+	prefix := ss.options.KeyPrefix
+	if prefix == "" || strings.HasSuffix(prefix, ss.options.KeySeparator) {
+		return prefix
+	}
+
+	return prefix + ss.options.KeySeparator
+}
+
+// quote quotes a validated identifier for the dialect. Each part of a
+// schema-qualified name is quoted separately.
+func (ss *SQLSource) quote(identifier string) string {
+	quote := `"`
+	switch strings.ToLower(ss.options.Dialect) {
+	case "mysql", "mariadb":
+		quote = "`"
+	}
+
+	parts := strings.Split(identifier, ".")
+	for i, part := range parts {
+		parts[i] = quote + part + quote
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// placeholder returns the n-th bind parameter for the dialect.
+func (ss *SQLSource) placeholder(n int) string {
+	switch strings.ToLower(ss.options.Dialect) {
+	case "postgres", "postgresql", "pgx":
+		return fmt.Sprintf("$%d", n)
+	default:
+		return "?"
+	}
+}
+
+// changeMarker returns a value that changes when rows change.
+func (ss *SQLSource) changeMarker(ctx context.Context) (string, error) {
+	column := ss.options.UpdatedAtColumn
+	if ss.options.VersionColumn != "" {
+		column = ss.options.VersionColumn
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ss.options.Timeout)
+	defer cancel()
+
+	var (
+		count   int64
+		maximum sql.NullString
+	)
+
+	query := "SELECT COUNT(*), MAX(" + ss.quote(column) + ") FROM " + ss.quote(ss.options.Table) //nolint:gosec // G202: identifiers are validated
+	if err := ss.db.QueryRowContext(ctx, query).Scan(&count, &maximum); err != nil {
+		return "", configcore.ErrConfigError("failed to query SQL change marker", err)
+	}
+
+	return fmt.Sprintf("%d:%s", count, maximum.String), nil
+}
+
+// watchLoop polls the table until stopped.
+func (ss *SQLSource) watchLoop(ctx context.Context, stop chan struct{}, callback func(map[string]any)) {
+	defer func() {
+		if r := recover(); r != nil {
+			if ss.logger != nil {
+				ss.logger.Error("panic in SQL watch loop",
+					logger.String("table", ss.options.Table),
+					logger.Any("panic", r),
+				)
+			}
+		}
+	}()
+
+	useMarker := ss.options.UpdatedAtColumn != "" || ss.options.VersionColumn != ""
+
+	var previous map[string]any
+
+	if useMarker {
+		if marker, err := ss.changeMarker(ctx); err == nil {
+			ss.mu.Lock()
+			ss.lastMarker = marker
+			ss.mu.Unlock()
+		}
+	} else if data, err := ss.Load(ctx); err == nil {
+		previous = data
+	}
+
+	ticker := time.NewTicker(ss.options.WatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		if useMarker {
+			marker, err := ss.changeMarker(ctx)
+			if err != nil {
+				ss.handleWatchError(err)
+
+				continue
+			}
+
+			ss.mu.Lock()
+			changed := marker != ss.lastMarker
+			ss.lastMarker = marker
+			ss.mu.Unlock()
+
+			if !changed {
+				continue
+			}
+		}
+
+		data, err := ss.Load(ctx)
+		if err != nil {
+			ss.handleWatchError(err)
+
+			continue
+		}
+
+		if !useMarker {
+			if reflect.DeepEqual(previous, data) {
+				continue
+			}
+
+			previous = data
+		}
+
+		if ss.logger != nil {
+			ss.logger.Info("SQL configuration change detected",
+				logger.String("table", ss.options.Table),
+			)
+		}
+
+		if callback != nil {
+			callback(data)
+		}
+	}
+}
+
+// handleWatchError handles errors during watching. Polling continues on the
+// next interval.
+func (ss *SQLSource) handleWatchError(err error) {
+	if ss.logger != nil {
+		ss.logger.Error("SQL watch error",
+			logger.String("table", ss.options.Table),
+			logger.Error(err),
+		)
+	}
+
+	if ss.errorHandler != nil {
+		_ = ss.errorHandler.HandleError(context.Background(), configcore.ErrConfigError("SQL watch error for table "+ss.options.Table, err))
+	}
+}
+
+// parseValue parses a column value. Without a format, JSON is tried first
+// and the raw string is used otherwise.
+func (ss *SQLSource) parseValue(value, format string) (any, error) {
+	switch strings.ToLower(format) {
+	case "string", "text":
+		return value, nil
+	case "", "json":
+		if value == "" {
+			return "", nil
+		}
+
+		var jsonValue any
+		if err := json.Unmarshal([]byte(value), &jsonValue); err == nil {
+			return jsonValue, nil
+		} else if format != "" {
+			return nil, err
+		}
+
+		return value, nil
+	default:
+		processor, err := getFormatProcessor(format)
+		if err != nil {
+			return nil, err
+		}
+
+		return processor.Parse([]byte(value))
+	}
+}
+
+// setNestedValue sets a nested configuration value using the key separator.
+func (ss *SQLSource) setNestedValue(config map[string]any, key string, value any) {
+	setNestedPath(config, strings.Split(key, ss.options.KeySeparator), value)
+}
+
+// encodeSQLValue encodes a value for storage and returns its format.
+func encodeSQLValue(value any) (string, string, error) {
+	if s, ok := value.(string); ok {
+		return s, "string", nil
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", "", err
+	}
+
+	return string(encoded), "json", nil
+}
+
+// SQLSourceFactory creates SQL configuration sources.
+type SQLSourceFactory struct {
+	logger       logger.Logger
+	errorHandler errors.ErrorHandler
+}
+
+// NewSQLSourceFactory creates a new SQL source factory.
+func NewSQLSourceFactory(logger logger.Logger, errorHandler errors.ErrorHandler) *SQLSourceFactory {
+	return &SQLSourceFactory{
+		logger:       logger,
+		errorHandler: errorHandler,
+	}
+}
+
+// CreateFromConfig opens the database and creates an SQL source that owns
+// it; Close the source to release the connection pool. The driver must be
+// registered by the application.
+func (factory *SQLSourceFactory) CreateFromConfig(config SQLSourceConfig) (configcore.ConfigSource, error) {
+	db, err := sql.Open(config.Driver, config.DSN)
+	if err != nil {
+		return nil, configcore.ErrConfigError("failed to open SQL database with driver "+config.Driver, err)
+	}
+
+	options := SQLSourceOptions{
+		Priority:        config.Priority,
+		Table:           config.Table,
+		KeyColumn:       config.KeyColumn,
+		ValueColumn:     config.ValueColumn,
+		FormatColumn:    config.FormatColumn,
+		UpdatedAtColumn: config.UpdatedAtColumn,
+		VersionColumn:   config.VersionColumn,
+		KeyPrefix:       config.KeyPrefix,
+		KeySeparator:    config.KeySeparator,
+		Dialect:         config.Dialect,
+		WatchEnabled:    config.WatchEnabled,
+		WatchInterval:   config.WatchInterval,
+		WriteEnabled:    config.WriteEnabled,
+		Timeout:         config.Timeout,
+		Logger:          factory.logger,
+		ErrorHandler:    factory.errorHandler,
+	}
+
+	source, err := NewSQLSource(db, options)
+	if err != nil {
+		_ = db.Close()
+
+		return nil, err
+	}
+
+	source.(*SQLSource).ownsDB = true
+
+	return source, nil
+}
@@ -0,0 +1,253 @@
+//go:build cgo
+
+package sources
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	configcore "github.com/xraph/confy/internal"
+)
+
+// openTestDB opens a SQLite database with a settings table.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "config.db"))
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	_, err = db.Exec(`CREATE TABLE settings (
+		name       TEXT PRIMARY KEY,
+		value      TEXT,
+		format     TEXT,
+		version    INTEGER NOT NULL DEFAULT 0,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	return db
+}
+
+func testSQLOptions() SQLSourceOptions {
+	return SQLSourceOptions{
+		Table:         "settings",
+		KeyColumn:     "name",
+		FormatColumn:  "format",
+		VersionColumn: "version",
+		KeyPrefix:     "app.",
+		WatchInterval: 20 * time.Millisecond,
+	}
+}
+
+func TestSQLSource_Load(t *testing.T) {
+	db := openTestDB(t)
+
+	_, err := db.Exec(`INSERT INTO settings (name, value, format) VALUES
+		('app.features.search', 'true', NULL),
+		('app.limits.rps', '250', 'json'),
+		('app.banner', '"hello"', 'string'),
+		('app.smtp', ?, 'yaml'),
+		('other.ignored', 'x', NULL)`, "host: mail\nport: 25\n")
+	if err != nil {
+		t.Fatalf("failed to seed: %v", err)
+	}
+
+	source, err := NewSQLSource(db, testSQLOptions())
+	if err != nil {
+		t.Fatalf("NewSQLSource() error = %v", err)
+	}
+
+	if source.Name() != "sql:settings" {
+		t.Errorf("Name() = %s, want sql:settings", source.Name())
+	}
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := map[string]any{
+		"features": map[string]any{"search": true},
+		"limits":   map[string]any{"rps": float64(250)},
+		"banner":   `"hello"`,
+		"smtp":     map[string]any{"host": "mail", "port": 25},
+	}
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("Load() = %#v, want %#v", data, want)
+	}
+}
+
+func TestSQLSource_InvalidIdentifier(t *testing.T) {
+	db := openTestDB(t)
+
+	if _, err := NewSQLSource(db, SQLSourceOptions{Table: "settings; DROP TABLE settings"}); err == nil {
+		t.Error("NewSQLSource() should reject unsafe identifiers")
+	}
+}
+
+func TestSQLSource_PersistAndWatch(t *testing.T) {
+	db := openTestDB(t)
+
+	options := testSQLOptions()
+	options.WatchEnabled = true
+
+	source, _ := NewSQLSource(db, options)
+
+	writable := source.(configcore.WritableSource)
+	if writable.IsWritable() {
+		t.Fatal("IsWritable() = true without WriteEnabled")
+	}
+
+	if err := writable.Persist(context.Background(), "limits.rps", 10); err == nil {
+		t.Fatal("Persist() should fail when writes are disabled")
+	}
+
+	options.WriteEnabled = true
+	source, _ = NewSQLSource(db, options)
+	writable = source.(configcore.WritableSource)
+
+	if err := writable.Persist(context.Background(), "limits", map[string]any{"rps": 10, "burst": 20}); err != nil {
+		t.Fatalf("Persist() error = %v", err)
+	}
+
+	updates := make(chan map[string]any, 4)
+	if err := source.Watch(context.Background(), func(data map[string]any) { updates <- data }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer func() { _ = source.StopWatch() }()
+
+	// Wait for the watch to record its initial marker.
+	time.Sleep(50 * time.Millisecond)
+
+	if err := writable.Persist(context.Background(), "limits.rps", 500); err != nil {
+		t.Fatalf("Persist() update error = %v", err)
+	}
+
+	select {
+	case data := <-updates:
+		limits, _ := data["limits"].(map[string]any)
+		if limits["rps"] != float64(500) || limits["burst"] != float64(20) {
+			t.Errorf("watched data = %v, want updated rps and kept burst", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for SQL watch update")
+	}
+
+	var version int
+	if err := db.QueryRow(`SELECT version FROM settings WHERE name = 'app.limits.rps'`).Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+
+	if version != 2 {
+		t.Errorf("version = %d, want table-wide version 2", version)
+	}
+}
+
+func TestSQLSource_KeyPrefixWithoutSeparator(t *testing.T) {
+	db := openTestDB(t)
+
+	// The default key column is a reserved word in MySQL, so it is quoted.
+	if _, err := db.Exec(`CREATE TABLE config ("key" TEXT PRIMARY KEY, value TEXT)`); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	if _, err := db.Exec(`INSERT INTO config ("key", value) VALUES ('application.name', 'other')`); err != nil {
+		t.Fatalf("failed to seed: %v", err)
+	}
+
+	source, _ := NewSQLSource(db, SQLSourceOptions{KeyPrefix: "app", WriteEnabled: true})
+
+	if err := source.(configcore.WritableSource).Persist(context.Background(), "service.name", "api"); err != nil {
+		t.Fatalf("Persist() error = %v", err)
+	}
+
+	var value string
+	if err := db.QueryRow(`SELECT value FROM config WHERE "key" = 'app.service.name'`).Scan(&value); err != nil {
+		t.Fatalf("row not written under the separated prefix: %v", err)
+	}
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := map[string]any{"service": map[string]any{"name": "api"}}
+	if !reflect.DeepEqual(data, want) {
+		t.Errorf("Load() = %#v, want %#v without keys that only share the prefix", data, want)
+	}
+}
+
+func TestSQLSource_WatchWithoutMarker(t *testing.T) {
+	db := openTestDB(t)
+
+	source, _ := NewSQLSource(db, SQLSourceOptions{
+		Table:         "settings",
+		KeyColumn:     "name",
+		WatchEnabled:  true,
+		WatchInterval: 20 * time.Millisecond,
+	})
+
+	updates := make(chan map[string]any, 4)
+	if err := source.Watch(context.Background(), func(data map[string]any) { updates <- data }); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer func() { _ = source.StopWatch() }()
+
+	time.Sleep(50 * time.Millisecond)
+
+	if _, err := db.Exec(`INSERT INTO settings (name, value) VALUES ('debug', 'true')`); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case data := <-updates:
+		if data["debug"] != true {
+			t.Errorf("watched data = %v, want debug=true", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for SQL watch update")
+	}
+}
+
+func TestSQLSource_Close(t *testing.T) {
+	db := openTestDB(t)
+
+	source, _ := NewSQLSource(db, testSQLOptions())
+	if err := source.(*SQLSource).Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		t.Errorf("Close() closed a database owned by the caller: %v", err)
+	}
+
+	factory := NewSQLSourceFactory(nil, nil)
+
+	created, err := factory.CreateFromConfig(SQLSourceConfig{
+		Driver: "sqlite3",
+		DSN:    filepath.Join(t.TempDir(), "factory.db"),
+	})
+	if err != nil {
+		t.Fatalf("CreateFromConfig() error = %v", err)
+	}
+
+	owned := created.(*SQLSource)
+	if err := owned.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if err := owned.db.Ping(); err == nil {
+		t.Error("Close() should close the database opened by the factory")
+	}
+}
@@ -0,0 +1,178 @@
+package sources
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	configcore "github.com/xraph/confy/internal"
+	errors "github.com/xraph/go-utils/errs"
+	logger "github.com/xraph/go-utils/log"
+)
+
+// StructSource exposes the field values and `default` tags of a struct as a
+// configuration source, so that defaults are visible through Get*,
+// GetAllSettings and exports rather than only during Bind.
+type StructSource struct {
+	name         string
+	prefix       string
+	value        any
+	priority     int
+	logger       logger.Logger
+	errorHandler errors.ErrorHandler
+	options      StructSourceOptions
+}
+
+// StructSourceOptions contains options for struct sources.
+type StructSourceOptions struct {
+	Name string
+	// Priority defaults to 0 so that every other source overrides the defaults.
+	Priority int
+	// TagName selects the struct tag used for key names. yaml and json tags
+	// are always honoured; a custom tag takes precedence when set.
+	TagName      string
+	Logger       logger.Logger
+	ErrorHandler errors.ErrorHandler
+}
+
+// NewStructSource creates a source from a struct value (or pointer to one),
+// mounted under the dot-separated prefix. Zero-valued fields with a
+// `default:"..."` tag take the tag value; non-zero fields are kept as is.
+// The struct is read on every Load, so a pointer reflects later changes.
+func NewStructSource(prefix string, value any, options StructSourceOptions) (configcore.ConfigSource, error) {
+	typ := reflect.TypeOf(value)
+	if typ != nil && typ.Kind() == reflect.Ptr {
+		if reflect.ValueOf(value).IsNil() {
+			return nil, configcore.ErrConfigError("struct source value cannot be a nil pointer", nil)
+		}
+
+		typ = typ.Elem()
+	}
+
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil, configcore.ErrConfigError("struct source value must be a struct", nil)
+	}
+
+	prefix = strings.Trim(prefix, ".")
+
+	name := options.Name
+	if name == "" {
+		if prefix != "" {
+			name = "struct:" + prefix
+		} else {
+			name = "struct:" + typ.Name()
+		}
+	}
+
+	if options.TagName == "" {
+		options.TagName = "yaml"
+	}
+
+	return &StructSource{
+		name:         name,
+		prefix:       prefix,
+		value:        value,
+		priority:     options.Priority,
+		logger:       options.Logger,
+		errorHandler: options.ErrorHandler,
+		options:      options,
+	}, nil
+}
+
+// Name returns the source name.
+func (ss *StructSource) Name() string {
+	return ss.name
+}
+
+// GetName returns the source name (alias for Name).
+func (ss *StructSource) GetName() string {
+	return ss.name
+}
+
+// GetType returns the source type.
+func (ss *StructSource) GetType() string {
+	return "struct"
+}
+
+// IsAvailable always returns true.
+func (ss *StructSource) IsAvailable(ctx context.Context) bool {
+	return true
+}
+
+// Priority returns the source priority.
+func (ss *StructSource) Priority() int {
+	return ss.priority
+}
+
+// Load converts the struct, with defaults applied, to configuration data.
+func (ss *StructSource) Load(ctx context.Context) (map[string]any, error) {
+	// Work on a copy so that applying defaults never mutates the caller's value
+	val := reflect.ValueOf(ss.value)
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+
+	copied := reflect.New(val.Type()).Elem()
+	copied.Set(val)
+
+	if err := configcore.ApplyStructDefaults(copied); err != nil {
+		return nil, configcore.ErrConfigError("failed to apply struct defaults for "+ss.name, err)
+	}
+
+	data, err := configcore.StructToMap(copied.Interface(), ss.options.TagName)
+	if err != nil {
+		return nil, configcore.ErrConfigError("failed to convert struct for "+ss.name, err)
+	}
+
+	if ss.prefix != "" {
+		result := make(map[string]any)
+		setNestedPath(result, strings.Split(ss.prefix, "."), data)
+		data = result
+	}
+
+	if ss.logger != nil {
+		ss.logger.Debug("configuration loaded from struct",
+			logger.String("name", ss.name),
+			logger.String("prefix", ss.prefix),
+		)
+	}
+
+	return data, nil
+}
+
+// Watch is not supported by struct sources.
+func (ss *StructSource) Watch(ctx context.Context, callback func(map[string]any)) error {
+	return configcore.ErrConfigError("struct source does not support watching", nil)
+}
+
+// StopWatch is a no-op for struct sources.
+func (ss *StructSource) StopWatch() error {
+	return nil
+}
+
+// Reload reloads the configuration.
+func (ss *StructSource) Reload(ctx context.Context) error {
+	_, err := ss.Load(ctx)
+
+	return err
+}
+
+// IsWatchable returns false.
+func (ss *StructSource) IsWatchable() bool {
+	return false
+}
+
+// SupportsSecrets returns false.
+func (ss *StructSource) SupportsSecrets() bool {
+	return false
+}
+
+// GetSecret is not supported by struct sources.
+func (ss *StructSource) GetSecret(ctx context.Context, key string) (string, error) {
+	return "", configcore.ErrConfigError("struct source does not support secrets", nil)
+}
+
+// GetPrefix returns the prefix the struct is mounted under.
+func (ss *StructSource) GetPrefix() string {
+	return ss.prefix
+}
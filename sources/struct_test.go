@@ -0,0 +1,101 @@
+package sources
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type structTestServer struct {
+	Host    string        `default:"0.0.0.0" yaml:"host"`
+	Port    int           `default:"8080"    yaml:"port"`
+	Timeout time.Duration `default:"30s"     yaml:"timeout"`
+	TLS     struct {
+		Enabled bool `default:"true" yaml:"enabled"`
+	} `yaml:"tls"`
+	internal string
+}
+
+func TestStructSource_Load(t *testing.T) {
+	source, err := NewStructSource("server", structTestServer{Port: 9090}, StructSourceOptions{})
+	if err != nil {
+		t.Fatalf("NewStructSource() error = %v", err)
+	}
+
+	if source.Name() != "struct:server" || source.Priority() != 0 {
+		t.Errorf("identity = %s/%d, want struct:server/0", source.Name(), source.Priority())
+	}
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	server, ok := data["server"].(map[string]any)
+	if !ok {
+		t.Fatalf("server section missing: %v", data)
+	}
+
+	if server["host"] != "0.0.0.0" {
+		t.Errorf("host = %v, want default 0.0.0.0", server["host"])
+	}
+
+	if server["port"] != 9090 {
+		t.Errorf("port = %v, want explicit 9090", server["port"])
+	}
+
+	if server["timeout"] != 30*time.Second {
+		t.Errorf("timeout = %v, want 30s", server["timeout"])
+	}
+
+	if tls, _ := server["tls"].(map[string]any); tls["enabled"] != true {
+		t.Errorf("tls = %v, want enabled default", server["tls"])
+	}
+
+	if _, ok := server["internal"]; ok {
+		t.Error("unexported field should not be exposed")
+	}
+}
+
+func TestStructSource_PointerIsNotMutated(t *testing.T) {
+	value := &structTestServer{}
+
+	source, err := NewStructSource("", value, StructSourceOptions{})
+	if err != nil {
+		t.Fatalf("NewStructSource() error = %v", err)
+	}
+
+	if source.Name() != "struct:structTestServer" {
+		t.Errorf("Name() = %s, want struct:structTestServer", source.Name())
+	}
+
+	data, err := source.Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if data["port"] != 8080 {
+		t.Errorf("port = %v, want 8080", data["port"])
+	}
+
+	if value.Port != 0 {
+		t.Errorf("caller value mutated: Port = %d", value.Port)
+	}
+
+	value.Host = "example.com"
+
+	data, _ = source.Load(context.Background())
+	if data["host"] != "example.com" {
+		t.Errorf("host = %v, want later pointer update", data["host"])
+	}
+}
+
+func TestStructSource_InvalidValue(t *testing.T) {
+	if _, err := NewStructSource("x", 42, StructSourceOptions{}); err == nil {
+		t.Error("NewStructSource() with non-struct should fail")
+	}
+
+	if _, err := NewStructSource("x", (*structTestServer)(nil), StructSourceOptions{}); err == nil {
+		t.Error("NewStructSource() with nil pointer should fail")
+	}
+}
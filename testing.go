@@ -27,6 +27,19 @@ type TestConfyImpl struct {
 	mu              sync.RWMutex
 	name            string
 	secretsManager  SecretsManager
+
+	// Persist simulation
+	writable     bool
+	persistError error
+	persistCalls []PersistCall
+}
+
+// PersistCall represents a tracked Persist call.
+type PersistCall struct {
+	Key       string
+	Value     any
+	Timestamp time.Time
+	Error     error
 }
 
 // NewTestConfyImpl creates a new test configuration.
@@ -961,6 +974,65 @@ func (t *TestConfyImpl) Set(key string, value any) {
 	t.notifyChangeCallbacks(change)
 }
 
+// Persist records the call and, like ConfyImpl.Persist, fails unless a
+// writable source is simulated with SetWritable. On success the value is set.
+func (t *TestConfyImpl) Persist(ctx context.Context, key string, value any) error {
+	t.mu.Lock()
+
+	var err error
+
+	switch {
+	case !t.writable:
+		err = ErrConfigError("no writable configuration source registered", nil)
+	case t.persistError != nil:
+		err = ErrConfigError(fmt.Sprintf("failed to persist configuration key '%s'", key), t.persistError)
+	}
+
+	t.persistCalls = append(t.persistCalls, PersistCall{
+		Key:       key,
+		Value:     value,
+		Timestamp: time.Now(),
+		Error:     err,
+	})
+	t.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	t.Set(key, value)
+
+	return nil
+}
+
+// SetWritable simulates whether a writable source is registered.
+func (t *TestConfyImpl) SetWritable(writable bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.writable = writable
+}
+
+// SetPersistError configures Persist to fail as if the writable source
+// returned err.
+func (t *TestConfyImpl) SetPersistError(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.persistError = err
+}
+
+// GetPersistCalls returns all tracked Persist calls.
+func (t *TestConfyImpl) GetPersistCalls() []PersistCall {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	calls := make([]PersistCall, len(t.persistCalls))
+	copy(calls, t.persistCalls)
+
+	return calls
+}
+
 // =============================================================================
 // BINDING METHODS
 // =============================================================================
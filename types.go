@@ -7,6 +7,9 @@ import (
 // Confy is the main interface for configuration management.
 type Confy = internal.Confy
 
+// PersistableConfy is a Confy that can write values back to a writable source.
+type PersistableConfy = internal.PersistableConfy
+
 // =============================================================================
 // ERROR CONSTRUCTORS
 // =============================================================================
@@ -30,6 +33,12 @@ var (
 	// ErrSourceError creates a source-related error.
 	ErrSourceError = internal.ErrSourceError
 
+	// ErrWriteConflict creates a write conflict error.
+	ErrWriteConflict = internal.ErrWriteConflict
+
+	// IsWriteConflict returns true if err wraps a write conflict.
+	IsWriteConflict = internal.IsWriteConflict
+
 	// ErrLoaderError creates a loader error.
 	ErrLoaderError = internal.ErrLoaderError
 